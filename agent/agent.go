@@ -10,7 +10,9 @@ import (
 	"github.com/hongyuxuan/lizardcd/agent/internal/config"
 	"github.com/hongyuxuan/lizardcd/agent/internal/server"
 	"github.com/hongyuxuan/lizardcd/agent/internal/svc"
+	"github.com/hongyuxuan/lizardcd/agent/internal/tunnel"
 	"github.com/hongyuxuan/lizardcd/agent/types/agent"
+	"github.com/hongyuxuan/lizardcd/common/utils"
 	"github.com/nacos-group/nacos-sdk-go/v2/common/constant"
 
 	"github.com/zeromicro/go-zero/core/logx"
@@ -19,6 +21,7 @@ import (
 	"github.com/zeromicro/zero-contrib/zrpc/registry/consul"
 	"github.com/zeromicro/zero-contrib/zrpc/registry/nacos"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
 	"google.golang.org/grpc/reflection"
 	"gopkg.in/alecthomas/kingpin.v2"
 )
@@ -38,6 +41,11 @@ var (
 	kubeconfig       = kingpin.Flag("kubeconfig", "Kubeconfig file, must be specified when agent is out-of-k8s deployed").Default("").String()
 	listenOn         = kingpin.Flag("grpc-addr", "Grpc listen address.").Default("").String()
 	metricsListenOn  = kingpin.Flag("metrics-addr", "Prometheus metrics listen address.").Default("").String()
+	tlsCert          = kingpin.Flag("tls-cert", "Server certificate of mutual tls.").Default("").String()
+	tlsKey           = kingpin.Flag("tls-key", "Server key of mutual tls.").Default("").String()
+	tlsCa            = kingpin.Flag("tls-ca", "CA verifying the client certificate of lizardcd-server.").Default("").String()
+	rpcSecret        = kingpin.Flag("rpc-secret", "Shared secret verifying the rpc tokens of lizardcd-server.").Envar("LIZARDCD_RPC_SECRET").Default("").String()
+	tunnelServer     = kingpin.Flag("tunnel-server", "Tunnel address of lizardcd-server. If specified, agent dials out to the server instead of listening on grpc-addr and registering to etcd, consul or nacos.").Default("").String()
 
 	/* print app version */
 	AppVersion = "unknown"
@@ -68,21 +76,55 @@ func main() {
 		servicePrefix,
 		kubeconfig,
 		listenOn,
-		metricsListenOn)
+		metricsListenOn,
+		tlsCert,
+		tlsKey,
+		tlsCa,
+		rpcSecret,
+		tunnelServer)
 
 	logx.DisableStat()
 	logx.MustSetup(c.Log)
 
 	ctx := svc.NewServiceContext(c)
-	s := zrpc.MustNewServer(c.RpcServerConf, func(grpcServer *grpc.Server) {
+	register := func(grpcServer *grpc.Server) {
 		agent.RegisterLizardAgentServer(grpcServer, server.NewLizardAgentServer(ctx))
-		logx.Infof("Lizardcd-agent: %s register to etcd success", c.Etcd.Key)
+		if len(c.Etcd.Hosts) > 0 && c.Tunnel.Server == "" {
+			logx.Infof("Lizardcd-agent: %s register to etcd success", c.Etcd.Key)
+		}
 
 		if c.Mode == service.DevMode || c.Mode == service.TestMode {
 			reflection.Register(grpcServer)
 		}
-	})
+	}
+
+	// tunnel mode, no port is listened and no registry is needed
+	if c.Tunnel.Server != "" {
+		if c.Tls.CaFile == "" && c.RpcSecret == "" {
+			logx.Errorf("Neither Tls.CaFile nor RpcSecret is set, lizardcd-server and lizardcd-agent are not authenticated")
+		}
+		tunnel.Serve(c, register)
+		return
+	}
+
+	s := zrpc.MustNewServer(c.RpcServerConf, register)
 	defer s.Stop()
+	if c.Tls.Enabled() {
+		tlsConfig, err := c.Tls.ServerConfig()
+		if err != nil {
+			logx.Errorf("Failed to load tls config: %v", err)
+			os.Exit(0)
+		}
+		s.AddOptions(grpc.Creds(credentials.NewTLS(tlsConfig)))
+	}
+	if c.RpcSecret != "" {
+		auth := utils.NewRpcAuthenticator(c.RpcSecret)
+		s.AddUnaryInterceptors(auth.UnaryInterceptor)
+		s.AddStreamInterceptors(auth.StreamInterceptor)
+	}
+	if c.Tls.CaFile == "" && c.RpcSecret == "" {
+		logx.Errorf("Neither Tls.CaFile nor RpcSecret is set, anyone reaching %s can call lizardcd-agent", c.ListenOn)
+	}
 
 	// register service to consul
 	if c.Consul.Host != "" {
@@ -15,11 +15,20 @@ import (
 
 type Config struct {
 	zrpc.RpcServerConf
-	Consul                 consul.Conf `json:",optional"`
-	Nacos                  NacosConf   `json:",optional"`
-	Kubeconfig             string      `json:",optional"`
-	ServicePrefix          string      `json:",optional"`
-	KubernetesSecretPrefix string      `json:",optional"`
+	Consul                 consul.Conf   `json:",optional"`
+	Nacos                  NacosConf     `json:",optional"`
+	Kubeconfig             string        `json:",optional"`
+	ServicePrefix          string        `json:",optional"`
+	KubernetesSecretPrefix string        `json:",optional"`
+	Tls                    utils.TlsConf `json:",optional"` // CaFile verifies the client certificate of lizardcd-server
+	RpcSecret              string        `json:",optional"` // shared secret with lizardcd-server, verifying the token of each rpc
+	Tunnel                 TunnelConf    `json:",optional"`
+}
+
+// TunnelConf is the tunnel of lizardcd-server, the agent dials out to it instead of listening on ListenOn and registering to etcd, consul or nacos
+type TunnelConf struct {
+	Server string `json:",optional"` // e.g. lizardcd-server:5118, tunnel mode is disabled if empty
+	Key    string `json:",optional"` // lizardcd-agent.<namespace>.<cluster>
 }
 
 type NacosConf struct {
@@ -33,7 +42,7 @@ type NacosConf struct {
 }
 
 func NewConfig(configFile, logLevel, consulHost, etcdHost, nacosHost, nacosNamespaceId, nacosUsername, nacosPassword, nacosGroup,
-	serviceKey, servicePrefix, kubeconfig, listenOn, metricsListenOn *string) Config {
+	serviceKey, servicePrefix, kubeconfig, listenOn, metricsListenOn, tlsCert, tlsKey, tlsCa, rpcSecret, tunnelServer *string) Config {
 	var c = Config{}
 	if *configFile != "" {
 		conf.MustLoad(*configFile, &c)
@@ -86,6 +95,12 @@ func NewConfig(configFile, logLevel, consulHost, etcdHost, nacosHost, nacosNames
 			c.Nacos.Key = *serviceKey
 		}
 	}
+	if *tunnelServer != "" {
+		c.Tunnel.Server = *tunnelServer
+		if *serviceKey != "" {
+			c.Tunnel.Key = *serviceKey
+		}
+	}
 	if *nacosNamespaceId != "" {
 		c.Nacos.NamespaceId = *nacosNamespaceId
 	}
@@ -101,6 +116,7 @@ func NewConfig(configFile, logLevel, consulHost, etcdHost, nacosHost, nacosNames
 	c.Consul.Key = c.ServicePrefix + c.Consul.Key
 	c.Etcd.Key = c.ServicePrefix + c.Etcd.Key
 	c.Nacos.Key = c.ServicePrefix + c.Nacos.Key
+	c.Tunnel.Key = c.ServicePrefix + c.Tunnel.Key
 	if *kubeconfig != "" {
 		c.Kubeconfig = *kubeconfig
 	}
@@ -113,13 +129,28 @@ func NewConfig(configFile, logLevel, consulHost, etcdHost, nacosHost, nacosNames
 		port, _ := strconv.Atoi(arr[1])
 		c.Prometheus.Port = port
 	}
+	if *tlsCert != "" {
+		c.Tls.CertFile = *tlsCert
+	}
+	if *tlsKey != "" {
+		c.Tls.KeyFile = *tlsKey
+	}
+	if *tlsCa != "" {
+		c.Tls.CaFile = *tlsCa
+	}
+	if *rpcSecret != "" {
+		c.RpcSecret = *rpcSecret
+	}
 	if c.KubernetesSecretPrefix == "" {
 		c.KubernetesSecretPrefix = "default-token" // default token prefix
 	}
-	if len(c.Etcd.Hosts) == 0 && c.Consul.Host == "" && c.Nacos.Host == "" {
-		logx.Errorf("Either etcd host, consul host or nacos host must be specified.")
-		os.Exit(0)
+	if len(c.Etcd.Hosts) == 0 && c.Consul.Host == "" && c.Nacos.Host == "" && c.Tunnel.Server == "" {
+		logx.Infof("Neither etcd host, consul host, nacos host nor tunnel server is specified, the agent should be added to static agents of lizardcd-server")
+	}
+	logged := c
+	if logged.RpcSecret != "" {
+		logged.RpcSecret = utils.SecretMask
 	}
-	logx.Infof("Using config: %+v", c)
+	logx.Infof("Using config: %+v", logged)
 	return c
 }
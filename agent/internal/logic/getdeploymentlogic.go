@@ -8,6 +8,7 @@ import (
 	"github.com/hongyuxuan/lizardcd/agent/types/agent"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
+	"k8s.io/apimachinery/pkg/api/errors"
 
 	"github.com/zeromicro/go-zero/core/logx"
 )
@@ -30,7 +31,9 @@ func NewGetDeploymentLogic(ctx context.Context, svcCtx *svc.ServiceContext) *Get
 
 func (l *GetDeploymentLogic) GetDeployment(in *agent.GetWorkloadRequest) (*agent.Response, error) {
 	res, err := l.K8sService.GetDeployment(in.Namespace, in.WorkloadName)
-	if err != nil {
+	if errors.IsNotFound(err) {
+		return nil, status.Error(codes.NotFound, err.Error())
+	} else if err != nil {
 		return nil, status.Error(codes.Internal, err.Error())
 	}
 	data, _ := json.Marshal(res)
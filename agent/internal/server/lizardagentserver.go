@@ -133,6 +133,16 @@ func (s *LizardAgentServer) GetStatefulsetQuota(ctx context.Context, in *agent.G
 	return l.GetStatefulsetQuota(in)
 }
 
+func (s *LizardAgentServer) GetDeploymentRolloutStatus(ctx context.Context, in *agent.GetWorkloadRequest) (*agent.Response, error) {
+	l := logic.NewGetDeploymentRolloutStatusLogic(ctx, s.svcCtx)
+	return l.GetDeploymentRolloutStatus(in)
+}
+
+func (s *LizardAgentServer) GetStatefulsetRolloutStatus(ctx context.Context, in *agent.GetWorkloadRequest) (*agent.Response, error) {
+	l := logic.NewGetStatefulsetRolloutStatusLogic(ctx, s.svcCtx)
+	return l.GetStatefulsetRolloutStatus(in)
+}
+
 // istio
 func (s *LizardAgentServer) CreateDestinationRule(ctx context.Context, in *agent.IstioCreateRequest) (*agent.Response, error) {
 	l := logic.NewCreateDestinationRuleLogic(ctx, s.svcCtx)
@@ -5,6 +5,8 @@ import (
 	"context"
 	"fmt"
 	"io"
+	"sort"
+	"strconv"
 	"strings"
 	"time"
 
@@ -602,6 +604,121 @@ func (k8s *K8sService) GetStatefulsetResourceQuota(namespace, workloadName strin
 	return &res.Spec.Template.Spec.Containers[0].Resources, nil
 }
 
+// GetDeploymentRolloutStatus reports the rollout progress of a deployment, the same as `kubectl rollout status`
+func (k8s *K8sService) GetDeploymentRolloutStatus(namespace, workloadName string) (*commontypes.RolloutStatus, error) {
+	res, err := k8s.svcCtx.Clientset.AppsV1().Deployments(namespace).Get(k8s.ctx, workloadName, metav1.GetOptions{})
+	if err != nil {
+		return nil, errorx.NewDefaultError(err.Error())
+	}
+	pods, err := k8s.GetPodStatus(namespace, res.Spec.Template.ObjectMeta.Labels)
+	if err != nil {
+		return nil, err
+	}
+	replicas := int32(1)
+	if res.Spec.Replicas != nil {
+		replicas = *res.Spec.Replicas
+	}
+	status := &commontypes.RolloutStatus{
+		Name:               workloadName,
+		Generation:         res.Generation,
+		ObservedGeneration: res.Status.ObservedGeneration,
+		Replicas:           replicas,
+		UpdatedReplicas:    res.Status.UpdatedReplicas,
+		ReadyReplicas:      res.Status.ReadyReplicas,
+		AvailableReplicas:  res.Status.AvailableReplicas,
+		Pods:               processPodStatus(pods),
+	}
+	// the status and conditions may still be of the previous rollout until the controller observes the new generation
+	if res.Status.ObservedGeneration < res.Generation {
+		return status, nil
+	}
+	for _, c := range res.Status.Conditions {
+		if c.Type == v1.DeploymentProgressing && c.Reason == "ProgressDeadlineExceeded" {
+			status.Failed = true
+			status.Message = c.Message
+			return status, nil
+		}
+	}
+	// old replicas are still running until status.replicas equals to status.updatedReplicas
+	status.Finished = res.Status.UpdatedReplicas >= replicas &&
+		res.Status.Replicas <= res.Status.UpdatedReplicas &&
+		res.Status.AvailableReplicas >= res.Status.UpdatedReplicas
+	return status, nil
+}
+
+// GetStatefulsetRolloutStatus reports the rolling update progress of a statefulset. Pods are updated one by one in reverse ordinal order,
+// the update is finished when all pods (except the partitioned ones) are ready with the update revision.
+func (k8s *K8sService) GetStatefulsetRolloutStatus(namespace, workloadName string) (*commontypes.RolloutStatus, error) {
+	res, err := k8s.svcCtx.Clientset.AppsV1().StatefulSets(namespace).Get(k8s.ctx, workloadName, metav1.GetOptions{})
+	if err != nil {
+		return nil, errorx.NewDefaultError(err.Error())
+	}
+	pods, err := k8s.GetPodStatus(namespace, res.Spec.Template.ObjectMeta.Labels)
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(pods, func(i, j int) bool {
+		return podOrdinal(pods[i].Name) < podOrdinal(pods[j].Name)
+	})
+	replicas := int32(1)
+	if res.Spec.Replicas != nil {
+		replicas = *res.Spec.Replicas
+	}
+	var partition int32
+	if res.Spec.UpdateStrategy.RollingUpdate != nil && res.Spec.UpdateStrategy.RollingUpdate.Partition != nil {
+		partition = *res.Spec.UpdateStrategy.RollingUpdate.Partition
+	}
+	status := &commontypes.RolloutStatus{
+		Name:               workloadName,
+		Generation:         res.Generation,
+		ObservedGeneration: res.Status.ObservedGeneration,
+		Replicas:           replicas,
+		UpdatedReplicas:    res.Status.UpdatedReplicas,
+		ReadyReplicas:      res.Status.ReadyReplicas,
+		AvailableReplicas:  res.Status.AvailableReplicas,
+		CurrentRevision:    res.Status.CurrentRevision,
+		UpdateRevision:     res.Status.UpdateRevision,
+		Pods:               processPodStatus(pods),
+	}
+	status.Finished = res.Status.ObservedGeneration >= res.Generation &&
+		res.Status.UpdatedReplicas >= replicas-partition &&
+		res.Status.ReadyReplicas >= replicas &&
+		// currentRevision is set to updateRevision when all pods are updated
+		(partition > 0 || res.Status.CurrentRevision == res.Status.UpdateRevision)
+	return status, nil
+}
+
+// processPodStatus returns the ready status of pods, with the reason if a container is waiting abnormally
+func processPodStatus(pods []corev1.Pod) (res []commontypes.PodStatus) {
+	for _, pod := range pods {
+		podStatus := commontypes.PodStatus{
+			PodName:  pod.Name,
+			Revision: pod.Labels[v1.ControllerRevisionHashLabelKey],
+		}
+		for _, c := range pod.Status.Conditions {
+			if c.Type == corev1.PodReady {
+				podStatus.Ready = string(c.Status)
+				break
+			}
+		}
+		for _, c := range append(pod.Status.InitContainerStatuses, pod.Status.ContainerStatuses...) {
+			if c.State.Waiting != nil && c.State.Waiting.Reason != "ContainerCreating" && c.State.Waiting.Reason != "PodInitializing" {
+				podStatus.Reason = c.State.Waiting.Reason
+				podStatus.Message = c.State.Waiting.Message
+				break
+			}
+		}
+		res = append(res, podStatus)
+	}
+	return
+}
+
+// podOrdinal returns the ordinal of a statefulset pod, e.g. 2 for mysql-2
+func podOrdinal(podName string) int {
+	ordinal, _ := strconv.Atoi(podName[strings.LastIndex(podName, "-")+1:])
+	return ordinal
+}
+
 func processDeploymentItems(listRes *v1.DeploymentList) []v1.Deployment {
 	for i := range listRes.Items {
 		listRes.Items[i].ManagedFields = nil
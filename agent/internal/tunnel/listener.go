@@ -0,0 +1,147 @@
+package tunnel
+
+import (
+	"crypto/tls"
+	"errors"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/hongyuxuan/lizardcd/agent/internal/config"
+	"github.com/hongyuxuan/lizardcd/common/utils"
+	"github.com/zeromicro/go-zero/core/logx"
+)
+
+const (
+	dialTimeout   = 10 * time.Second
+	retryInterval = 5 * time.Second
+)
+
+// Listener dials out to the tunnel of lizardcd-server instead of listening on a port.
+// Each accepted connection is a tunnel on which lizardcd-server calls the agent, the next one is dialed after it is closed.
+type Listener struct {
+	server     string
+	serviceKey string
+	secret     string
+	tlsConfig  *tls.Config
+	idle       chan struct{} // holds a token while no tunnel is open
+	closed     chan struct{}
+	closeOnce  sync.Once
+}
+
+func NewListener(c config.Config) (*Listener, error) {
+	l := &Listener{
+		server:     c.Tunnel.Server,
+		serviceKey: c.Tunnel.Key,
+		secret:     c.RpcSecret,
+		idle:       make(chan struct{}, 1),
+		closed:     make(chan struct{}),
+	}
+	if c.Tls.Enabled() {
+		tlsConfig, err := c.Tls.ClientConfig()
+		if err != nil {
+			return nil, err
+		}
+		if tlsConfig.ServerName == "" {
+			host, _, err := net.SplitHostPort(c.Tunnel.Server)
+			if err != nil {
+				return nil, err
+			}
+			tlsConfig.ServerName = host
+		}
+		l.tlsConfig = tlsConfig
+	}
+	l.idle <- struct{}{}
+	return l, nil
+}
+
+func (l *Listener) Accept() (net.Conn, error) {
+	select {
+	case <-l.idle:
+	case <-l.closed:
+		return nil, net.ErrClosed
+	}
+	for {
+		conn, err := l.dial()
+		if err == nil {
+			logx.Infof("Lizardcd-agent: %s connected to tunnel of lizardcd-server %s", l.serviceKey, l.server)
+			return &tunnelConn{Conn: conn, idle: l.idle}, nil
+		}
+		logx.Errorf("Failed to connect to tunnel of lizardcd-server %s: %v", l.server, err)
+		select {
+		case <-time.After(retryInterval):
+		case <-l.closed:
+			return nil, net.ErrClosed
+		}
+	}
+}
+
+func (l *Listener) Close() error {
+	l.closeOnce.Do(func() {
+		close(l.closed)
+	})
+	return nil
+}
+
+func (l *Listener) Addr() net.Addr {
+	return tunnelAddr(l.server)
+}
+
+func (l *Listener) dial() (conn net.Conn, err error) {
+	if conn, err = net.DialTimeout("tcp", l.server, dialTimeout); err != nil {
+		return
+	}
+	defer func() {
+		if err != nil {
+			conn.Close()
+		}
+	}()
+	conn.SetDeadline(time.Now().Add(dialTimeout))
+	if l.tlsConfig != nil {
+		conn = tls.Client(conn, l.tlsConfig)
+	}
+	hello := utils.TunnelHello{ServiceKey: l.serviceKey}
+	if l.secret != "" {
+		if hello.Token, err = utils.SignTunnelToken(l.secret); err != nil {
+			return
+		}
+	}
+	if err = utils.WriteTunnelFrame(conn, hello); err != nil {
+		return
+	}
+	var ack utils.TunnelAck
+	if err = utils.ReadTunnelFrame(conn, &ack); err != nil {
+		return
+	}
+	if ack.Error != "" {
+		err = errors.New(ack.Error)
+		return
+	}
+	err = conn.SetDeadline(time.Time{})
+	return
+}
+
+// tunnelConn returns the token of idle when closed, so that the listener dials the next tunnel
+type tunnelConn struct {
+	net.Conn
+	idle      chan struct{}
+	closeOnce sync.Once
+}
+
+func (c *tunnelConn) Close() error {
+	err := c.Conn.Close()
+	c.closeOnce.Do(func() {
+		c.idle <- struct{}{}
+	})
+	return err
+}
+
+type tunnelAddr string
+
+func (a tunnelAddr) Network() string {
+	return "tunnel"
+}
+
+func (a tunnelAddr) String() string {
+	return string(a)
+}
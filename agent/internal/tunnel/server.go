@@ -0,0 +1,80 @@
+package tunnel
+
+import (
+	"context"
+	"runtime/debug"
+	"time"
+
+	"github.com/hongyuxuan/lizardcd/agent/internal/config"
+	"github.com/hongyuxuan/lizardcd/common/utils"
+	"github.com/zeromicro/go-zero/core/logx"
+	"github.com/zeromicro/go-zero/core/proc"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/keepalive"
+	"google.golang.org/grpc/status"
+)
+
+// Serve serves the rpcs of lizardcd-server on the tunnels dialed out, instead of zrpc server listening on ListenOn.
+// The tunnels are protected by Tls, and each rpc is still authenticated by RpcSecret.
+func Serve(c config.Config, register func(*grpc.Server)) {
+	c.MustSetUp()
+	lis, err := NewListener(c)
+	if err != nil {
+		logx.Errorf("Failed to create tunnel: %v", err)
+		return
+	}
+	unaryInterceptors := []grpc.UnaryServerInterceptor{unaryRecoverInterceptor}
+	streamInterceptors := []grpc.StreamServerInterceptor{streamRecoverInterceptor}
+	if c.RpcSecret != "" {
+		auth := utils.NewRpcAuthenticator(c.RpcSecret)
+		unaryInterceptors = append(unaryInterceptors, auth.UnaryInterceptor)
+		streamInterceptors = append(streamInterceptors, auth.StreamInterceptor)
+	}
+	server := grpc.NewServer(
+		grpc.ChainUnaryInterceptor(unaryInterceptors...),
+		grpc.ChainStreamInterceptor(streamInterceptors...),
+		// pings detect the tunnels broken, e.g. dropped by NAT, and lizardcd-server pings by Rpc.KeepaliveTime
+		grpc.KeepaliveParams(keepalive.ServerParameters{
+			Time:    time.Minute,
+			Timeout: 10 * time.Second,
+		}),
+		grpc.KeepaliveEnforcementPolicy(keepalive.EnforcementPolicy{
+			MinTime:             10 * time.Second,
+			PermitWithoutStream: true,
+		}),
+	)
+	register(server)
+	waitForCalled := proc.AddShutdownListener(func() {
+		server.GracefulStop()
+	})
+	defer waitForCalled()
+
+	logx.Infof("Connecting to tunnel of lizardcd-server at %s...", c.Tunnel.Server)
+	if err = server.Serve(lis); err != nil {
+		logx.Error(err)
+	}
+}
+
+func unaryRecoverInterceptor(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (resp any, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = panicError(r)
+		}
+	}()
+	return handler(ctx, req)
+}
+
+func streamRecoverInterceptor(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = panicError(r)
+		}
+	}()
+	return handler(srv, ss)
+}
+
+func panicError(r any) error {
+	logx.Errorf("%+v\n\n%s", r, debug.Stack())
+	return status.Errorf(codes.Internal, "panic: %v", r)
+}
@@ -57,6 +57,8 @@ type (
 		GetStatefulset(ctx context.Context, in *GetWorkloadRequest, opts ...grpc.CallOption) (*Response, error)
 		GetDeploymentQuota(ctx context.Context, in *GetWorkloadRequest, opts ...grpc.CallOption) (*Response, error)
 		GetStatefulsetQuota(ctx context.Context, in *GetWorkloadRequest, opts ...grpc.CallOption) (*Response, error)
+		GetDeploymentRolloutStatus(ctx context.Context, in *GetWorkloadRequest, opts ...grpc.CallOption) (*Response, error)
+		GetStatefulsetRolloutStatus(ctx context.Context, in *GetWorkloadRequest, opts ...grpc.CallOption) (*Response, error)
 		// istio
 		CreateDestinationRule(ctx context.Context, in *IstioCreateRequest, opts ...grpc.CallOption) (*Response, error)
 		PatchDestinationRule(ctx context.Context, in *IstioPatchRequest, opts ...grpc.CallOption) (*Response, error)
@@ -204,6 +206,16 @@ func (m *defaultLizardAgent) GetStatefulsetQuota(ctx context.Context, in *GetWor
 	return client.GetStatefulsetQuota(ctx, in, opts...)
 }
 
+func (m *defaultLizardAgent) GetDeploymentRolloutStatus(ctx context.Context, in *GetWorkloadRequest, opts ...grpc.CallOption) (*Response, error) {
+	client := agent.NewLizardAgentClient(m.cli.Conn())
+	return client.GetDeploymentRolloutStatus(ctx, in, opts...)
+}
+
+func (m *defaultLizardAgent) GetStatefulsetRolloutStatus(ctx context.Context, in *GetWorkloadRequest, opts ...grpc.CallOption) (*Response, error) {
+	client := agent.NewLizardAgentClient(m.cli.Conn())
+	return client.GetStatefulsetRolloutStatus(ctx, in, opts...)
+}
+
 // istio
 func (m *defaultLizardAgent) CreateDestinationRule(ctx context.Context, in *IstioCreateRequest, opts ...grpc.CallOption) (*Response, error) {
 	client := agent.NewLizardAgentClient(m.cli.Conn())
@@ -1374,7 +1374,7 @@ var file_agent_proto_rawDesc = []byte{
 	0x04, 0x70, 0x6f, 0x72, 0x74, 0x12, 0x16, 0x0a, 0x06, 0x6d, 0x65, 0x74, 0x68, 0x6f, 0x64, 0x18,
 	0x04, 0x20, 0x01, 0x28, 0x09, 0x52, 0x06, 0x6d, 0x65, 0x74, 0x68, 0x6f, 0x64, 0x12, 0x14, 0x0a,
 	0x05, 0x73, 0x68, 0x65, 0x6c, 0x6c, 0x18, 0x05, 0x20, 0x01, 0x28, 0x09, 0x52, 0x05, 0x73, 0x68,
-	0x65, 0x6c, 0x6c, 0x32, 0xef, 0x14, 0x0a, 0x0b, 0x4c, 0x69, 0x7a, 0x61, 0x72, 0x64, 0x41, 0x67,
+	0x65, 0x6c, 0x6c, 0x32, 0x84, 0x16, 0x0a, 0x0b, 0x4c, 0x69, 0x7a, 0x61, 0x72, 0x64, 0x41, 0x67,
 	0x65, 0x6e, 0x74, 0x12, 0x3f, 0x0a, 0x0f, 0x70, 0x61, 0x74, 0x63, 0x68, 0x44, 0x65, 0x70, 0x6c,
 	0x6f, 0x79, 0x6d, 0x65, 0x6e, 0x74, 0x12, 0x1b, 0x2e, 0x61, 0x67, 0x65, 0x6e, 0x74, 0x2e, 0x50,
 	0x61, 0x74, 0x63, 0x68, 0x57, 0x6f, 0x72, 0x6b, 0x6c, 0x6f, 0x61, 0x64, 0x52, 0x65, 0x71, 0x75,
@@ -1459,90 +1459,99 @@ var file_agent_proto_rawDesc = []byte{
 	0x61, 0x74, 0x65, 0x66, 0x75, 0x6c, 0x73, 0x65, 0x74, 0x51, 0x75, 0x6f, 0x74, 0x61, 0x12, 0x19,
 	0x2e, 0x61, 0x67, 0x65, 0x6e, 0x74, 0x2e, 0x47, 0x65, 0x74, 0x57, 0x6f, 0x72, 0x6b, 0x6c, 0x6f,
 	0x61, 0x64, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x0f, 0x2e, 0x61, 0x67, 0x65, 0x6e,
-	0x74, 0x2e, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x43, 0x0a, 0x15, 0x63, 0x72,
-	0x65, 0x61, 0x74, 0x65, 0x44, 0x65, 0x73, 0x74, 0x69, 0x6e, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x52,
-	0x75, 0x6c, 0x65, 0x12, 0x19, 0x2e, 0x61, 0x67, 0x65, 0x6e, 0x74, 0x2e, 0x49, 0x73, 0x74, 0x69,
-	0x6f, 0x43, 0x72, 0x65, 0x61, 0x74, 0x65, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x0f,
+	0x74, 0x2e, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x48, 0x0a, 0x1a, 0x67, 0x65,
+	0x74, 0x44, 0x65, 0x70, 0x6c, 0x6f, 0x79, 0x6d, 0x65, 0x6e, 0x74, 0x52, 0x6f, 0x6c, 0x6c, 0x6f,
+	0x75, 0x74, 0x53, 0x74, 0x61, 0x74, 0x75, 0x73, 0x12, 0x19, 0x2e, 0x61, 0x67, 0x65, 0x6e, 0x74,
+	0x2e, 0x47, 0x65, 0x74, 0x57, 0x6f, 0x72, 0x6b, 0x6c, 0x6f, 0x61, 0x64, 0x52, 0x65, 0x71, 0x75,
+	0x65, 0x73, 0x74, 0x1a, 0x0f, 0x2e, 0x61, 0x67, 0x65, 0x6e, 0x74, 0x2e, 0x52, 0x65, 0x73, 0x70,
+	0x6f, 0x6e, 0x73, 0x65, 0x12, 0x49, 0x0a, 0x1b, 0x67, 0x65, 0x74, 0x53, 0x74, 0x61, 0x74, 0x65,
+	0x66, 0x75, 0x6c, 0x73, 0x65, 0x74, 0x52, 0x6f, 0x6c, 0x6c, 0x6f, 0x75, 0x74, 0x53, 0x74, 0x61,
+	0x74, 0x75, 0x73, 0x12, 0x19, 0x2e, 0x61, 0x67, 0x65, 0x6e, 0x74, 0x2e, 0x47, 0x65, 0x74, 0x57,
+	0x6f, 0x72, 0x6b, 0x6c, 0x6f, 0x61, 0x64, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x0f,
 	0x2e, 0x61, 0x67, 0x65, 0x6e, 0x74, 0x2e, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12,
-	0x41, 0x0a, 0x14, 0x70, 0x61, 0x74, 0x63, 0x68, 0x44, 0x65, 0x73, 0x74, 0x69, 0x6e, 0x61, 0x74,
-	0x69, 0x6f, 0x6e, 0x52, 0x75, 0x6c, 0x65, 0x12, 0x18, 0x2e, 0x61, 0x67, 0x65, 0x6e, 0x74, 0x2e,
+	0x43, 0x0a, 0x15, 0x63, 0x72, 0x65, 0x61, 0x74, 0x65, 0x44, 0x65, 0x73, 0x74, 0x69, 0x6e, 0x61,
+	0x74, 0x69, 0x6f, 0x6e, 0x52, 0x75, 0x6c, 0x65, 0x12, 0x19, 0x2e, 0x61, 0x67, 0x65, 0x6e, 0x74,
+	0x2e, 0x49, 0x73, 0x74, 0x69, 0x6f, 0x43, 0x72, 0x65, 0x61, 0x74, 0x65, 0x52, 0x65, 0x71, 0x75,
+	0x65, 0x73, 0x74, 0x1a, 0x0f, 0x2e, 0x61, 0x67, 0x65, 0x6e, 0x74, 0x2e, 0x52, 0x65, 0x73, 0x70,
+	0x6f, 0x6e, 0x73, 0x65, 0x12, 0x41, 0x0a, 0x14, 0x70, 0x61, 0x74, 0x63, 0x68, 0x44, 0x65, 0x73,
+	0x74, 0x69, 0x6e, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x52, 0x75, 0x6c, 0x65, 0x12, 0x18, 0x2e, 0x61,
+	0x67, 0x65, 0x6e, 0x74, 0x2e, 0x49, 0x73, 0x74, 0x69, 0x6f, 0x50, 0x61, 0x74, 0x63, 0x68, 0x52,
+	0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x0f, 0x2e, 0x61, 0x67, 0x65, 0x6e, 0x74, 0x2e, 0x52,
+	0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x42, 0x0a, 0x13, 0x6c, 0x69, 0x73, 0x74, 0x44,
+	0x65, 0x73, 0x74, 0x69, 0x6e, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x52, 0x75, 0x6c, 0x65, 0x12, 0x1a,
+	0x2e, 0x61, 0x67, 0x65, 0x6e, 0x74, 0x2e, 0x4c, 0x69, 0x73, 0x74, 0x52, 0x65, 0x73, 0x6f, 0x75,
+	0x72, 0x63, 0x65, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x0f, 0x2e, 0x61, 0x67, 0x65,
+	0x6e, 0x74, 0x2e, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x3d, 0x0a, 0x12, 0x67,
+	0x65, 0x74, 0x44, 0x65, 0x73, 0x74, 0x69, 0x6e, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x52, 0x75, 0x6c,
+	0x65, 0x12, 0x16, 0x2e, 0x61, 0x67, 0x65, 0x6e, 0x74, 0x2e, 0x49, 0x73, 0x74, 0x69, 0x6f, 0x47,
+	0x65, 0x74, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x0f, 0x2e, 0x61, 0x67, 0x65, 0x6e,
+	0x74, 0x2e, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x40, 0x0a, 0x15, 0x64, 0x65,
+	0x6c, 0x65, 0x74, 0x65, 0x44, 0x65, 0x73, 0x74, 0x69, 0x6e, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x52,
+	0x75, 0x6c, 0x65, 0x12, 0x16, 0x2e, 0x61, 0x67, 0x65, 0x6e, 0x74, 0x2e, 0x49, 0x73, 0x74, 0x69,
+	0x6f, 0x47, 0x65, 0x74, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x0f, 0x2e, 0x61, 0x67,
+	0x65, 0x6e, 0x74, 0x2e, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x42, 0x0a, 0x14,
+	0x63, 0x72, 0x65, 0x61, 0x74, 0x65, 0x56, 0x69, 0x72, 0x74, 0x75, 0x61, 0x6c, 0x53, 0x65, 0x72,
+	0x76, 0x69, 0x63, 0x65, 0x12, 0x19, 0x2e, 0x61, 0x67, 0x65, 0x6e, 0x74, 0x2e, 0x49, 0x73, 0x74,
+	0x69, 0x6f, 0x43, 0x72, 0x65, 0x61, 0x74, 0x65, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a,
+	0x0f, 0x2e, 0x61, 0x67, 0x65, 0x6e, 0x74, 0x2e, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65,
+	0x12, 0x40, 0x0a, 0x13, 0x70, 0x61, 0x74, 0x63, 0x68, 0x56, 0x69, 0x72, 0x74, 0x75, 0x61, 0x6c,
+	0x53, 0x65, 0x72, 0x76, 0x69, 0x63, 0x65, 0x12, 0x18, 0x2e, 0x61, 0x67, 0x65, 0x6e, 0x74, 0x2e,
 	0x49, 0x73, 0x74, 0x69, 0x6f, 0x50, 0x61, 0x74, 0x63, 0x68, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73,
 	0x74, 0x1a, 0x0f, 0x2e, 0x61, 0x67, 0x65, 0x6e, 0x74, 0x2e, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e,
-	0x73, 0x65, 0x12, 0x42, 0x0a, 0x13, 0x6c, 0x69, 0x73, 0x74, 0x44, 0x65, 0x73, 0x74, 0x69, 0x6e,
-	0x61, 0x74, 0x69, 0x6f, 0x6e, 0x52, 0x75, 0x6c, 0x65, 0x12, 0x1a, 0x2e, 0x61, 0x67, 0x65, 0x6e,
-	0x74, 0x2e, 0x4c, 0x69, 0x73, 0x74, 0x52, 0x65, 0x73, 0x6f, 0x75, 0x72, 0x63, 0x65, 0x52, 0x65,
-	0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x0f, 0x2e, 0x61, 0x67, 0x65, 0x6e, 0x74, 0x2e, 0x52, 0x65,
-	0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x3d, 0x0a, 0x12, 0x67, 0x65, 0x74, 0x44, 0x65, 0x73,
-	0x74, 0x69, 0x6e, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x52, 0x75, 0x6c, 0x65, 0x12, 0x16, 0x2e, 0x61,
-	0x67, 0x65, 0x6e, 0x74, 0x2e, 0x49, 0x73, 0x74, 0x69, 0x6f, 0x47, 0x65, 0x74, 0x52, 0x65, 0x71,
+	0x73, 0x65, 0x12, 0x41, 0x0a, 0x12, 0x6c, 0x69, 0x73, 0x74, 0x56, 0x69, 0x72, 0x74, 0x75, 0x61,
+	0x6c, 0x53, 0x65, 0x72, 0x76, 0x69, 0x63, 0x65, 0x12, 0x1a, 0x2e, 0x61, 0x67, 0x65, 0x6e, 0x74,
+	0x2e, 0x4c, 0x69, 0x73, 0x74, 0x52, 0x65, 0x73, 0x6f, 0x75, 0x72, 0x63, 0x65, 0x52, 0x65, 0x71,
 	0x75, 0x65, 0x73, 0x74, 0x1a, 0x0f, 0x2e, 0x61, 0x67, 0x65, 0x6e, 0x74, 0x2e, 0x52, 0x65, 0x73,
-	0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x40, 0x0a, 0x15, 0x64, 0x65, 0x6c, 0x65, 0x74, 0x65, 0x44,
-	0x65, 0x73, 0x74, 0x69, 0x6e, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x52, 0x75, 0x6c, 0x65, 0x12, 0x16,
-	0x2e, 0x61, 0x67, 0x65, 0x6e, 0x74, 0x2e, 0x49, 0x73, 0x74, 0x69, 0x6f, 0x47, 0x65, 0x74, 0x52,
-	0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x0f, 0x2e, 0x61, 0x67, 0x65, 0x6e, 0x74, 0x2e, 0x52,
-	0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x42, 0x0a, 0x14, 0x63, 0x72, 0x65, 0x61, 0x74,
-	0x65, 0x56, 0x69, 0x72, 0x74, 0x75, 0x61, 0x6c, 0x53, 0x65, 0x72, 0x76, 0x69, 0x63, 0x65, 0x12,
-	0x19, 0x2e, 0x61, 0x67, 0x65, 0x6e, 0x74, 0x2e, 0x49, 0x73, 0x74, 0x69, 0x6f, 0x43, 0x72, 0x65,
-	0x61, 0x74, 0x65, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x0f, 0x2e, 0x61, 0x67, 0x65,
-	0x6e, 0x74, 0x2e, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x40, 0x0a, 0x13, 0x70,
-	0x61, 0x74, 0x63, 0x68, 0x56, 0x69, 0x72, 0x74, 0x75, 0x61, 0x6c, 0x53, 0x65, 0x72, 0x76, 0x69,
-	0x63, 0x65, 0x12, 0x18, 0x2e, 0x61, 0x67, 0x65, 0x6e, 0x74, 0x2e, 0x49, 0x73, 0x74, 0x69, 0x6f,
-	0x50, 0x61, 0x74, 0x63, 0x68, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x0f, 0x2e, 0x61,
-	0x67, 0x65, 0x6e, 0x74, 0x2e, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x41, 0x0a,
-	0x12, 0x6c, 0x69, 0x73, 0x74, 0x56, 0x69, 0x72, 0x74, 0x75, 0x61, 0x6c, 0x53, 0x65, 0x72, 0x76,
-	0x69, 0x63, 0x65, 0x12, 0x1a, 0x2e, 0x61, 0x67, 0x65, 0x6e, 0x74, 0x2e, 0x4c, 0x69, 0x73, 0x74,
-	0x52, 0x65, 0x73, 0x6f, 0x75, 0x72, 0x63, 0x65, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a,
-	0x0f, 0x2e, 0x61, 0x67, 0x65, 0x6e, 0x74, 0x2e, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65,
-	0x12, 0x3c, 0x0a, 0x11, 0x67, 0x65, 0x74, 0x56, 0x69, 0x72, 0x74, 0x75, 0x61, 0x6c, 0x53, 0x65,
-	0x72, 0x76, 0x69, 0x63, 0x65, 0x12, 0x16, 0x2e, 0x61, 0x67, 0x65, 0x6e, 0x74, 0x2e, 0x49, 0x73,
-	0x74, 0x69, 0x6f, 0x47, 0x65, 0x74, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x0f, 0x2e,
-	0x61, 0x67, 0x65, 0x6e, 0x74, 0x2e, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x3f,
-	0x0a, 0x14, 0x64, 0x65, 0x6c, 0x65, 0x74, 0x65, 0x56, 0x69, 0x72, 0x74, 0x75, 0x61, 0x6c, 0x53,
-	0x65, 0x72, 0x76, 0x69, 0x63, 0x65, 0x12, 0x16, 0x2e, 0x61, 0x67, 0x65, 0x6e, 0x74, 0x2e, 0x49,
-	0x73, 0x74, 0x69, 0x6f, 0x47, 0x65, 0x74, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x0f,
-	0x2e, 0x61, 0x67, 0x65, 0x6e, 0x74, 0x2e, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12,
-	0x3c, 0x0a, 0x0e, 0x68, 0x65, 0x6c, 0x6d, 0x55, 0x70, 0x64, 0x61, 0x74, 0x65, 0x52, 0x65, 0x70,
-	0x6f, 0x12, 0x19, 0x2e, 0x61, 0x67, 0x65, 0x6e, 0x74, 0x2e, 0x48, 0x65, 0x6c, 0x6d, 0x45, 0x6e,
-	0x74, 0x72, 0x69, 0x65, 0x73, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x0f, 0x2e, 0x61,
-	0x67, 0x65, 0x6e, 0x74, 0x2e, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x43, 0x0a,
-	0x10, 0x68, 0x65, 0x6c, 0x6d, 0x49, 0x6e, 0x73, 0x74, 0x61, 0x6c, 0x6c, 0x43, 0x68, 0x61, 0x72,
-	0x74, 0x12, 0x1e, 0x2e, 0x61, 0x67, 0x65, 0x6e, 0x74, 0x2e, 0x48, 0x65, 0x6c, 0x6d, 0x49, 0x6e,
-	0x73, 0x74, 0x61, 0x6c, 0x6c, 0x43, 0x68, 0x61, 0x72, 0x74, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73,
+	0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x3c, 0x0a, 0x11, 0x67, 0x65, 0x74, 0x56, 0x69, 0x72, 0x74,
+	0x75, 0x61, 0x6c, 0x53, 0x65, 0x72, 0x76, 0x69, 0x63, 0x65, 0x12, 0x16, 0x2e, 0x61, 0x67, 0x65,
+	0x6e, 0x74, 0x2e, 0x49, 0x73, 0x74, 0x69, 0x6f, 0x47, 0x65, 0x74, 0x52, 0x65, 0x71, 0x75, 0x65,
+	0x73, 0x74, 0x1a, 0x0f, 0x2e, 0x61, 0x67, 0x65, 0x6e, 0x74, 0x2e, 0x52, 0x65, 0x73, 0x70, 0x6f,
+	0x6e, 0x73, 0x65, 0x12, 0x3f, 0x0a, 0x14, 0x64, 0x65, 0x6c, 0x65, 0x74, 0x65, 0x56, 0x69, 0x72,
+	0x74, 0x75, 0x61, 0x6c, 0x53, 0x65, 0x72, 0x76, 0x69, 0x63, 0x65, 0x12, 0x16, 0x2e, 0x61, 0x67,
+	0x65, 0x6e, 0x74, 0x2e, 0x49, 0x73, 0x74, 0x69, 0x6f, 0x47, 0x65, 0x74, 0x52, 0x65, 0x71, 0x75,
+	0x65, 0x73, 0x74, 0x1a, 0x0f, 0x2e, 0x61, 0x67, 0x65, 0x6e, 0x74, 0x2e, 0x52, 0x65, 0x73, 0x70,
+	0x6f, 0x6e, 0x73, 0x65, 0x12, 0x3c, 0x0a, 0x0e, 0x68, 0x65, 0x6c, 0x6d, 0x55, 0x70, 0x64, 0x61,
+	0x74, 0x65, 0x52, 0x65, 0x70, 0x6f, 0x12, 0x19, 0x2e, 0x61, 0x67, 0x65, 0x6e, 0x74, 0x2e, 0x48,
+	0x65, 0x6c, 0x6d, 0x45, 0x6e, 0x74, 0x72, 0x69, 0x65, 0x73, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73,
 	0x74, 0x1a, 0x0f, 0x2e, 0x61, 0x67, 0x65, 0x6e, 0x74, 0x2e, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e,
-	0x73, 0x65, 0x12, 0x45, 0x0a, 0x12, 0x68, 0x65, 0x6c, 0x6d, 0x55, 0x6e, 0x69, 0x6e, 0x73, 0x74,
-	0x61, 0x6c, 0x6c, 0x43, 0x68, 0x61, 0x72, 0x74, 0x12, 0x1e, 0x2e, 0x61, 0x67, 0x65, 0x6e, 0x74,
-	0x2e, 0x48, 0x65, 0x6c, 0x6d, 0x49, 0x6e, 0x73, 0x74, 0x61, 0x6c, 0x6c, 0x43, 0x68, 0x61, 0x72,
-	0x74, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x0f, 0x2e, 0x61, 0x67, 0x65, 0x6e, 0x74,
-	0x2e, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x43, 0x0a, 0x10, 0x68, 0x65, 0x6c,
-	0x6d, 0x55, 0x70, 0x67, 0x72, 0x61, 0x64, 0x65, 0x43, 0x68, 0x61, 0x72, 0x74, 0x12, 0x1e, 0x2e,
+	0x73, 0x65, 0x12, 0x43, 0x0a, 0x10, 0x68, 0x65, 0x6c, 0x6d, 0x49, 0x6e, 0x73, 0x74, 0x61, 0x6c,
+	0x6c, 0x43, 0x68, 0x61, 0x72, 0x74, 0x12, 0x1e, 0x2e, 0x61, 0x67, 0x65, 0x6e, 0x74, 0x2e, 0x48,
+	0x65, 0x6c, 0x6d, 0x49, 0x6e, 0x73, 0x74, 0x61, 0x6c, 0x6c, 0x43, 0x68, 0x61, 0x72, 0x74, 0x52,
+	0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x0f, 0x2e, 0x61, 0x67, 0x65, 0x6e, 0x74, 0x2e, 0x52,
+	0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x45, 0x0a, 0x12, 0x68, 0x65, 0x6c, 0x6d, 0x55,
+	0x6e, 0x69, 0x6e, 0x73, 0x74, 0x61, 0x6c, 0x6c, 0x43, 0x68, 0x61, 0x72, 0x74, 0x12, 0x1e, 0x2e,
 	0x61, 0x67, 0x65, 0x6e, 0x74, 0x2e, 0x48, 0x65, 0x6c, 0x6d, 0x49, 0x6e, 0x73, 0x74, 0x61, 0x6c,
 	0x6c, 0x43, 0x68, 0x61, 0x72, 0x74, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x0f, 0x2e,
-	0x61, 0x67, 0x65, 0x6e, 0x74, 0x2e, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x3f,
-	0x0a, 0x10, 0x68, 0x65, 0x6c, 0x6d, 0x4c, 0x69, 0x73, 0x74, 0x52, 0x65, 0x6c, 0x65, 0x61, 0x73,
-	0x65, 0x73, 0x12, 0x1a, 0x2e, 0x61, 0x67, 0x65, 0x6e, 0x74, 0x2e, 0x4c, 0x69, 0x73, 0x74, 0x52,
-	0x65, 0x6c, 0x65, 0x61, 0x73, 0x65, 0x73, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x0f,
-	0x2e, 0x61, 0x67, 0x65, 0x6e, 0x74, 0x2e, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12,
-	0x3c, 0x0a, 0x0d, 0x68, 0x65, 0x6c, 0x6d, 0x47, 0x65, 0x74, 0x56, 0x61, 0x6c, 0x75, 0x65, 0x73,
-	0x12, 0x1a, 0x2e, 0x61, 0x67, 0x65, 0x6e, 0x74, 0x2e, 0x4c, 0x69, 0x73, 0x74, 0x52, 0x65, 0x6c,
-	0x65, 0x61, 0x73, 0x65, 0x73, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x0f, 0x2e, 0x61,
-	0x67, 0x65, 0x6e, 0x74, 0x2e, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x41, 0x0a,
-	0x12, 0x68, 0x65, 0x6c, 0x6d, 0x52, 0x65, 0x6c, 0x65, 0x61, 0x73, 0x65, 0x48, 0x69, 0x73, 0x74,
-	0x6f, 0x72, 0x79, 0x12, 0x1a, 0x2e, 0x61, 0x67, 0x65, 0x6e, 0x74, 0x2e, 0x4c, 0x69, 0x73, 0x74,
-	0x52, 0x65, 0x6c, 0x65, 0x61, 0x73, 0x65, 0x73, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a,
-	0x0f, 0x2e, 0x61, 0x67, 0x65, 0x6e, 0x74, 0x2e, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65,
-	0x12, 0x3f, 0x0a, 0x0c, 0x68, 0x65, 0x6c, 0x6d, 0x52, 0x6f, 0x6c, 0x6c, 0x62, 0x61, 0x63, 0x6b,
-	0x12, 0x1e, 0x2e, 0x61, 0x67, 0x65, 0x6e, 0x74, 0x2e, 0x48, 0x65, 0x6c, 0x6d, 0x49, 0x6e, 0x73,
-	0x74, 0x61, 0x6c, 0x6c, 0x43, 0x68, 0x61, 0x72, 0x74, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74,
-	0x1a, 0x0f, 0x2e, 0x61, 0x67, 0x65, 0x6e, 0x74, 0x2e, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73,
-	0x65, 0x12, 0x33, 0x0a, 0x08, 0x76, 0x6d, 0x44, 0x65, 0x70, 0x6c, 0x6f, 0x79, 0x12, 0x16, 0x2e,
-	0x61, 0x67, 0x65, 0x6e, 0x74, 0x2e, 0x56, 0x6d, 0x44, 0x65, 0x70, 0x6c, 0x6f, 0x79, 0x52, 0x65,
-	0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x0f, 0x2e, 0x61, 0x67, 0x65, 0x6e, 0x74, 0x2e, 0x52, 0x65,
-	0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x3d, 0x0a, 0x0d, 0x76, 0x6d, 0x48, 0x65, 0x61, 0x6c,
-	0x74, 0x68, 0x43, 0x68, 0x65, 0x63, 0x6b, 0x12, 0x1b, 0x2e, 0x61, 0x67, 0x65, 0x6e, 0x74, 0x2e,
-	0x56, 0x6d, 0x48, 0x65, 0x61, 0x6c, 0x74, 0x68, 0x43, 0x68, 0x65, 0x63, 0x6b, 0x52, 0x65, 0x71,
+	0x61, 0x67, 0x65, 0x6e, 0x74, 0x2e, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x43,
+	0x0a, 0x10, 0x68, 0x65, 0x6c, 0x6d, 0x55, 0x70, 0x67, 0x72, 0x61, 0x64, 0x65, 0x43, 0x68, 0x61,
+	0x72, 0x74, 0x12, 0x1e, 0x2e, 0x61, 0x67, 0x65, 0x6e, 0x74, 0x2e, 0x48, 0x65, 0x6c, 0x6d, 0x49,
+	0x6e, 0x73, 0x74, 0x61, 0x6c, 0x6c, 0x43, 0x68, 0x61, 0x72, 0x74, 0x52, 0x65, 0x71, 0x75, 0x65,
+	0x73, 0x74, 0x1a, 0x0f, 0x2e, 0x61, 0x67, 0x65, 0x6e, 0x74, 0x2e, 0x52, 0x65, 0x73, 0x70, 0x6f,
+	0x6e, 0x73, 0x65, 0x12, 0x3f, 0x0a, 0x10, 0x68, 0x65, 0x6c, 0x6d, 0x4c, 0x69, 0x73, 0x74, 0x52,
+	0x65, 0x6c, 0x65, 0x61, 0x73, 0x65, 0x73, 0x12, 0x1a, 0x2e, 0x61, 0x67, 0x65, 0x6e, 0x74, 0x2e,
+	0x4c, 0x69, 0x73, 0x74, 0x52, 0x65, 0x6c, 0x65, 0x61, 0x73, 0x65, 0x73, 0x52, 0x65, 0x71, 0x75,
+	0x65, 0x73, 0x74, 0x1a, 0x0f, 0x2e, 0x61, 0x67, 0x65, 0x6e, 0x74, 0x2e, 0x52, 0x65, 0x73, 0x70,
+	0x6f, 0x6e, 0x73, 0x65, 0x12, 0x3c, 0x0a, 0x0d, 0x68, 0x65, 0x6c, 0x6d, 0x47, 0x65, 0x74, 0x56,
+	0x61, 0x6c, 0x75, 0x65, 0x73, 0x12, 0x1a, 0x2e, 0x61, 0x67, 0x65, 0x6e, 0x74, 0x2e, 0x4c, 0x69,
+	0x73, 0x74, 0x52, 0x65, 0x6c, 0x65, 0x61, 0x73, 0x65, 0x73, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73,
+	0x74, 0x1a, 0x0f, 0x2e, 0x61, 0x67, 0x65, 0x6e, 0x74, 0x2e, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e,
+	0x73, 0x65, 0x12, 0x41, 0x0a, 0x12, 0x68, 0x65, 0x6c, 0x6d, 0x52, 0x65, 0x6c, 0x65, 0x61, 0x73,
+	0x65, 0x48, 0x69, 0x73, 0x74, 0x6f, 0x72, 0x79, 0x12, 0x1a, 0x2e, 0x61, 0x67, 0x65, 0x6e, 0x74,
+	0x2e, 0x4c, 0x69, 0x73, 0x74, 0x52, 0x65, 0x6c, 0x65, 0x61, 0x73, 0x65, 0x73, 0x52, 0x65, 0x71,
 	0x75, 0x65, 0x73, 0x74, 0x1a, 0x0f, 0x2e, 0x61, 0x67, 0x65, 0x6e, 0x74, 0x2e, 0x52, 0x65, 0x73,
-	0x70, 0x6f, 0x6e, 0x73, 0x65, 0x42, 0x09, 0x5a, 0x07, 0x2e, 0x2f, 0x61, 0x67, 0x65, 0x6e, 0x74,
-	0x62, 0x06, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x33,
+	0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x3f, 0x0a, 0x0c, 0x68, 0x65, 0x6c, 0x6d, 0x52, 0x6f, 0x6c,
+	0x6c, 0x62, 0x61, 0x63, 0x6b, 0x12, 0x1e, 0x2e, 0x61, 0x67, 0x65, 0x6e, 0x74, 0x2e, 0x48, 0x65,
+	0x6c, 0x6d, 0x49, 0x6e, 0x73, 0x74, 0x61, 0x6c, 0x6c, 0x43, 0x68, 0x61, 0x72, 0x74, 0x52, 0x65,
+	0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x0f, 0x2e, 0x61, 0x67, 0x65, 0x6e, 0x74, 0x2e, 0x52, 0x65,
+	0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x33, 0x0a, 0x08, 0x76, 0x6d, 0x44, 0x65, 0x70, 0x6c,
+	0x6f, 0x79, 0x12, 0x16, 0x2e, 0x61, 0x67, 0x65, 0x6e, 0x74, 0x2e, 0x56, 0x6d, 0x44, 0x65, 0x70,
+	0x6c, 0x6f, 0x79, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x0f, 0x2e, 0x61, 0x67, 0x65,
+	0x6e, 0x74, 0x2e, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x3d, 0x0a, 0x0d, 0x76,
+	0x6d, 0x48, 0x65, 0x61, 0x6c, 0x74, 0x68, 0x43, 0x68, 0x65, 0x63, 0x6b, 0x12, 0x1b, 0x2e, 0x61,
+	0x67, 0x65, 0x6e, 0x74, 0x2e, 0x56, 0x6d, 0x48, 0x65, 0x61, 0x6c, 0x74, 0x68, 0x43, 0x68, 0x65,
+	0x63, 0x6b, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x0f, 0x2e, 0x61, 0x67, 0x65, 0x6e,
+	0x74, 0x2e, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x42, 0x09, 0x5a, 0x07, 0x2e, 0x2f,
+	0x61, 0x67, 0x65, 0x6e, 0x74, 0x62, 0x06, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x33,
 }
 
 var (
@@ -1602,70 +1611,74 @@ var file_agent_proto_depIdxs = []int32{
 	2,  // 19: agent.LizardAgent.getStatefulset:input_type -> agent.GetWorkloadRequest
 	2,  // 20: agent.LizardAgent.getDeploymentQuota:input_type -> agent.GetWorkloadRequest
 	2,  // 21: agent.LizardAgent.getStatefulsetQuota:input_type -> agent.GetWorkloadRequest
-	11, // 22: agent.LizardAgent.createDestinationRule:input_type -> agent.IstioCreateRequest
-	12, // 23: agent.LizardAgent.patchDestinationRule:input_type -> agent.IstioPatchRequest
-	1,  // 24: agent.LizardAgent.listDestinationRule:input_type -> agent.ListResourceRequest
-	13, // 25: agent.LizardAgent.getDestinationRule:input_type -> agent.IstioGetRequest
-	13, // 26: agent.LizardAgent.deleteDestinationRule:input_type -> agent.IstioGetRequest
-	11, // 27: agent.LizardAgent.createVirtualService:input_type -> agent.IstioCreateRequest
-	12, // 28: agent.LizardAgent.patchVirtualService:input_type -> agent.IstioPatchRequest
-	1,  // 29: agent.LizardAgent.listVirtualService:input_type -> agent.ListResourceRequest
-	13, // 30: agent.LizardAgent.getVirtualService:input_type -> agent.IstioGetRequest
-	13, // 31: agent.LizardAgent.deleteVirtualService:input_type -> agent.IstioGetRequest
-	14, // 32: agent.LizardAgent.helmUpdateRepo:input_type -> agent.HelmEntriesRequest
-	15, // 33: agent.LizardAgent.helmInstallChart:input_type -> agent.HelmInstallChartRequest
-	15, // 34: agent.LizardAgent.helmUninstallChart:input_type -> agent.HelmInstallChartRequest
-	15, // 35: agent.LizardAgent.helmUpgradeChart:input_type -> agent.HelmInstallChartRequest
-	16, // 36: agent.LizardAgent.helmListReleases:input_type -> agent.ListReleasesRequest
-	16, // 37: agent.LizardAgent.helmGetValues:input_type -> agent.ListReleasesRequest
-	16, // 38: agent.LizardAgent.helmReleaseHistory:input_type -> agent.ListReleasesRequest
-	15, // 39: agent.LizardAgent.helmRollback:input_type -> agent.HelmInstallChartRequest
-	17, // 40: agent.LizardAgent.vmDeploy:input_type -> agent.VmDeployRequest
-	18, // 41: agent.LizardAgent.vmHealthCheck:input_type -> agent.VmHealthCheckRequest
-	9,  // 42: agent.LizardAgent.patchDeployment:output_type -> agent.Response
-	9,  // 43: agent.LizardAgent.patchStatefulset:output_type -> agent.Response
-	9,  // 44: agent.LizardAgent.listDeployment:output_type -> agent.Response
-	9,  // 45: agent.LizardAgent.listStatefulset:output_type -> agent.Response
-	9,  // 46: agent.LizardAgent.deleteDeployment:output_type -> agent.Response
-	9,  // 47: agent.LizardAgent.deleteStatefulset:output_type -> agent.Response
-	9,  // 48: agent.LizardAgent.getDeploymentPod:output_type -> agent.Response
-	9,  // 49: agent.LizardAgent.getStatefulsetPod:output_type -> agent.Response
-	9,  // 50: agent.LizardAgent.getEvent:output_type -> agent.Response
-	9,  // 51: agent.LizardAgent.getPodStatus:output_type -> agent.Response
-	9,  // 52: agent.LizardAgent.deleteYaml:output_type -> agent.Response
-	9,  // 53: agent.LizardAgent.applyYaml:output_type -> agent.Response
-	10, // 54: agent.LizardAgent.getyaml:output_type -> agent.YamlResponse
-	9,  // 55: agent.LizardAgent.rolloutDeployment:output_type -> agent.Response
-	9,  // 56: agent.LizardAgent.rolloutStatefulset:output_type -> agent.Response
-	9,  // 57: agent.LizardAgent.scaleDeployment:output_type -> agent.Response
-	9,  // 58: agent.LizardAgent.scaleStatefulset:output_type -> agent.Response
-	9,  // 59: agent.LizardAgent.getNamespaces:output_type -> agent.Response
-	9,  // 60: agent.LizardAgent.getDeployment:output_type -> agent.Response
-	9,  // 61: agent.LizardAgent.getStatefulset:output_type -> agent.Response
-	9,  // 62: agent.LizardAgent.getDeploymentQuota:output_type -> agent.Response
-	9,  // 63: agent.LizardAgent.getStatefulsetQuota:output_type -> agent.Response
-	9,  // 64: agent.LizardAgent.createDestinationRule:output_type -> agent.Response
-	9,  // 65: agent.LizardAgent.patchDestinationRule:output_type -> agent.Response
-	9,  // 66: agent.LizardAgent.listDestinationRule:output_type -> agent.Response
-	9,  // 67: agent.LizardAgent.getDestinationRule:output_type -> agent.Response
-	9,  // 68: agent.LizardAgent.deleteDestinationRule:output_type -> agent.Response
-	9,  // 69: agent.LizardAgent.createVirtualService:output_type -> agent.Response
-	9,  // 70: agent.LizardAgent.patchVirtualService:output_type -> agent.Response
-	9,  // 71: agent.LizardAgent.listVirtualService:output_type -> agent.Response
-	9,  // 72: agent.LizardAgent.getVirtualService:output_type -> agent.Response
-	9,  // 73: agent.LizardAgent.deleteVirtualService:output_type -> agent.Response
-	9,  // 74: agent.LizardAgent.helmUpdateRepo:output_type -> agent.Response
-	9,  // 75: agent.LizardAgent.helmInstallChart:output_type -> agent.Response
-	9,  // 76: agent.LizardAgent.helmUninstallChart:output_type -> agent.Response
-	9,  // 77: agent.LizardAgent.helmUpgradeChart:output_type -> agent.Response
-	9,  // 78: agent.LizardAgent.helmListReleases:output_type -> agent.Response
-	9,  // 79: agent.LizardAgent.helmGetValues:output_type -> agent.Response
-	9,  // 80: agent.LizardAgent.helmReleaseHistory:output_type -> agent.Response
-	9,  // 81: agent.LizardAgent.helmRollback:output_type -> agent.Response
-	9,  // 82: agent.LizardAgent.vmDeploy:output_type -> agent.Response
-	9,  // 83: agent.LizardAgent.vmHealthCheck:output_type -> agent.Response
-	42, // [42:84] is the sub-list for method output_type
-	0,  // [0:42] is the sub-list for method input_type
+	2,  // 22: agent.LizardAgent.getDeploymentRolloutStatus:input_type -> agent.GetWorkloadRequest
+	2,  // 23: agent.LizardAgent.getStatefulsetRolloutStatus:input_type -> agent.GetWorkloadRequest
+	11, // 24: agent.LizardAgent.createDestinationRule:input_type -> agent.IstioCreateRequest
+	12, // 25: agent.LizardAgent.patchDestinationRule:input_type -> agent.IstioPatchRequest
+	1,  // 26: agent.LizardAgent.listDestinationRule:input_type -> agent.ListResourceRequest
+	13, // 27: agent.LizardAgent.getDestinationRule:input_type -> agent.IstioGetRequest
+	13, // 28: agent.LizardAgent.deleteDestinationRule:input_type -> agent.IstioGetRequest
+	11, // 29: agent.LizardAgent.createVirtualService:input_type -> agent.IstioCreateRequest
+	12, // 30: agent.LizardAgent.patchVirtualService:input_type -> agent.IstioPatchRequest
+	1,  // 31: agent.LizardAgent.listVirtualService:input_type -> agent.ListResourceRequest
+	13, // 32: agent.LizardAgent.getVirtualService:input_type -> agent.IstioGetRequest
+	13, // 33: agent.LizardAgent.deleteVirtualService:input_type -> agent.IstioGetRequest
+	14, // 34: agent.LizardAgent.helmUpdateRepo:input_type -> agent.HelmEntriesRequest
+	15, // 35: agent.LizardAgent.helmInstallChart:input_type -> agent.HelmInstallChartRequest
+	15, // 36: agent.LizardAgent.helmUninstallChart:input_type -> agent.HelmInstallChartRequest
+	15, // 37: agent.LizardAgent.helmUpgradeChart:input_type -> agent.HelmInstallChartRequest
+	16, // 38: agent.LizardAgent.helmListReleases:input_type -> agent.ListReleasesRequest
+	16, // 39: agent.LizardAgent.helmGetValues:input_type -> agent.ListReleasesRequest
+	16, // 40: agent.LizardAgent.helmReleaseHistory:input_type -> agent.ListReleasesRequest
+	15, // 41: agent.LizardAgent.helmRollback:input_type -> agent.HelmInstallChartRequest
+	17, // 42: agent.LizardAgent.vmDeploy:input_type -> agent.VmDeployRequest
+	18, // 43: agent.LizardAgent.vmHealthCheck:input_type -> agent.VmHealthCheckRequest
+	9,  // 44: agent.LizardAgent.patchDeployment:output_type -> agent.Response
+	9,  // 45: agent.LizardAgent.patchStatefulset:output_type -> agent.Response
+	9,  // 46: agent.LizardAgent.listDeployment:output_type -> agent.Response
+	9,  // 47: agent.LizardAgent.listStatefulset:output_type -> agent.Response
+	9,  // 48: agent.LizardAgent.deleteDeployment:output_type -> agent.Response
+	9,  // 49: agent.LizardAgent.deleteStatefulset:output_type -> agent.Response
+	9,  // 50: agent.LizardAgent.getDeploymentPod:output_type -> agent.Response
+	9,  // 51: agent.LizardAgent.getStatefulsetPod:output_type -> agent.Response
+	9,  // 52: agent.LizardAgent.getEvent:output_type -> agent.Response
+	9,  // 53: agent.LizardAgent.getPodStatus:output_type -> agent.Response
+	9,  // 54: agent.LizardAgent.deleteYaml:output_type -> agent.Response
+	9,  // 55: agent.LizardAgent.applyYaml:output_type -> agent.Response
+	10, // 56: agent.LizardAgent.getyaml:output_type -> agent.YamlResponse
+	9,  // 57: agent.LizardAgent.rolloutDeployment:output_type -> agent.Response
+	9,  // 58: agent.LizardAgent.rolloutStatefulset:output_type -> agent.Response
+	9,  // 59: agent.LizardAgent.scaleDeployment:output_type -> agent.Response
+	9,  // 60: agent.LizardAgent.scaleStatefulset:output_type -> agent.Response
+	9,  // 61: agent.LizardAgent.getNamespaces:output_type -> agent.Response
+	9,  // 62: agent.LizardAgent.getDeployment:output_type -> agent.Response
+	9,  // 63: agent.LizardAgent.getStatefulset:output_type -> agent.Response
+	9,  // 64: agent.LizardAgent.getDeploymentQuota:output_type -> agent.Response
+	9,  // 65: agent.LizardAgent.getStatefulsetQuota:output_type -> agent.Response
+	9,  // 66: agent.LizardAgent.getDeploymentRolloutStatus:output_type -> agent.Response
+	9,  // 67: agent.LizardAgent.getStatefulsetRolloutStatus:output_type -> agent.Response
+	9,  // 68: agent.LizardAgent.createDestinationRule:output_type -> agent.Response
+	9,  // 69: agent.LizardAgent.patchDestinationRule:output_type -> agent.Response
+	9,  // 70: agent.LizardAgent.listDestinationRule:output_type -> agent.Response
+	9,  // 71: agent.LizardAgent.getDestinationRule:output_type -> agent.Response
+	9,  // 72: agent.LizardAgent.deleteDestinationRule:output_type -> agent.Response
+	9,  // 73: agent.LizardAgent.createVirtualService:output_type -> agent.Response
+	9,  // 74: agent.LizardAgent.patchVirtualService:output_type -> agent.Response
+	9,  // 75: agent.LizardAgent.listVirtualService:output_type -> agent.Response
+	9,  // 76: agent.LizardAgent.getVirtualService:output_type -> agent.Response
+	9,  // 77: agent.LizardAgent.deleteVirtualService:output_type -> agent.Response
+	9,  // 78: agent.LizardAgent.helmUpdateRepo:output_type -> agent.Response
+	9,  // 79: agent.LizardAgent.helmInstallChart:output_type -> agent.Response
+	9,  // 80: agent.LizardAgent.helmUninstallChart:output_type -> agent.Response
+	9,  // 81: agent.LizardAgent.helmUpgradeChart:output_type -> agent.Response
+	9,  // 82: agent.LizardAgent.helmListReleases:output_type -> agent.Response
+	9,  // 83: agent.LizardAgent.helmGetValues:output_type -> agent.Response
+	9,  // 84: agent.LizardAgent.helmReleaseHistory:output_type -> agent.Response
+	9,  // 85: agent.LizardAgent.helmRollback:output_type -> agent.Response
+	9,  // 86: agent.LizardAgent.vmDeploy:output_type -> agent.Response
+	9,  // 87: agent.LizardAgent.vmHealthCheck:output_type -> agent.Response
+	44, // [44:88] is the sub-list for method output_type
+	0,  // [0:44] is the sub-list for method input_type
 	0,  // [0:0] is the sub-list for extension type_name
 	0,  // [0:0] is the sub-list for extension extendee
 	0,  // [0:0] is the sub-list for field type_name
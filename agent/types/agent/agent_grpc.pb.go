@@ -45,6 +45,8 @@ type LizardAgentClient interface {
 	GetStatefulset(ctx context.Context, in *GetWorkloadRequest, opts ...grpc.CallOption) (*Response, error)
 	GetDeploymentQuota(ctx context.Context, in *GetWorkloadRequest, opts ...grpc.CallOption) (*Response, error)
 	GetStatefulsetQuota(ctx context.Context, in *GetWorkloadRequest, opts ...grpc.CallOption) (*Response, error)
+	GetDeploymentRolloutStatus(ctx context.Context, in *GetWorkloadRequest, opts ...grpc.CallOption) (*Response, error)
+	GetStatefulsetRolloutStatus(ctx context.Context, in *GetWorkloadRequest, opts ...grpc.CallOption) (*Response, error)
 	// istio
 	CreateDestinationRule(ctx context.Context, in *IstioCreateRequest, opts ...grpc.CallOption) (*Response, error)
 	PatchDestinationRule(ctx context.Context, in *IstioPatchRequest, opts ...grpc.CallOption) (*Response, error)
@@ -276,6 +278,24 @@ func (c *lizardAgentClient) GetStatefulsetQuota(ctx context.Context, in *GetWork
 	return out, nil
 }
 
+func (c *lizardAgentClient) GetDeploymentRolloutStatus(ctx context.Context, in *GetWorkloadRequest, opts ...grpc.CallOption) (*Response, error) {
+	out := new(Response)
+	err := c.cc.Invoke(ctx, "/agent.LizardAgent/getDeploymentRolloutStatus", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *lizardAgentClient) GetStatefulsetRolloutStatus(ctx context.Context, in *GetWorkloadRequest, opts ...grpc.CallOption) (*Response, error) {
+	out := new(Response)
+	err := c.cc.Invoke(ctx, "/agent.LizardAgent/getStatefulsetRolloutStatus", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 func (c *lizardAgentClient) CreateDestinationRule(ctx context.Context, in *IstioCreateRequest, opts ...grpc.CallOption) (*Response, error) {
 	out := new(Response)
 	err := c.cc.Invoke(ctx, "/agent.LizardAgent/createDestinationRule", in, out, opts...)
@@ -483,6 +503,8 @@ type LizardAgentServer interface {
 	GetStatefulset(context.Context, *GetWorkloadRequest) (*Response, error)
 	GetDeploymentQuota(context.Context, *GetWorkloadRequest) (*Response, error)
 	GetStatefulsetQuota(context.Context, *GetWorkloadRequest) (*Response, error)
+	GetDeploymentRolloutStatus(context.Context, *GetWorkloadRequest) (*Response, error)
+	GetStatefulsetRolloutStatus(context.Context, *GetWorkloadRequest) (*Response, error)
 	// istio
 	CreateDestinationRule(context.Context, *IstioCreateRequest) (*Response, error)
 	PatchDestinationRule(context.Context, *IstioPatchRequest) (*Response, error)
@@ -579,6 +601,12 @@ func (UnimplementedLizardAgentServer) GetDeploymentQuota(context.Context, *GetWo
 func (UnimplementedLizardAgentServer) GetStatefulsetQuota(context.Context, *GetWorkloadRequest) (*Response, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method GetStatefulsetQuota not implemented")
 }
+func (UnimplementedLizardAgentServer) GetDeploymentRolloutStatus(context.Context, *GetWorkloadRequest) (*Response, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetDeploymentRolloutStatus not implemented")
+}
+func (UnimplementedLizardAgentServer) GetStatefulsetRolloutStatus(context.Context, *GetWorkloadRequest) (*Response, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetStatefulsetRolloutStatus not implemented")
+}
 func (UnimplementedLizardAgentServer) CreateDestinationRule(context.Context, *IstioCreateRequest) (*Response, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method CreateDestinationRule not implemented")
 }
@@ -1048,6 +1076,42 @@ func _LizardAgent_GetStatefulsetQuota_Handler(srv interface{}, ctx context.Conte
 	return interceptor(ctx, in, info, handler)
 }
 
+func _LizardAgent_GetDeploymentRolloutStatus_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetWorkloadRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(LizardAgentServer).GetDeploymentRolloutStatus(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/agent.LizardAgent/getDeploymentRolloutStatus",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(LizardAgentServer).GetDeploymentRolloutStatus(ctx, req.(*GetWorkloadRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _LizardAgent_GetStatefulsetRolloutStatus_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetWorkloadRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(LizardAgentServer).GetStatefulsetRolloutStatus(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/agent.LizardAgent/getStatefulsetRolloutStatus",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(LizardAgentServer).GetStatefulsetRolloutStatus(ctx, req.(*GetWorkloadRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 func _LizardAgent_CreateDestinationRule_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
 	in := new(IstioCreateRequest)
 	if err := dec(in); err != nil {
@@ -1503,6 +1567,14 @@ var LizardAgent_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "getStatefulsetQuota",
 			Handler:    _LizardAgent_GetStatefulsetQuota_Handler,
 		},
+		{
+			MethodName: "getDeploymentRolloutStatus",
+			Handler:    _LizardAgent_GetDeploymentRolloutStatus_Handler,
+		},
+		{
+			MethodName: "getStatefulsetRolloutStatus",
+			Handler:    _LizardAgent_GetStatefulsetRolloutStatus_Handler,
+		},
 		{
 			MethodName: "createDestinationRule",
 			Handler:    _LizardAgent_CreateDestinationRule_Handler,
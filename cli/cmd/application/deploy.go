@@ -5,8 +5,11 @@ package application
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"net/url"
+	"os"
+	"time"
 
 	"github.com/hongyuxuan/lizardcd/cli/common"
 	"github.com/hongyuxuan/lizardcd/cli/types"
@@ -17,6 +20,10 @@ import (
 
 var application string
 var image string
+var wait bool
+var timeout time.Duration
+var strategy string
+var workloadNames []string
 
 // deployCmd represents the deploy command
 var deployCmd = &cobra.Command{
@@ -34,11 +41,22 @@ var deployCmd = &cobra.Command{
 		}
 
 		app := res.Data.Results[0]
+		workloads := app.Workload
+		if len(workloadNames) > 0 { // e.g. only deploy the canary workloads
+			workloads = lo.Filter(workloads, func(w commontypes.WorkLoad, _ int) bool {
+				return lo.Contains(workloadNames, w.WorkloadName)
+			})
+			if len(workloads) == 0 {
+				common.PrintFatal("cannot find workloads %v in application \"%s\"", workloadNames, application)
+			}
+		}
+		var taskRes *types.TaskRunRes
 		if err := common.LizardServer.Post("/lizardcd/task/run").SetBody(map[string]interface{}{
 			"app_name":     application,
 			"task_type":    "deploy",
 			"trigger_type": "lizardcd-cli",
-			"workloads": lo.Map(app.Workload, func(w commontypes.WorkLoad, _ int) map[string]interface{} {
+			"strategy":     strategy,
+			"workloads": lo.Map(workloads, func(w commontypes.WorkLoad, _ int) map[string]interface{} {
 				return map[string]interface{}{
 					"cluster":        w.Cluster,
 					"namespace":      w.Namespace,
@@ -48,17 +66,50 @@ var deployCmd = &cobra.Command{
 					"artifact_url":   image,
 				}
 			}),
-		}).Do(context.Background()).Err; err != nil {
+		}).SetResult(&taskRes).Do(context.Background()).Err; err != nil {
 			common.PrintFatal("failed to deploy application \"%s\": %v", application, err)
-		} else {
+		}
+		if !wait {
 			common.PrintSuccess("successfully submit deploy task, use \"%s task list\" to see results", common.GetExec())
+			return
+		}
+
+		// block until the task is done, so the deploy step of CI pipelines fails with the task
+		id := taskRes.Data.Id
+		ctx, cancel := context.WithTimeout(context.Background(), timeout)
+		defer cancel()
+		if err := common.FollowTask(ctx, id); err != nil {
+			if errors.Is(err, context.DeadlineExceeded) {
+				common.PrintFatal("timeout waiting for deploy task id=%s after %s, the task is still running", id, timeout)
+			}
+			common.PrintFatal("failed to wait for deploy task id=%s: %v", id, err)
+		}
+		var historyRes *types.TaskHistoryRes
+		if err := common.LizardServer.Get(fmt.Sprintf("/lizardcd/db/task_history/%s", id)).SetResult(&historyRes).Do(context.Background()).Err; err != nil {
+			common.PrintFatal("failed to get task history: %v", err)
+		}
+		task := historyRes.Data
+		if task.Success.Valid && task.Success.Bool {
+			common.PrintSuccess("deploy task id=%s finished successfully", id)
+			return
+		}
+		common.PrintError("deploy task id=%s failed: status=%s err_message=%s", id, task.Status, task.ErrMessage)
+		for _, w := range task.TaskHistoryWorkloads {
+			if !w.Success.Valid || !w.Success.Bool {
+				common.PrintError("  %s/%s/%s/%s: %s", w.Workload.Cluster, w.Workload.Namespace, w.Workload.WorkloadType, w.Workload.WorkloadName, w.ErrMessage)
+			}
 		}
+		os.Exit(1)
 	},
 }
 
 func init() {
 	deployCmd.Flags().StringVar(&application, "name", "", "application name")
 	deployCmd.Flags().StringVar(&image, "image", "", "image name")
+	deployCmd.Flags().BoolVar(&wait, "wait", false, "wait until the deploy task is done, exit with non-zero code if the task failed")
+	deployCmd.Flags().DurationVar(&timeout, "timeout", 10*time.Minute, "timeout of waiting for the deploy task, used with --wait")
+	deployCmd.Flags().StringVar(&strategy, "strategy", "", "deploy strategy: rolling, canary or bluegreen, default to the strategy of application")
+	deployCmd.Flags().StringSliceVar(&workloadNames, "workload", nil, "only deploy these workloads of application, e.g. the canary workloads")
 	deployCmd.MarkFlagRequired("name")
 	deployCmd.MarkFlagRequired("image")
 }
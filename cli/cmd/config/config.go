@@ -10,6 +10,7 @@ import (
 )
 
 var serverAddr string
+var token string
 
 // configCmd represents the config command
 var ConfigCmd = &cobra.Command{
@@ -18,10 +19,14 @@ var ConfigCmd = &cobra.Command{
 	Run: func(cmd *cobra.Command, args []string) {
 		common.InitConfig()
 		viper.Set("lizardcd.server.url", serverAddr)
+		if token != "" {
+			viper.Set("lizardcd.auth.access_token", token)
+		}
 		viper.WriteConfig()
 	},
 }
 
 func init() {
 	ConfigCmd.Flags().StringVarP(&serverAddr, "lizardcd.server.addr", "s", "http://localhost:5117", "lizardcd-server address")
+	ConfigCmd.Flags().StringVar(&token, "token", "", "api token created by \"token create\", used instead of login")
 }
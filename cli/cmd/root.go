@@ -15,6 +15,7 @@ import (
 	"github.com/hongyuxuan/lizardcd/cli/cmd/login"
 	"github.com/hongyuxuan/lizardcd/cli/cmd/statefulset"
 	"github.com/hongyuxuan/lizardcd/cli/cmd/task"
+	"github.com/hongyuxuan/lizardcd/cli/cmd/token"
 	common "github.com/hongyuxuan/lizardcd/cli/common"
 	"github.com/spf13/cobra"
 )
@@ -48,4 +49,5 @@ func init() {
 	rootCmd.AddCommand(application.ApplicationCmd)
 	rootCmd.AddCommand(task.TaskCmd)
 	rootCmd.AddCommand(helm.HelmCmd)
+	rootCmd.AddCommand(token.TokenCmd)
 }
@@ -0,0 +1,47 @@
+/*
+Copyright © 2024 NAME HERE <EMAIL ADDRESS>
+*/
+package task
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/hongyuxuan/lizardcd/cli/common"
+	"github.com/spf13/cobra"
+)
+
+var reject bool
+var reason string
+
+// approveCmd represents the approve command
+var approveCmd = &cobra.Command{
+	Use:   "approve <id>",
+	Short: "Approve or reject the stage of a pipeline task which is waiting for approval",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		common.InitConfig()
+
+		action := "approve"
+		if reject {
+			action = "reject"
+		}
+		var res struct {
+			Data struct {
+				Id    string `json:"id"`
+				Stage string `json:"stage"`
+			} `json:"data"`
+		}
+		if err := common.LizardServer.Post(fmt.Sprintf("/lizardcd/task/%s/%s", action, args[0])).SetBody(map[string]interface{}{
+			"reason": reason,
+		}).SetResult(&res).Do(context.Background()).Err; err != nil {
+			common.PrintFatal("failed to %s task \"%s\": %v", action, args[0], err)
+		}
+		common.PrintSuccess("successfully %s stage \"%s\" (task id=%s), use \"%s task show --id %s --follow\" to see results", action, res.Data.Stage, res.Data.Id, common.GetExec(), args[0])
+	},
+}
+
+func init() {
+	approveCmd.Flags().BoolVar(&reject, "reject", false, "reject the stage, then the pipeline task is halted")
+	approveCmd.Flags().StringVar(&reason, "reason", "", "reason of rejection")
+}
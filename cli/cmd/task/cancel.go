@@ -0,0 +1,35 @@
+/*
+Copyright © 2024 NAME HERE <EMAIL ADDRESS>
+*/
+package task
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/hongyuxuan/lizardcd/cli/common"
+	"github.com/spf13/cobra"
+)
+
+var rollback bool
+
+// cancelCmd represents the cancel command
+var cancelCmd = &cobra.Command{
+	Use:   "cancel <id>",
+	Short: "Cancel a running task",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		common.InitConfig()
+
+		if err := common.LizardServer.Post(fmt.Sprintf("/lizardcd/task/cancel/%s", args[0])).SetBody(map[string]interface{}{
+			"rollback": rollback,
+		}).Do(context.Background()).Err; err != nil {
+			common.PrintFatal("failed to cancel task \"%s\": %v", args[0], err)
+		}
+		common.PrintSuccess("successfully cancel task \"%s\", use \"%s task show --id %s\" to see results", args[0], common.GetExec(), args[0])
+	},
+}
+
+func init() {
+	cancelCmd.Flags().BoolVar(&rollback, "rollback", false, "rollback workloads which have been updated")
+}
@@ -0,0 +1,87 @@
+/*
+Copyright © 2024 NAME HERE <EMAIL ADDRESS>
+*/
+package task
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+
+	"github.com/hongyuxuan/lizardcd/cli/common"
+	"github.com/hongyuxuan/lizardcd/cli/types"
+	commontypes "github.com/hongyuxuan/lizardcd/common/types"
+	"github.com/samber/lo"
+	"github.com/spf13/cobra"
+)
+
+const runAtLayout = "2006-01-02 15:04:05"
+
+// scheduleCmd represents the schedule command
+var scheduleCmd = &cobra.Command{
+	Use:   "schedule",
+	Short: "List/create/update/enable/disable/delete scheduled tasks",
+	Run: func(cmd *cobra.Command, args []string) {
+		fmt.Printf("Use \"%s task schedule [command] --help\" for more information about a command.\n", common.GetExec())
+	},
+}
+
+func init() {
+	scheduleCmd.AddCommand(scheduleListCmd)
+	scheduleCmd.AddCommand(scheduleCreateCmd)
+	scheduleCmd.AddCommand(scheduleUpdateCmd)
+	scheduleCmd.AddCommand(scheduleEnableCmd)
+	scheduleCmd.AddCommand(scheduleDisableCmd)
+	scheduleCmd.AddCommand(scheduleDeleteCmd)
+}
+
+// getScheduledTask gets a scheduled task by id
+func getScheduledTask(id string) commontypes.ScheduledTask {
+	var res *types.ScheduledTasksRes
+	if err := common.LizardServer.Get(fmt.Sprintf("/lizardcd/db/scheduled_task?page=1&size=1&filter=id==%s", url.QueryEscape(id))).SetResult(&res).Do(context.Background()).Err; err != nil {
+		common.PrintFatal("failed to get scheduled task \"%s\": %v", id, err)
+	}
+	if res.Data.Total == 0 {
+		common.PrintFatal("cannot find scheduled task \"%s\"", id)
+	}
+	return res.Data.Results[0]
+}
+
+// putScheduledTask updates all fields of a scheduled task, runAt replaces the one-shot time if not empty
+func putScheduledTask(st commontypes.ScheduledTask, runAt string) {
+	if runAt == "" && st.Cron == "" && st.RunAt.Valid {
+		runAt = st.RunAt.Time.Local().Format(runAtLayout)
+	}
+	if err := common.LizardServer.Put(fmt.Sprintf("/lizardcd/task/schedule/%s", st.Id)).SetBody(map[string]interface{}{
+		"name":         st.Name,
+		"app_name":     st.AppName,
+		"task_type":    st.TaskType,
+		"cron":         st.Cron,
+		"run_at":       runAt,
+		"enabled":      st.Enabled,
+		"labels":       st.Labels,
+		"workloads":    scheduleWorkloads(st.Workloads, ""),
+		"artifact_url": st.ArtifactUrl,
+		"strategy":     st.Strategy,
+	}).Do(context.Background()).Err; err != nil {
+		common.PrintFatal("failed to update scheduled task \"%s\": %v", st.Id, err)
+	}
+}
+
+// scheduleWorkloads converts workloads to the request body, image overrides the artifact_url of workloads if not empty
+func scheduleWorkloads(workloads []commontypes.WorkLoad, image string) []map[string]interface{} {
+	return lo.Map(workloads, func(w commontypes.WorkLoad, _ int) map[string]interface{} {
+		artifactUrl := w.ArtifactUrl
+		if image != "" {
+			artifactUrl = image
+		}
+		return map[string]interface{}{
+			"cluster":        w.Cluster,
+			"namespace":      w.Namespace,
+			"workload_type":  w.WorkloadType,
+			"workload_name":  w.WorkloadName,
+			"container_name": w.ContainerName,
+			"artifact_url":   artifactUrl,
+		}
+	})
+}
@@ -0,0 +1,82 @@
+/*
+Copyright © 2024 NAME HERE <EMAIL ADDRESS>
+*/
+package task
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+
+	"github.com/hongyuxuan/lizardcd/cli/common"
+	"github.com/hongyuxuan/lizardcd/cli/types"
+	commontypes "github.com/hongyuxuan/lizardcd/common/types"
+	"github.com/samber/lo"
+	"github.com/spf13/cobra"
+)
+
+var scheduleName string
+var scheduleApp string
+var scheduleTaskType string
+var scheduleCron string
+var scheduleRunAt string
+var scheduleImage string
+var scheduleStrategy string
+var scheduleWorkloadNames []string
+var scheduleDisabled bool
+
+// scheduleCreateCmd represents the schedule create command
+var scheduleCreateCmd = &cobra.Command{
+	Use:   "create",
+	Short: "Create a scheduled task by a cron expression or at a time, e.g. --cron \"0 2 * * *\" or --run-at \"2024-01-01 02:00:00\"",
+	Run: func(cmd *cobra.Command, args []string) {
+		common.InitConfig()
+
+		var res *types.ApplicationRes
+		if err := common.LizardServer.Get(fmt.Sprintf("/lizardcd/db/application?page=1&size=1&filter=app_name==%s", url.QueryEscape(scheduleApp))).SetResult(&res).Do(context.Background()).Err; err != nil {
+			common.PrintFatal("failed to get application \"%s\": %v", scheduleApp, err)
+		}
+		if res.Data.Total == 0 {
+			common.PrintFatal("cannot find application \"%s\"", scheduleApp)
+		}
+		workloads := res.Data.Results[0].Workload
+		if len(scheduleWorkloadNames) > 0 {
+			workloads = lo.Filter(workloads, func(w commontypes.WorkLoad, _ int) bool {
+				return lo.Contains(scheduleWorkloadNames, w.WorkloadName)
+			})
+			if len(workloads) == 0 {
+				common.PrintFatal("cannot find workloads %v in application \"%s\"", scheduleWorkloadNames, scheduleApp)
+			}
+		}
+
+		var createRes *types.TaskRunRes
+		if err := common.LizardServer.Post("/lizardcd/task/schedule").SetBody(map[string]interface{}{
+			"name":      scheduleName,
+			"app_name":  scheduleApp,
+			"task_type": scheduleTaskType,
+			"cron":      scheduleCron,
+			"run_at":    scheduleRunAt,
+			"enabled":   !scheduleDisabled,
+			"strategy":  scheduleStrategy,
+			"workloads": scheduleWorkloads(workloads, scheduleImage),
+		}).SetResult(&createRes).Do(context.Background()).Err; err != nil {
+			common.PrintFatal("failed to create scheduled task: %v", err)
+		}
+		common.PrintSuccess("successfully create scheduled task id=%s, use \"%s task schedule list\" to see next run time", createRes.Data.Id, common.GetExec())
+	},
+}
+
+func init() {
+	scheduleCreateCmd.Flags().StringVar(&scheduleName, "name", "", "scheduled task name (required)")
+	scheduleCreateCmd.Flags().StringVar(&scheduleApp, "app", "", "application name (required)")
+	scheduleCreateCmd.Flags().StringVar(&scheduleTaskType, "task-type", "deploy", "task type: deploy or rollout")
+	scheduleCreateCmd.Flags().StringVar(&scheduleCron, "cron", "", "cron expression, e.g. \"0 2 * * *\"")
+	scheduleCreateCmd.Flags().StringVar(&scheduleRunAt, "run-at", "", "run once at this time, format \"2006-01-02 15:04:05\"")
+	scheduleCreateCmd.Flags().StringVar(&scheduleImage, "image", "", "image name, required by deploy task")
+	scheduleCreateCmd.Flags().StringVar(&scheduleStrategy, "strategy", "", "deploy strategy: rolling, canary or bluegreen, default to the strategy of application")
+	scheduleCreateCmd.Flags().StringSliceVar(&scheduleWorkloadNames, "workload", nil, "only run on these workloads of application")
+	scheduleCreateCmd.Flags().BoolVar(&scheduleDisabled, "disabled", false, "create the scheduled task disabled")
+	scheduleCreateCmd.MarkFlagRequired("name")
+	scheduleCreateCmd.MarkFlagRequired("app")
+	scheduleCreateCmd.MarkFlagsMutuallyExclusive("cron", "run-at")
+}
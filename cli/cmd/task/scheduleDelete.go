@@ -0,0 +1,27 @@
+/*
+Copyright © 2024 NAME HERE <EMAIL ADDRESS>
+*/
+package task
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/hongyuxuan/lizardcd/cli/common"
+	"github.com/spf13/cobra"
+)
+
+// scheduleDeleteCmd represents the schedule delete command
+var scheduleDeleteCmd = &cobra.Command{
+	Use:   "delete <id>",
+	Short: "Delete a scheduled task",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		common.InitConfig()
+
+		if err := common.LizardServer.Delete(fmt.Sprintf("/lizardcd/task/schedule/%s", args[0])).Do(context.Background()).Err; err != nil {
+			common.PrintFatal("failed to delete scheduled task \"%s\": %v", args[0], err)
+		}
+		common.PrintSuccess("successfully delete scheduled task \"%s\"", args[0])
+	},
+}
@@ -0,0 +1,70 @@
+/*
+Copyright © 2024 NAME HERE <EMAIL ADDRESS>
+*/
+package task
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strconv"
+
+	"github.com/golang-module/carbon"
+	"github.com/hongyuxuan/lizardcd/cli/common"
+	"github.com/hongyuxuan/lizardcd/cli/types"
+	"github.com/olekukonko/tablewriter"
+	"github.com/spf13/cobra"
+)
+
+// scheduleListCmd represents the schedule list command
+var scheduleListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List scheduled tasks",
+	Run: func(cmd *cobra.Command, args []string) {
+		common.InitConfig()
+
+		table := tablewriter.NewWriter(os.Stdout)
+		table.SetHeader([]string{"id", "name", "app_name", "task_type", "schedule", "enabled", "next_run_at", "last_run_at", "last_task_id", "last_error"})
+		if !common.Nocolor {
+			colors := tablewriter.Colors{tablewriter.Bold, tablewriter.BgGreenColor}
+			table.SetHeaderColor(colors, colors, colors, colors, colors, colors, colors, colors, colors, colors)
+		}
+		table.SetAlignment(tablewriter.ALIGN_LEFT)
+		table.SetAutoWrapText(false)
+
+		var res *types.ScheduledTasksRes
+		if err := common.LizardServer.Get(fmt.Sprintf("/lizardcd/db/scheduled_task?page=%d&size=%d&sort=update_at%%20desc", page, limit)).SetResult(&res).Do(context.Background()).Err; err != nil {
+			common.PrintFatal("failed to list scheduled tasks: %v", err)
+		}
+
+		for _, d := range res.Data.Results {
+			schedule := d.Cron
+			if schedule == "" && d.RunAt.Valid {
+				schedule = "at " + carbon.FromStdTime(d.RunAt.Time).Format("Y-m-d H:i:s")
+			}
+			var nextRunAt, lastRunAt string
+			if d.NextRunAt.Valid {
+				nextRunAt = carbon.FromStdTime(d.NextRunAt.Time).Format("Y-m-d H:i:s")
+			}
+			if d.LastRunAt.Valid {
+				lastRunAt = carbon.FromStdTime(d.LastRunAt.Time).Format("Y-m-d H:i:s")
+			}
+			var colors tablewriter.Colors
+			if !common.Nocolor {
+				if d.Enabled {
+					colors = tablewriter.Colors{tablewriter.Normal, tablewriter.FgGreenColor}
+				} else {
+					colors = tablewriter.Colors{tablewriter.Normal, tablewriter.FgRedColor}
+				}
+			}
+			row := []string{d.Id, d.Name, d.AppName, d.TaskType, schedule, strconv.FormatBool(d.Enabled), nextRunAt, lastRunAt, d.LastTaskId, d.LastError}
+			table.Rich(row, []tablewriter.Colors{{}, {}, {}, {}, {}, colors, {}, {}, {}, {}})
+		}
+		table.Render()
+	},
+}
+
+func init() {
+	scheduleListCmd.Flags().Int32Var(&page, "page", 1, "pages of listing scheduled tasks")
+	scheduleListCmd.Flags().Int32Var(&limit, "limit", 10, "pageSize of listing scheduled tasks")
+}
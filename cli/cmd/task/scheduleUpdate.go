@@ -0,0 +1,83 @@
+/*
+Copyright © 2024 NAME HERE <EMAIL ADDRESS>
+*/
+package task
+
+import (
+	"github.com/hongyuxuan/lizardcd/cli/common"
+	commontypes "github.com/hongyuxuan/lizardcd/common/types"
+	"github.com/samber/lo"
+	"github.com/spf13/cobra"
+)
+
+// scheduleUpdateCmd represents the schedule update command
+var scheduleUpdateCmd = &cobra.Command{
+	Use:   "update <id>",
+	Short: "Update the schedule, image or strategy of a scheduled task",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		common.InitConfig()
+
+		st := getScheduledTask(args[0])
+		flags := cmd.Flags()
+		if flags.Changed("name") {
+			st.Name = scheduleName
+		}
+		if flags.Changed("cron") {
+			st.Cron = scheduleCron
+		}
+		if flags.Changed("run-at") {
+			st.Cron = "" // the one-shot time is replaced by scheduleRunAt
+		}
+		if flags.Changed("strategy") {
+			st.Strategy = scheduleStrategy
+		}
+		if flags.Changed("image") {
+			st.Workloads = lo.Map(st.Workloads, func(w commontypes.WorkLoad, _ int) commontypes.WorkLoad {
+				w.ArtifactUrl = scheduleImage
+				return w
+			})
+		}
+		putScheduledTask(st, scheduleRunAt)
+		common.PrintSuccess("successfully update scheduled task \"%s\"", st.Id)
+	},
+}
+
+// scheduleEnableCmd represents the schedule enable command
+var scheduleEnableCmd = &cobra.Command{
+	Use:   "enable <id>",
+	Short: "Enable a scheduled task",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		common.InitConfig()
+
+		st := getScheduledTask(args[0])
+		st.Enabled = true
+		putScheduledTask(st, "")
+		common.PrintSuccess("successfully enable scheduled task \"%s\"", st.Id)
+	},
+}
+
+// scheduleDisableCmd represents the schedule disable command
+var scheduleDisableCmd = &cobra.Command{
+	Use:   "disable <id>",
+	Short: "Disable a scheduled task",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		common.InitConfig()
+
+		st := getScheduledTask(args[0])
+		st.Enabled = false
+		putScheduledTask(st, "")
+		common.PrintSuccess("successfully disable scheduled task \"%s\"", st.Id)
+	},
+}
+
+func init() {
+	scheduleUpdateCmd.Flags().StringVar(&scheduleName, "name", "", "scheduled task name")
+	scheduleUpdateCmd.Flags().StringVar(&scheduleCron, "cron", "", "cron expression, e.g. \"0 2 * * *\"")
+	scheduleUpdateCmd.Flags().StringVar(&scheduleRunAt, "run-at", "", "run once at this time, format \"2006-01-02 15:04:05\"")
+	scheduleUpdateCmd.Flags().StringVar(&scheduleImage, "image", "", "image name of all workloads")
+	scheduleUpdateCmd.Flags().StringVar(&scheduleStrategy, "strategy", "", "deploy strategy: rolling, canary or bluegreen")
+	scheduleUpdateCmd.MarkFlagsMutuallyExclusive("cron", "run-at")
+}
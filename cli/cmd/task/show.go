@@ -13,12 +13,14 @@ import (
 	"github.com/golang-module/carbon"
 	"github.com/hongyuxuan/lizardcd/cli/common"
 	"github.com/hongyuxuan/lizardcd/cli/types"
+	commontypes "github.com/hongyuxuan/lizardcd/common/types"
 	"github.com/olekukonko/tablewriter"
 	"github.com/samber/lo"
 	"github.com/spf13/cobra"
 )
 
 var id string
+var follow bool
 
 type podStatus struct {
 	PodName string `json:"pod_name"`
@@ -32,6 +34,12 @@ var showCmd = &cobra.Command{
 	Run: func(cmd *cobra.Command, args []string) {
 		common.InitConfig()
 
+		if follow {
+			if err := common.FollowTask(context.Background(), id); err != nil {
+				common.PrintFatal("failed to follow task: %v", err)
+			}
+		}
+
 		table := tablewriter.NewWriter(os.Stdout)
 		table.SetHeader([]string{"cluster", "namespace", "deployment_type", "deployment_name", "container_name", "image", "status", "err_message", "update_at"})
 		if !common.Nocolor {
@@ -46,8 +54,13 @@ var showCmd = &cobra.Command{
 			common.PrintFatal("failed to get task history: %v", err)
 		}
 
+		workloads := res.Data.TaskHistoryWorkloads
+		if len(res.Data.Stages) > 0 { // pipeline task, the workloads belong to its stage tasks
+			workloads = showStages(id)
+		}
+
 		var data [][]string
-		for _, w := range res.Data.TaskHistoryWorkloads {
+		for _, w := range workloads {
 			update_at := carbon.FromStdTime(w.UpdateAt).Format("Y-m-d H:i:s")
 			var podstatus []podStatus
 			json.Unmarshal([]byte(w.Status), &podstatus)
@@ -68,10 +81,40 @@ var showCmd = &cobra.Command{
 			table.Rich(row, []tablewriter.Colors{{}, {}, {}, {}, {}, {}, {}, colors, {}})
 		}
 		table.Render()
+		if follow && !(res.Data.Success.Valid && res.Data.Success.Bool) {
+			os.Exit(1)
+		}
 	},
 }
 
+// showStages prints the stage tasks of a pipeline task and returns their workloads
+func showStages(id string) (workloads []commontypes.TaskHistoryWorkload) {
+	var stagesRes *types.TaskHistoriesRes
+	if err := common.LizardServer.Get(fmt.Sprintf("/lizardcd/db/task_history?page=1&size=100&preload=true&sort=init_at&filter=parent_id==%s", id)).SetResult(&stagesRes).Do(context.Background()).Err; err != nil {
+		common.PrintFatal("failed to list stage tasks: %v", err)
+	}
+	table := tablewriter.NewWriter(os.Stdout)
+	table.SetHeader([]string{"stage", "id", "status", "result", "approved_by", "rejected_by", "err_message"})
+	if !common.Nocolor {
+		colors := tablewriter.Colors{tablewriter.Bold, tablewriter.BgGreenColor}
+		table.SetHeaderColor(colors, colors, colors, colors, colors, colors, colors)
+	}
+	table.SetAlignment(tablewriter.ALIGN_LEFT)
+	table.SetAutoWrapText(false)
+	for _, t := range stagesRes.Data.Results {
+		var result string
+		if t.Success.Valid {
+			result = lo.Ternary(t.Success.Bool, "SUCCESS", "FAIL")
+		}
+		table.Append([]string{t.Stage, t.Id, t.Status, result, t.ApprovedBy, t.RejectedBy, t.ErrMessage})
+		workloads = append(workloads, t.TaskHistoryWorkloads...)
+	}
+	table.Render()
+	return
+}
+
 func init() {
 	showCmd.Flags().StringVar(&id, "id", "", "task id")
 	showCmd.MarkFlagRequired("id")
+	showCmd.Flags().BoolVarP(&follow, "follow", "f", false, "stream task progress until the task is done, exit with non-zero code if the task failed")
 }
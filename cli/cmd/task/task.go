@@ -13,7 +13,7 @@ import (
 // taskCmd represents the task command
 var TaskCmd = &cobra.Command{
 	Use:   "task",
-	Short: "List/show task history",
+	Short: "List/show/cancel/approve/schedule tasks",
 	Run: func(cmd *cobra.Command, args []string) {
 		fmt.Printf("Use \"%s task [command] --help\" for more information about a command.", common.GetExec())
 	},
@@ -22,4 +22,7 @@ var TaskCmd = &cobra.Command{
 func init() {
 	TaskCmd.AddCommand(listCmd)
 	TaskCmd.AddCommand(showCmd)
+	TaskCmd.AddCommand(cancelCmd)
+	TaskCmd.AddCommand(approveCmd)
+	TaskCmd.AddCommand(scheduleCmd)
 }
@@ -0,0 +1,51 @@
+/*
+Copyright © 2024 NAME HERE <EMAIL ADDRESS>
+*/
+package token
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/hongyuxuan/lizardcd/cli/common"
+	"github.com/hongyuxuan/lizardcd/cli/types"
+	"github.com/spf13/cobra"
+)
+
+var name string
+var role string
+var tenant string
+var applications []string
+var expireAt string
+
+// createCmd represents the token create command
+var createCmd = &cobra.Command{
+	Use:   "create",
+	Short: "Create an api token, the token is only printed once",
+	Run: func(cmd *cobra.Command, args []string) {
+		common.InitConfig()
+
+		var res *types.ApiTokenCreateRes
+		if err := common.LizardServer.Post("/lizardcd/token/tokens").SetBody(map[string]interface{}{
+			"name":         name,
+			"role":         role,
+			"tenant":       tenant,
+			"applications": applications,
+			"expire_at":    expireAt,
+		}).SetResult(&res).Do(context.Background()).Err; err != nil {
+			common.PrintFatal("failed to create api token: %v", err)
+		}
+		common.PrintSuccess("successfully create api token id=%s, it will not be shown again:", res.Data.Token.Id)
+		fmt.Println(res.Data.AccessToken)
+		fmt.Printf("use it by environment variable LIZARDCD_TOKEN or \"%s config --token\"\n", common.GetExec())
+	},
+}
+
+func init() {
+	createCmd.Flags().StringVar(&name, "name", "", "api token name (required)")
+	createCmd.Flags().StringVar(&role, "role", "readwrite", "role of api token: admin, readwrite or readonly")
+	createCmd.Flags().StringVar(&tenant, "tenant", "", "tenant of api token, default to the tenant of current user")
+	createCmd.Flags().StringSliceVar(&applications, "app", nil, "only allow running tasks of these applications")
+	createCmd.Flags().StringVar(&expireAt, "expire-at", "", "expire time, format \"2006-01-02 15:04:05\", never expires if empty")
+	createCmd.MarkFlagRequired("name")
+}
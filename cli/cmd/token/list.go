@@ -0,0 +1,58 @@
+/*
+Copyright © 2024 NAME HERE <EMAIL ADDRESS>
+*/
+package token
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"os"
+	"strings"
+
+	"github.com/golang-module/carbon"
+	"github.com/hongyuxuan/lizardcd/cli/common"
+	"github.com/hongyuxuan/lizardcd/cli/types"
+	"github.com/olekukonko/tablewriter"
+	"github.com/spf13/cobra"
+)
+
+// listCmd represents the token list command
+var listCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List api tokens",
+	Run: func(cmd *cobra.Command, args []string) {
+		common.InitConfig()
+
+		table := tablewriter.NewWriter(os.Stdout)
+		table.SetHeader([]string{"id", "name", "role", "tenant", "applications", "expire_at", "last_used_at", "create_by", "create_at"})
+		if !common.Nocolor {
+			colors := tablewriter.Colors{tablewriter.Bold, tablewriter.BgGreenColor}
+			table.SetHeaderColor(colors, colors, colors, colors, colors, colors, colors, colors, colors)
+		}
+		table.SetAlignment(tablewriter.ALIGN_LEFT)
+		table.SetAutoWrapText(false)
+
+		var res *types.ApiTokenRes
+		if err := common.LizardServer.Get(fmt.Sprintf("/lizardcd/token/tokens?tenant=%s", url.QueryEscape(tenant))).SetResult(&res).Do(context.Background()).Err; err != nil {
+			common.PrintFatal("failed to list api tokens: %v", err)
+		}
+
+		for _, d := range res.Data {
+			expire := "never"
+			if d.ExpireAt.Valid {
+				expire = carbon.FromStdTime(d.ExpireAt.Time).Format("Y-m-d H:i:s")
+			}
+			var lastUsedAt string
+			if d.LastUsedAt.Valid {
+				lastUsedAt = carbon.FromStdTime(d.LastUsedAt.Time).DiffForHumans()
+			}
+			table.Append([]string{d.Id, d.Name, d.Role, d.Tenant, strings.Join(d.Applications, ","), expire, lastUsedAt, d.CreateBy, carbon.FromStdTime(d.CreateAt).Format("Y-m-d H:i:s")})
+		}
+		table.Render()
+	},
+}
+
+func init() {
+	listCmd.Flags().StringVar(&tenant, "tenant", "", "only list api tokens of this tenant, admin only")
+}
@@ -0,0 +1,27 @@
+/*
+Copyright © 2024 NAME HERE <EMAIL ADDRESS>
+*/
+package token
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/hongyuxuan/lizardcd/cli/common"
+	"github.com/spf13/cobra"
+)
+
+// revokeCmd represents the token revoke command
+var revokeCmd = &cobra.Command{
+	Use:   "revoke <id>",
+	Short: "Revoke an api token",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		common.InitConfig()
+
+		if err := common.LizardServer.Delete(fmt.Sprintf("/lizardcd/token/tokens/%s", args[0])).Do(context.Background()).Err; err != nil {
+			common.PrintFatal("failed to revoke api token \"%s\": %v", args[0], err)
+		}
+		common.PrintSuccess("successfully revoke api token \"%s\"", args[0])
+	},
+}
@@ -0,0 +1,26 @@
+/*
+Copyright © 2024 NAME HERE <EMAIL ADDRESS>
+*/
+package token
+
+import (
+	"fmt"
+
+	"github.com/hongyuxuan/lizardcd/cli/common"
+	"github.com/spf13/cobra"
+)
+
+// TokenCmd represents the token command
+var TokenCmd = &cobra.Command{
+	Use:   "token",
+	Short: "Create/list/revoke api tokens for CI systems",
+	Run: func(cmd *cobra.Command, args []string) {
+		fmt.Printf("Use \"%s token [command] --help\" for more information about a command.\n", common.GetExec())
+	},
+}
+
+func init() {
+	TokenCmd.AddCommand(createCmd)
+	TokenCmd.AddCommand(listCmd)
+	TokenCmd.AddCommand(revokeCmd)
+}
@@ -44,7 +44,10 @@ func InitConfig() {
 	}
 	LizardServer = utils.NewHttpClient(otel.Tracer("imroc/req"))
 	LizardServer.SetBaseURL(serverAddr)
-	if access_token := viper.GetString("lizardcd.auth.access_token"); access_token != "" {
+	// an api token in environment, e.g. of CI systems, overrides the token of login
+	if access_token := os.Getenv("LIZARDCD_TOKEN"); access_token != "" {
+		LizardServer.SetCommonBearerAuthToken(access_token)
+	} else if access_token := viper.GetString("lizardcd.auth.access_token"); access_token != "" {
 		LizardServer.SetCommonBearerAuthToken(access_token)
 	}
 	if LogLevel == "debug" {
@@ -0,0 +1,68 @@
+package common
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/hongyuxuan/lizardcd/common/utils"
+	"github.com/spf13/viper"
+)
+
+// StreamEvents reads server-sent events from lizardcd-server and calls handler for each event,
+// until the stream is closed by server, ctx is done or handler returns false. done reports whether handler stopped the stream.
+func StreamEvents(ctx context.Context, path string, handler func(event string, data []byte) bool) (done bool, err error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, strings.TrimSuffix(viper.GetString("lizardcd.server.url"), "/")+path, nil)
+	if err != nil {
+		return
+	}
+	req.Header.Set("Accept", "text/event-stream")
+	req.Header.Set("Cache-Control", "no-cache")
+	if access_token := viper.GetString("lizardcd.auth.access_token"); access_token != "" {
+		req.Header.Set("Authorization", "Bearer "+access_token)
+	}
+	res, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(res.Body)
+		err = fmt.Errorf("%s: %s", res.Status, string(body))
+		return
+	}
+
+	scanner := bufio.NewScanner(res.Body)
+	scanner.Buffer(make([]byte, 64*1024), 4*1024*1024)
+	var event string
+	var data bytes.Buffer
+	for scanner.Scan() {
+		line := scanner.Text()
+		switch {
+		case line == "":
+			// blank line dispatches the event
+			if data.Len() > 0 {
+				utils.Log.Debugf("receive event=%s data=%s", event, data.String())
+				if !handler(event, data.Bytes()) {
+					return true, nil
+				}
+			}
+			event = ""
+			data.Reset()
+		case strings.HasPrefix(line, ":"):
+			// comment, e.g. keepalive
+		case strings.HasPrefix(line, "event:"):
+			event = strings.TrimSpace(strings.TrimPrefix(line, "event:"))
+		case strings.HasPrefix(line, "data:"):
+			if data.Len() > 0 {
+				data.WriteByte('\n')
+			}
+			data.WriteString(strings.TrimPrefix(strings.TrimPrefix(line, "data:"), " "))
+		}
+	}
+	return false, scanner.Err()
+}
@@ -0,0 +1,75 @@
+package common
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/golang-module/carbon"
+	"github.com/hongyuxuan/lizardcd/common/constant"
+	commontypes "github.com/hongyuxuan/lizardcd/common/types"
+	"github.com/samber/lo"
+)
+
+// FollowTask prints the progress of a task until it is done or ctx is done, reconnecting when the stream is closed by server
+func FollowTask(ctx context.Context, id string) (err error) {
+	printed := make(map[string]string) // last printed line of the task and workloads, to skip duplicates after reconnecting
+	printLine := func(key, line string) {
+		if printed[key] == line {
+			return
+		}
+		printed[key] = line
+		fmt.Printf("%s %s\n", carbon.Now().Format("Y-m-d H:i:s"), line)
+	}
+	printWorkload := func(w commontypes.TaskHistoryWorkload) {
+		var podstatus []commontypes.PodStatus
+		json.Unmarshal([]byte(w.Status), &podstatus)
+		status := lo.Map(podstatus, func(p commontypes.PodStatus, _ int) string {
+			if p.Reason != "" {
+				return p.PodName + ":" + p.Ready + "(" + p.Reason + ")"
+			}
+			return p.PodName + ":" + p.Ready
+		})
+		line := fmt.Sprintf("workload %s/%s/%s/%s: %s", w.Workload.Cluster, w.Workload.Namespace, w.Workload.WorkloadType, w.Workload.WorkloadName, strings.Join(status, ","))
+		if w.ErrMessage != "" {
+			line += " err_message=" + w.ErrMessage
+		}
+		printLine(fmt.Sprintf("workload-%d", w.Id), line)
+	}
+
+	for {
+		var done bool
+		done, err = StreamEvents(ctx, fmt.Sprintf("/lizardcd/task/events/%s", id), func(event string, data []byte) bool {
+			switch event {
+			case "task":
+				var task commontypes.TaskHistory
+				if err = json.Unmarshal(data, &task); err != nil {
+					return false
+				}
+				line := fmt.Sprintf("task %s status=%s", task.Id, task.Status)
+				if task.Success.Valid {
+					line += fmt.Sprintf(" success=%v", task.Success.Bool)
+				}
+				if task.ErrMessage != "" {
+					line += " err_message=" + task.ErrMessage
+				}
+				printLine("task", line)
+				for _, w := range task.TaskHistoryWorkloads {
+					printWorkload(w)
+				}
+				return task.Status != constant.TASK_STATUS_FINISHED && task.Status != constant.TASK_STATUS_TERMINATED && task.Status != constant.TASK_STATUS_CANCELLED
+			case "workload":
+				var w commontypes.TaskHistoryWorkload
+				if err = json.Unmarshal(data, &w); err != nil {
+					return false
+				}
+				printWorkload(w)
+			}
+			return true
+		})
+		if err != nil || done {
+			return
+		}
+	}
+}
@@ -58,11 +58,39 @@ type TaskHistoriesRes struct {
 	} `json:"data"`
 }
 
+type TaskRunRes struct {
+	Code int `json:"code"`
+	Data struct {
+		Id string `json:"id"`
+	} `json:"data"`
+}
+
 type TaskHistoryRes struct {
 	Code int                     `json:"code"`
 	Data commontypes.TaskHistory `json:"data"`
 }
 
+type ApiTokenRes struct {
+	Code int                    `json:"code"`
+	Data []commontypes.ApiToken `json:"data"`
+}
+
+type ApiTokenCreateRes struct {
+	Code int `json:"code"`
+	Data struct {
+		Token       commontypes.ApiToken `json:"token"`
+		AccessToken string               `json:"access_token"`
+	} `json:"data"`
+}
+
+type ScheduledTasksRes struct {
+	Code int `json:"code"`
+	Data struct {
+		Total   int                         `json:"total"`
+		Results []commontypes.ScheduledTask `json:"results"`
+	} `json:"data"`
+}
+
 type HelmRepoRes struct {
 	Code int `json:"code"`
 	Data struct {
@@ -14,5 +14,57 @@ const ROLE_ADMIN = "admin"
 const ROLE_READWRITE = "readwrite"
 const ROLE_READONLY = "readonly"
 
+const USER_SOURCE_LOCAL = "local"
+const USER_SOURCE_LDAP = "ldap"
+const USER_SOURCE_OIDC = "oidc"
+
 const K8S_TASK_TYPE_ROLLOUT = "rollout"
 const K8S_TASK_TYPE_DEPLOY = "deploy"
+
+const DEPLOY_TYPE_CONTAINER = "容器"
+const DEPLOY_TYPE_VM = "虚拟机"
+const DEPLOY_TYPE_HTTP = "HTTP"
+
+const TASK_STATUS_INITIALIZE = "initialize"
+const TASK_STATUS_RUNNING = "running"
+const TASK_STATUS_RECOVERED = "recovered"
+const TASK_STATUS_FINISHED = "finished"
+const TASK_STATUS_TERMINATED = "terminated"
+const TASK_STATUS_CANCELLED = "cancelled"
+const TASK_STATUS_WAITING_APPROVAL = "waiting_approval"
+
+const TASK_REASON_TIMEOUT = "timeout"
+const TASK_REASON_CANCELLED = "cancelled"
+
+const TRIGGER_TYPE_ROLLBACK = "rollback"
+const TRIGGER_TYPE_SCHEDULER = "scheduler"
+const TRIGGER_TYPE_WEBHOOK = "webhook"
+const TRIGGER_TYPE_IMAGE_UPDATER = "image-updater"
+
+const IMAGE_UPDATER_MODE_DEPLOY = "deploy"
+const IMAGE_UPDATER_MODE_NOTIFY = "notify"
+
+const DEPLOY_STRATEGY_ROLLING = "rolling"
+const DEPLOY_STRATEGY_CANARY = "canary"
+const DEPLOY_STRATEGY_BLUEGREEN = "bluegreen"
+
+const NOTIFY_EVENT_START = "start"
+const NOTIFY_EVENT_SUCCESS = "success"
+const NOTIFY_EVENT_FAILURE = "failure"
+const NOTIFY_EVENT_TIMEOUT = "timeout"
+const NOTIFY_EVENT_TEST = "test"
+
+const NOTIFY_CHANNEL_WEBHOOK = "webhook"
+const NOTIFY_CHANNEL_DINGTALK = "dingtalk"
+const NOTIFY_CHANNEL_WECOM = "wecom"
+const NOTIFY_CHANNEL_SLACK = "slack"
+const NOTIFY_CHANNEL_EMAIL = "email"
+
+const AUDIT_SOURCE_API = "api"
+const AUDIT_SOURCE_RPC = "rpc"
+
+const RBAC_SUBJECT_USER = "user"
+const RBAC_SUBJECT_GROUP = "group"
+
+const RBAC_EFFECT_ALLOW = "allow"
+const RBAC_EFFECT_DENY = "deny"
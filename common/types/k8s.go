@@ -6,6 +6,25 @@ type WorkloadStatus struct {
 }
 
 type PodStatus struct {
-	PodName string `json:"pod_name"`
-	Ready   string `json:"ready"`
+	PodName  string `json:"pod_name"`
+	Ready    string `json:"ready"`
+	Revision string `json:"revision,omitempty"` // controller revision of statefulset pod
+	Reason   string `json:"reason,omitempty"`   // waiting reason of container, e.g. ImagePullBackOff, CrashLoopBackOff
+	Message  string `json:"message,omitempty"`
+}
+
+type RolloutStatus struct {
+	Name               string      `json:"name"`
+	Generation         int64       `json:"generation"`
+	ObservedGeneration int64       `json:"observed_generation"`
+	Replicas           int32       `json:"replicas"`
+	UpdatedReplicas    int32       `json:"updated_replicas"`
+	ReadyReplicas      int32       `json:"ready_replicas"`
+	AvailableReplicas  int32       `json:"available_replicas"`
+	CurrentRevision    string      `json:"current_revision,omitempty"` // statefulset only
+	UpdateRevision     string      `json:"update_revision,omitempty"`  // statefulset only
+	Finished           bool        `json:"finished"`
+	Failed             bool        `json:"failed"` // e.g. ProgressDeadlineExceeded
+	Message            string      `json:"message"`
+	Pods               []PodStatus `json:"pod_status"`
 }
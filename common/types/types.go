@@ -4,11 +4,15 @@ import (
 	"database/sql"
 	"database/sql/driver"
 	"encoding/json"
+	"path"
 	"time"
 )
 
 type TraceIDKey struct{}
 
+// ApiTokenKey is the context key of the ApiToken authenticating a request
+type ApiTokenKey struct{}
+
 type Response struct {
 	Code    int         `json:"code"`
 	Data    interface{} `json:"data"`
@@ -27,6 +31,9 @@ func (s StringList) Value() (driver.Value, error) {
 }
 
 func (s *StringList) Scan(value interface{}) error {
+	if value == nil {
+		return nil
+	}
 	return json.Unmarshal([]byte(value.(string)), &s)
 }
 
@@ -66,52 +73,78 @@ type ApplicationTemplate struct {
 }
 
 type User struct {
-	Id       int       `json:"id" gorm:"primaryKey,autoIncrement"`
-	Username string    `json:"username" gorm:"size:50;unique"`
-	Password string    `json:",omitempty" gorm:"size:100"`
-	Role     string    `json:"role" gorm:"size:50"`
-	Tenant   string    `json:"tenant" gorm:"size:50"`
-	UpdateAt time.Time `json:"update_at"`
+	Id       int        `json:"id" gorm:"primaryKey,autoIncrement"`
+	Username string     `json:"username" gorm:"size:50;unique"`
+	Password string     `json:",omitempty" gorm:"size:100"`
+	Role     string     `json:"role" gorm:"size:50"`
+	Tenant   string     `json:"tenant" gorm:"size:50"`
+	Groups   StringList `json:"groups" gorm:"type:json"` // groups bound by rbac policies
+	Source   string     `json:"source" gorm:"size:20"`   // local, ldap or oidc
+	Subject  string     `json:"subject" gorm:"size:300"` // id of the user in its identity provider, e.g. the dn of ldap or issuer|sub of oidc
+	UpdateAt time.Time  `json:"update_at"`
 }
 
 type Tenant struct {
 	Id         int       `json:"id" gorm:"primaryKey,autoIncrement"`
 	TenantName string    `json:"tenant_name" gorm:"size:50;unique"`
-	Namespaces string    `json:"namespaces"`
+	Namespaces string    `json:"namespaces"` // json array of NsCluster
 	UpdateAt   time.Time `json:"update_at"`
 }
 
+// NsCluster is a namespace of a cluster owned by a tenant
+type NsCluster struct {
+	Namespace string `json:"namespace"`
+	Cluster   string `json:"cluster"`
+}
+
 type ImageRepository struct {
 	Id           int    `json:"id" gorm:"primaryKey,autoIncrement"`
 	RepoUrl      string `json:"repo_url" gorm:"size:300;uniqueIndex:idx_repo"`
 	RepoAccount  string `json:"repo_account" gorm:"size:50;uniqueIndex:idx_repo"`
-	RepoPassword string `json:"repo_password" gorm:"size:100"`
+	RepoPassword string `json:"repo_password" gorm:"size:500"` // encrypted by the master key of lizardcd-server
 	RepoType     string `json:"repo_type" gorm:"size:20"`
 	Tenant       string `json:"tenant" gorm:"size:50;uniqueIndex:idx_repo"`
 }
 
 func (r ImageRepository) Value() (driver.Value, error) {
-	return json.Marshal(r)
+	b, err := json.Marshal(r)
+	return string(b), err
 }
 
 func (r *ImageRepository) Scan(value interface{}) error {
-	return json.Unmarshal([]byte(value.(string)), &r)
+	switch v := value.(type) {
+	case string:
+		return json.Unmarshal([]byte(v), &r)
+	case []byte:
+		return json.Unmarshal(v, &r)
+	}
+	return nil
 }
 
 type Application struct {
-	Id                   int             `json:"id" gorm:"primaryKey,autoIncrement"`
-	AppName              string          `json:"app_name" gorm:"size:300;unique"`
-	DeployType           string          `json:"deploy_type" gorm:"size:10"`
-	Repo                 ImageRepository `json:"repo" gorm:"type:json"`
-	RepoName             string          `json:"repo_name" gorm:"size:50"`
-	ImageName            string          `json:"image_name" gorm:"size:300"`
-	Workload             WorkLoadList    `json:"workload" gorm:"type:json"`
-	EnableTrafficControl bool            `json:"enable_traffic_control"`
-	TrafficPolicy        string          `json:"traffic_policy"`
-	Tenant               string          `json:"tenant" gorm:"size:50"`
-	Tags                 StringList      `json:"tags" gorm:"type:json"`
-	ExtraVars            string          `json:"extra_vars" gorm:"type:json"`
-	UpdateAt             time.Time       `json:"update_at"`
+	Id                   int                `json:"id" gorm:"primaryKey,autoIncrement"`
+	AppName              string             `json:"app_name" gorm:"size:300;unique"`
+	DeployType           string             `json:"deploy_type" gorm:"size:10"`
+	Repo                 ImageRepository    `json:"repo" gorm:"type:json"`
+	RepoName             string             `json:"repo_name" gorm:"size:50"`
+	ImageName            string             `json:"image_name" gorm:"size:300"`
+	Workload             WorkLoadList       `json:"workload" gorm:"type:json"`
+	EnableTrafficControl bool               `json:"enable_traffic_control"`
+	TrafficPolicy        string             `json:"traffic_policy"`
+	AutoRollback         bool               `json:"auto_rollback"`           // rollback workloads when deploy task failed
+	TaskTimeout          int64              `json:"task_timeout"`            // seconds, 0 means using tenant settings or server default
+	TaskInitialDelay     int64              `json:"task_initial_delay"`      // seconds, waiting before the first status check
+	TaskPollInterval     int64              `json:"task_poll_interval"`      // seconds
+	Stages               StageList          `json:"stages" gorm:"type:json"` // ordered deploy stages, workloads are deployed concurrently if empty
+	Strategy             string             `json:"strategy" gorm:"size:20"` // default deploy strategy, rolling if empty
+	Canary               CanaryConfig       `json:"canary" gorm:"type:json"`
+	BlueGreen            BlueGreenConfig    `json:"bluegreen" gorm:"type:json"`
+	Webhook              WebhookConfig      `json:"webhook" gorm:"type:json"`       // deploy when an image is pushed to the registry
+	ImageUpdater         ImageUpdaterConfig `json:"image_updater" gorm:"type:json"` // poll the registry for new tags
+	Tenant               string             `json:"tenant" gorm:"size:50"`
+	Tags                 StringList         `json:"tags" gorm:"type:json"`
+	ExtraVars            string             `json:"extra_vars" gorm:"type:json"`
+	UpdateAt             time.Time          `json:"update_at"`
 }
 
 func (a Application) Tablename() string {
@@ -158,6 +191,175 @@ func (w *WorkLoadList) Scan(value interface{}) error {
 	return json.Unmarshal([]byte(value.(string)), &w)
 }
 
+// Stage is a step of a deploy pipeline, the workloads in the clusters (and namespaces) of a stage are deployed together
+type Stage struct {
+	Name              string   `json:"name"`
+	Clusters          []string `json:"clusters"`             // empty matches all clusters
+	Namespaces        []string `json:"namespaces,omitempty"` // empty matches all namespaces
+	RequireApproval   bool     `json:"require_approval"`     // wait for manual approval before the stage runs
+	ContinueOnFailure bool     `json:"continue_on_failure"`  // run the next stages even if the stage failed, halt by default
+}
+
+func (s Stage) Match(w WorkLoad) bool {
+	return matchAny(s.Clusters, w.Cluster) && matchAny(s.Namespaces, w.Namespace)
+}
+
+// matchAny reports whether v is in list, an empty list matches any value
+func matchAny(list []string, v string) bool {
+	if len(list) == 0 {
+		return true
+	}
+	for _, item := range list {
+		if item == v {
+			return true
+		}
+	}
+	return false
+}
+
+type StageList []Stage
+
+func (s StageList) Value() (driver.Value, error) {
+	b, err := json.Marshal(s)
+	return string(b), err
+}
+
+func (s *StageList) Scan(value interface{}) error {
+	switch v := value.(type) {
+	case string:
+		return json.Unmarshal([]byte(v), &s)
+	case []byte:
+		return json.Unmarshal(v, &s)
+	}
+	return nil
+}
+
+// CanaryConfig shifts the istio weight to the deployed workloads step by step, and checks the health gate after each step
+type CanaryConfig struct {
+	Steps      []int      `json:"steps"`    // weights of the deployed workloads, e.g. 10,30,50,100
+	Interval   int64      `json:"interval"` // seconds, waiting before checking the health gate of a step
+	HealthGate HealthGate `json:"health_gate"`
+}
+
+func (c CanaryConfig) Value() (driver.Value, error) {
+	b, err := json.Marshal(c)
+	return string(b), err
+}
+
+func (c *CanaryConfig) Scan(value interface{}) error {
+	switch v := value.(type) {
+	case string:
+		return json.Unmarshal([]byte(v), &c)
+	case []byte:
+		return json.Unmarshal(v, &c)
+	}
+	return nil
+}
+
+// BlueGreenConfig deploys a parallel green deployment beside the live blue one, and switches the service selector to it when it is ready
+type BlueGreenConfig struct {
+	Service    string `json:"service,omitempty"`     // service of the workloads, default to the workload name
+	ColorLabel string `json:"color_label,omitempty"` // label of the green pods, selected by the service when green is live, default to lizardcd.io/color
+}
+
+func (c BlueGreenConfig) Value() (driver.Value, error) {
+	b, err := json.Marshal(c)
+	return string(b), err
+}
+
+func (c *BlueGreenConfig) Scan(value interface{}) error {
+	switch v := value.(type) {
+	case string:
+		return json.Unmarshal([]byte(v), &c)
+	case []byte:
+		return json.Unmarshal(v, &c)
+	}
+	return nil
+}
+
+// WebhookConfig authenticates the inbound webhooks of image registries, and filters the pushed tags to deploy
+type WebhookConfig struct {
+	Enabled   bool   `json:"enabled"`
+	Secret    string `json:"secret,omitempty"`     // passed by query "secret" or header "Authorization"
+	TagFilter string `json:"tag_filter,omitempty"` // regex of tags to deploy, all tags are deployed if empty
+}
+
+func (c WebhookConfig) Value() (driver.Value, error) {
+	b, err := json.Marshal(c)
+	return string(b), err
+}
+
+func (c *WebhookConfig) Scan(value interface{}) error {
+	switch v := value.(type) {
+	case string:
+		return json.Unmarshal([]byte(v), &c)
+	case []byte:
+		return json.Unmarshal(v, &c)
+	}
+	return nil
+}
+
+// ImageUpdaterConfig polls the repository of application for the newest tag matching the constraints
+type ImageUpdaterConfig struct {
+	Enabled   bool   `json:"enabled"`
+	Mode      string `json:"mode,omitempty"`       // deploy or notify, default to notify
+	Semver    string `json:"semver,omitempty"`     // semver constraint, e.g. "~1.2" or ">=1.0.0 <2.0.0", the highest version is selected
+	TagFilter string `json:"tag_filter,omitempty"` // regex of tags, the last modified tag is selected if semver is empty
+	Interval  int64  `json:"interval,omitempty"`   // seconds, default to 300
+}
+
+func (u ImageUpdaterConfig) Value() (driver.Value, error) {
+	b, err := json.Marshal(u)
+	return string(b), err
+}
+
+func (u *ImageUpdaterConfig) Scan(value interface{}) error {
+	switch v := value.(type) {
+	case string:
+		return json.Unmarshal([]byte(v), &u)
+	case []byte:
+		return json.Unmarshal(v, &u)
+	}
+	return nil
+}
+
+// HealthGate checks the deployed workloads, all of the configured checks must pass. Pods readiness is always checked.
+type HealthGate struct {
+	HttpUrl         string  `json:"http_url,omitempty"` // a 2xx response is healthy
+	PrometheusUrl   string  `json:"prometheus_url,omitempty"`
+	PrometheusQuery string  `json:"prometheus_query,omitempty"` // the first value of the result is compared with threshold
+	Operator        string  `json:"operator,omitempty"`         // <, <=, >, >=
+	Threshold       float64 `json:"threshold,omitempty"`
+}
+
+// TaskStep is a step of a task which deploys progressively, e.g. a canary weight step
+type TaskStep struct {
+	Name     string       `json:"name"`
+	Weight   int          `json:"weight,omitempty"`
+	Success  sql.NullBool `json:"success"`
+	Message  string       `json:"message,omitempty"`
+	Target   string       `json:"target,omitempty"` // e.g. the color which a blue/green step switches to
+	StartAt  time.Time    `json:"start_at"`
+	FinishAt sql.NullTime `json:"finish_at"`
+}
+
+type TaskStepList []TaskStep
+
+func (s TaskStepList) Value() (driver.Value, error) {
+	b, err := json.Marshal(s)
+	return string(b), err
+}
+
+func (s *TaskStepList) Scan(value interface{}) error {
+	switch v := value.(type) {
+	case string:
+		return json.Unmarshal([]byte(v), &s)
+	case []byte:
+		return json.Unmarshal(v, &s)
+	}
+	return nil
+}
+
 type Settings struct {
 	Id           int    `json:"id" gorm:"primaryKey;autoIncrement"`
 	SettingKey   string `json:"setting_key" gorm:"uniqueIndex:idx_key"`
@@ -206,6 +408,7 @@ type TaskHistory struct {
 	Success              sql.NullBool          `json:"success"`
 	ErrMessage           string                `json:"err_message"`
 	Status               string                `json:"status" gorm:"size:50"`
+	Reason               string                `json:"reason" gorm:"size:20"` // why a failed task is stopped, e.g. timeout or cancelled
 	Tenant               string                `json:"tenant" gorm:"size:50"`
 	TriggerType          string                `json:"trigger_type" gorm:"size:50"`
 	InitAt               sql.NullTime          `json:"init_at"`
@@ -213,14 +416,162 @@ type TaskHistory struct {
 	FinishAt             sql.NullTime          `json:"finish_at"`
 	Expire               string                `json:"expire" gorm:"size:50"`
 	Labels               StringList            `json:"labels" gorm:"type:json"`
+	RollbackOf           string                `json:"rollback_of" gorm:"size:100"`     // id of the task which is rolled back by this task
+	ParentId             string                `json:"parent_id" gorm:"size:100;index"` // id of the pipeline task which this stage task belongs to
+	Stage                string                `json:"stage" gorm:"size:100"`           // stage name of a stage task
+	Stages               StageList             `json:"stages" gorm:"type:json"`         // stages of a pipeline task
+	ApprovedBy           string                `json:"approved_by" gorm:"size:50"`      // user who approved the stage task
+	RejectedBy           string                `json:"rejected_by" gorm:"size:50"`      // user who rejected the stage task
+	ApproveAt            sql.NullTime          `json:"approve_at"`                      // time when the stage task is approved or rejected
+	Strategy             string                `json:"strategy" gorm:"size:20"`         // deploy strategy, e.g. rolling, canary, bluegreen
+	Steps                TaskStepList          `json:"steps" gorm:"type:json"`          // progressive steps of the task, e.g. canary weights
+	Owner                string                `json:"owner" gorm:"size:100"`           // id of the lizardcd-server running the task
+	LeaseUntil           sql.NullTime          `json:"lease_until"`                     // renewed by the owner while running, other lizardcd-servers resume the task after it expires
 	TaskHistoryWorkloads []TaskHistoryWorkload `json:"workloads" gorm:"foreignKey:TaskHistoryId;constraint:OnDelete:CASCADE,OnUpdate:CASCADE"`
 }
 
+// ScheduledTask runs a task once at a time or periodically by a cron expression
+type ScheduledTask struct {
+	Id          string       `json:"id" gorm:"primaryKey"`
+	Name        string       `json:"name" gorm:"size:100"`
+	AppName     string       `json:"app_name" gorm:"index"`
+	TaskType    string       `json:"task_type" gorm:"size:20"`
+	Cron        string       `json:"cron"`   // standard cron expression, e.g. "0 2 * * *"
+	RunAt       sql.NullTime `json:"run_at"` // run once at this time when cron is empty
+	Enabled     bool         `json:"enabled"`
+	Strategy    string       `json:"strategy" gorm:"size:20"`
+	ArtifactUrl string       `json:"artifact_url"`
+	Labels      StringList   `json:"labels" gorm:"type:json"`
+	Workloads   WorkLoadList `json:"workloads" gorm:"type:json"`
+	Tenant      string       `json:"tenant" gorm:"size:50"`
+	CreatedBy   string       `json:"created_by"`
+	NextRunAt   sql.NullTime `json:"next_run_at"`
+	LastRunAt   sql.NullTime `json:"last_run_at"`
+	LastTaskId  string       `json:"last_task_id"`
+	LastError   string       `json:"last_error"`
+	UpdateAt    time.Time    `json:"update_at"`
+}
+
+// NotifyChannel sends the task events of a tenant to a webhook, DingTalk robot, WeCom robot, Slack incoming webhook or email
+type NotifyChannel struct {
+	Id           int        `json:"id" gorm:"primaryKey;autoIncrement"`
+	Name         string     `json:"name" gorm:"size:100"`
+	ChannelType  string     `json:"channel_type" gorm:"size:20"` // webhook, dingtalk, wecom, slack, email
+	Enabled      bool       `json:"enabled"`
+	Events       StringList `json:"events" gorm:"type:json"` // start, success, failure, timeout, all events if empty
+	Url          string     `json:"url"`                     // webhook url of webhook, dingtalk, wecom and slack
+	Secret       string     `json:"secret"`                  // sign secret of dingtalk robot, or hmac secret of webhook
+	Template     string     `json:"template"`                // go template of the webhook body or the message text, default if empty
+	SmtpAddr     string     `json:"smtp_addr"`               // host:port
+	SmtpUsername string     `json:"smtp_username"`
+	SmtpPassword string     `json:"smtp_password"`
+	MailFrom     string     `json:"mail_from"`
+	MailTo       StringList `json:"mail_to" gorm:"type:json"`
+	Tenant       string     `json:"tenant" gorm:"size:50"`
+	UpdateAt     time.Time  `json:"update_at"`
+}
+
+// RbacPolicy binds a user or a group to verbs on a resource type in the matched clusters and namespaces.
+// Policies restrict the permissions of the role further, a user bound by any policy may only do what is allowed and not denied.
+type RbacPolicy struct {
+	Id          int        `json:"id" gorm:"primaryKey;autoIncrement"`
+	Name        string     `json:"name" gorm:"size:100"`
+	SubjectType string     `json:"subject_type" gorm:"size:10"` // user, group
+	Subject     string     `json:"subject" gorm:"size:100;index"`
+	Cluster     string     `json:"cluster" gorm:"size:100"`   // glob pattern, * matches all clusters
+	Namespace   string     `json:"namespace" gorm:"size:100"` // glob pattern, e.g. prod-*
+	Resource    string     `json:"resource" gorm:"size:50"`   // resource type, e.g. deployments, helm, task, * matches all
+	Verbs       StringList `json:"verbs" gorm:"type:json"`    // e.g. get, scale, delete, * matches all
+	Effect      string     `json:"effect" gorm:"size:10"`     // allow, deny
+	UpdateAt    time.Time  `json:"update_at"`
+}
+
+// Match reports whether the policy applies to the verb on the resource in cluster and namespace, empty cluster or namespace matches any pattern
+func (p RbacPolicy) Match(cluster, namespace, resource, verb string) bool {
+	return matchPattern(p.Cluster, cluster) &&
+		matchPattern(p.Namespace, namespace) &&
+		matchPattern(p.Resource, resource) &&
+		len(p.Verbs) > 0 && (matchAny(p.Verbs, "*") || matchAny(p.Verbs, verb))
+}
+
+// matchPattern reports whether v matches the glob pattern, an empty pattern or value matches any
+func matchPattern(pattern, v string) bool {
+	if pattern == "" || v == "" {
+		return true
+	}
+	matched, _ := path.Match(pattern, v)
+	return matched
+}
+
+// AuditLog is a record of a mutating api call or agent rpc
+type AuditLog struct {
+	Id         int       `json:"id" gorm:"primaryKey;autoIncrement"`
+	Source     string    `json:"source" gorm:"size:10"` // api, rpc
+	Username   string    `json:"username" gorm:"size:100;index"`
+	Tenant     string    `json:"tenant" gorm:"size:50;index"`
+	Method     string    `json:"method" gorm:"size:10"` // http method of api, RPC of rpc
+	Route      string    `json:"route"`                 // route of api, e.g. /lizardcd/db/:tablename/:id, or full method of rpc
+	Path       string    `json:"path"`                  // request path of api, or service name of the agent
+	Cluster    string    `json:"cluster" gorm:"size:100"`
+	Namespace  string    `json:"namespace" gorm:"size:100"`
+	Resource   string    `json:"resource"`
+	BodyDigest string    `json:"body_digest" gorm:"size:64"` // sha256 of request body
+	Code       int       `json:"code"`                       // http status code of api, or grpc status code of rpc
+	ClientIp   string    `json:"client_ip" gorm:"size:50"`
+	CreateAt   time.Time `json:"create_at" gorm:"index"`
+}
+
+// StaticAgent is an agent without registry, which lizardcd-server connects by its address
+type StaticAgent struct {
+	Id         int       `json:"id" gorm:"primaryKey;autoIncrement"`
+	ServiceKey string    `json:"service_key" gorm:"size:200;uniqueIndex"` // lizardcd-agent.<namespace>.<cluster>, without ServicePrefix
+	Address    string    `json:"address" gorm:"size:100"`                 // e.g. 10.50.89.20:5017
+	UpdateAt   time.Time `json:"update_at"`
+}
+
+// ApiToken is a long-lived jwt token of CI systems, bound to a tenant and role instead of a user.
+// The token itself is only returned when it is created, revoking deletes the record so the token is rejected.
+type ApiToken struct {
+	Id           string       `json:"id" gorm:"primaryKey;size:100"`
+	Name         string       `json:"name" gorm:"size:100;uniqueIndex:idx_token_name"`
+	Role         string       `json:"role" gorm:"size:50"`
+	Tenant       string       `json:"tenant" gorm:"size:50;uniqueIndex:idx_token_name"`
+	Applications StringList   `json:"applications" gorm:"type:json"` // applications allowed to run tasks, all applications of the tenant if empty
+	CreateBy     string       `json:"create_by" gorm:"size:50"`
+	ExpireAt     sql.NullTime `json:"expire_at"` // never expires if null
+	LastUsedAt   sql.NullTime `json:"last_used_at"`
+	CreateAt     time.Time    `json:"create_at"`
+}
+
+// Username is the user of requests authenticated by the token, which is used by audit logs.
+// Rbac policies of the token are the policies of CreateBy.
+func (t ApiToken) Username() string {
+	return "token:" + t.Name
+}
+
+// ImageUpdate is a new tag found by the image updater
+type ImageUpdate struct {
+	Id          int       `json:"id" gorm:"primaryKey;autoIncrement"`
+	AppName     string    `json:"app_name" gorm:"size:300;index"`
+	Tag         string    `json:"tag"`
+	PreviousTag string    `json:"previous_tag"`
+	ArtifactUrl string    `json:"artifact_url"`
+	Mode        string    `json:"mode" gorm:"size:20"`
+	TaskId      string    `json:"task_id" gorm:"size:100"` // the deploy task in deploy mode
+	ErrMessage  string    `json:"err_message"`
+	Tenant      string    `json:"tenant" gorm:"size:50"`
+	DetectAt    time.Time `json:"detect_at"`
+}
+
 type TaskHistoryWorkload struct {
-	Id            int       `json:"id" gorm:"primaryKey;autoIncrement"`
-	Workload      WorkLoad  `json:"workload" gorm:"type:json"`
-	Status        string    `json:"status" gorm:"type:json"`
-	ErrMessage    string    `json:"err_message"`
-	TaskHistoryId string    `json:"task_history_id" gorm:"size:100"`
-	UpdateAt      time.Time `json:"update_at"`
+	Id                  int          `json:"id" gorm:"primaryKey;autoIncrement"`
+	Workload            WorkLoad     `json:"workload" gorm:"type:json"`
+	PreviousArtifactUrl string       `json:"previous_artifact_url"` // image before the workload is patched, used to rollback
+	Status              string       `json:"status" gorm:"type:json"`
+	Success             sql.NullBool `json:"success"`
+	ErrMessage          string       `json:"err_message"`
+	HealthCheck         string       `json:"health_check" gorm:"type:json"` // resolved health check of vm/http workload, used to resume a task
+	TaskHistoryId       string       `json:"task_history_id" gorm:"size:100"`
+	StartAt             sql.NullTime `json:"start_at"` // set when the workload is deployed and status checking starts
+	UpdateAt            time.Time    `json:"update_at"`
 }
@@ -7,6 +7,7 @@ import (
 	"net/http"
 	"time"
 
+	"github.com/hongyuxuan/lizardcd/common/constant"
 	"github.com/hongyuxuan/lizardcd/common/errorx"
 	"github.com/hongyuxuan/lizardcd/common/types"
 	"golang.org/x/crypto/bcrypt"
@@ -23,6 +24,7 @@ func AddUser(username, password, role, tenant string, db *gorm.DB) (err error) {
 		Password: string(hashPwd),
 		Role:     role,
 		Tenant:   tenant,
+		Source:   constant.USER_SOURCE_LOCAL,
 		UpdateAt: time.Now(),
 	}
 	return db.Create(&user).Error
@@ -55,6 +57,26 @@ func AddSettings(tenant string, db *gorm.DB) {
 			SettingValue: "",
 			Tenant:       tenant,
 		},
+		{
+			SettingKey:   "task_timeout",
+			SettingValue: "",
+			Tenant:       tenant,
+		},
+		{
+			SettingKey:   "task_initial_delay",
+			SettingValue: "",
+			Tenant:       tenant,
+		},
+		{
+			SettingKey:   "task_poll_interval",
+			SettingValue: "",
+			Tenant:       tenant,
+		},
+		{
+			SettingKey:   "deploy_windows",
+			SettingValue: "",
+			Tenant:       tenant,
+		},
 	}
 	for _, setting := range settings {
 		if err := db.Save(setting).Error; err != nil {
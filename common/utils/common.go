@@ -56,6 +56,17 @@ func GetPayload(ctx context.Context) (username, role, tenant string, namespaces
 	return
 }
 
+// GetPayloadUser returns username and tenant of the payloads, empty if ctx is not authorized
+func GetPayloadUser(ctx context.Context) (username, tenant string) {
+	payload, ok := ctx.Value("payloads").(map[string]interface{})
+	if !ok {
+		return
+	}
+	username, _ = payload["username"].(string)
+	tenant, _ = payload["tenant"].(string)
+	return
+}
+
 func AnyToString(v interface{}) string {
 	switch v.(type) {
 	case string:
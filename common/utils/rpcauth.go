@@ -0,0 +1,183 @@
+package utils
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// rpcTokenExpire is the expiry of the tokens between lizardcd-server and lizardcd-agent, tolerating clock skew between them
+const rpcTokenExpire = 5 * time.Minute
+
+const rpcTokenIssuer = "lizardcd-server"
+
+// TlsConf is the certificates of the mutual tls between lizardcd-server and lizardcd-agent
+type TlsConf struct {
+	CertFile           string `json:",optional"`
+	KeyFile            string `json:",optional"`
+	CaFile             string `json:",optional"` // verifies the certificate of the peer, client certificates are required if set
+	ServerName         string `json:",optional"` // the name in the certificate of the peer dialed if it is not issued for its address
+	InsecureSkipVerify bool   `json:",optional"` // does not verify the certificate of the peer dialed
+}
+
+func (t TlsConf) Enabled() bool {
+	return t.CertFile != "" || t.CaFile != ""
+}
+
+// ServerConfig returns the tls config of the side accepting connections, i.e. lizardcd-agent, or lizardcd-server in tunnel mode
+func (t TlsConf) ServerConfig() (*tls.Config, error) {
+	if t.CertFile == "" || t.KeyFile == "" {
+		return nil, errors.New("both CertFile and KeyFile must be specified for tls")
+	}
+	cert, err := tls.LoadX509KeyPair(t.CertFile, t.KeyFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load tls certificate: %v", err)
+	}
+	config := &tls.Config{
+		Certificates: []tls.Certificate{cert},
+		MinVersion:   tls.VersionTLS12,
+	}
+	if t.CaFile != "" {
+		if config.ClientCAs, err = loadCertPool(t.CaFile); err != nil {
+			return nil, err
+		}
+		config.ClientAuth = tls.RequireAndVerifyClientCert
+	}
+	return config, nil
+}
+
+// ClientConfig returns the tls config of the side dialing, i.e. lizardcd-server, or lizardcd-agent in tunnel mode
+func (t TlsConf) ClientConfig() (*tls.Config, error) {
+	config := &tls.Config{
+		ServerName:         t.ServerName,
+		InsecureSkipVerify: t.InsecureSkipVerify,
+		MinVersion:         tls.VersionTLS12,
+	}
+	if t.CertFile != "" {
+		cert, err := tls.LoadX509KeyPair(t.CertFile, t.KeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load tls certificate: %v", err)
+		}
+		config.Certificates = []tls.Certificate{cert}
+	}
+	if t.CaFile != "" {
+		pool, err := loadCertPool(t.CaFile)
+		if err != nil {
+			return nil, err
+		}
+		config.RootCAs = pool
+	}
+	return config, nil
+}
+
+func loadCertPool(caFile string) (*x509.CertPool, error) {
+	b, err := os.ReadFile(caFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read ca file: %v", err)
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(b) {
+		return nil, fmt.Errorf("no certificate found in ca file %s", caFile)
+	}
+	return pool, nil
+}
+
+// RpcCredentials signs a short-lived jwt by the shared secret for each rpc of lizardcd-server
+type RpcCredentials struct {
+	secret []byte
+	secure bool
+}
+
+// NewRpcCredentials returns the credentials of secret, secure reports whether the rpcs are protected by tls
+func NewRpcCredentials(secret string, secure bool) RpcCredentials {
+	return RpcCredentials{
+		secret: []byte(secret),
+		secure: secure,
+	}
+}
+
+func (c RpcCredentials) GetRequestMetadata(ctx context.Context, uri ...string) (map[string]string, error) {
+	token, err := signRpcToken(c.secret, rpcTokenIssuer)
+	if err != nil {
+		return nil, err
+	}
+	return map[string]string{"authorization": "Bearer " + token}, nil
+}
+
+// RequireTransportSecurity reports whether tls is used, the shared secret also works without tls though the tokens can be sniffed
+func (c RpcCredentials) RequireTransportSecurity() bool {
+	return c.secure
+}
+
+// RpcAuthenticator verifies the tokens of RpcCredentials in lizardcd-agent
+type RpcAuthenticator struct {
+	secret []byte
+}
+
+func NewRpcAuthenticator(secret string) *RpcAuthenticator {
+	return &RpcAuthenticator{
+		secret: []byte(secret),
+	}
+}
+
+func (a *RpcAuthenticator) UnaryInterceptor(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+	if err := a.authenticate(ctx, info.FullMethod); err != nil {
+		return nil, err
+	}
+	return handler(ctx, req)
+}
+
+func (a *RpcAuthenticator) StreamInterceptor(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	if err := a.authenticate(ss.Context(), info.FullMethod); err != nil {
+		return err
+	}
+	return handler(srv, ss)
+}
+
+func (a *RpcAuthenticator) authenticate(ctx context.Context, method string) error {
+	// health checks of the registry are not authenticated
+	if strings.HasPrefix(method, "/grpc.health.v1.Health/") {
+		return nil
+	}
+	md, _ := metadata.FromIncomingContext(ctx)
+	values := md.Get("authorization")
+	if len(values) == 0 || !strings.HasPrefix(values[0], "Bearer ") {
+		return status.Error(codes.Unauthenticated, "missing rpc token")
+	}
+	if err := verifyRpcToken(a.secret, strings.TrimPrefix(values[0], "Bearer "), rpcTokenIssuer); err != nil {
+		return status.Errorf(codes.Unauthenticated, "invalid rpc token: %v", err)
+	}
+	return nil
+}
+
+func signRpcToken(secret []byte, issuer string) (string, error) {
+	// iat is not set, as it is rejected when the clock of the peer is behind
+	return jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.RegisteredClaims{
+		Issuer:    issuer,
+		ExpiresAt: jwt.NewNumericDate(time.Now().Add(rpcTokenExpire)),
+	}).SignedString(secret)
+}
+
+func verifyRpcToken(secret []byte, token, issuer string) error {
+	claims := &jwt.RegisteredClaims{}
+	if _, err := jwt.ParseWithClaims(token, claims, func(t *jwt.Token) (interface{}, error) {
+		return secret, nil
+	}, jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Name})); err != nil {
+		return err
+	}
+	if claims.Issuer != issuer || claims.ExpiresAt == nil {
+		return errors.New("unexpected issuer or expiry")
+	}
+	return nil
+}
@@ -0,0 +1,169 @@
+package utils
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// SecretMask replaces the secrets in responses, a secret equal to SecretMask in requests means unchanged
+const SecretMask = "******"
+
+// encrypted secrets are "enc:v1:<data key encrypted by master key>:<secret encrypted by data key>"
+const secretPrefix = "enc:v1:"
+
+var masterKey []byte
+
+// SetMasterKey sets the key encrypting the data keys of secrets, key of any length is hashed to 256 bits.
+// Secrets are stored in plaintext if key is empty.
+func SetMasterKey(key string) {
+	if key == "" {
+		masterKey = nil
+		return
+	}
+	sum := sha256.Sum256([]byte(key))
+	masterKey = sum[:]
+}
+
+// MasterKeyEnabled reports whether secrets are encrypted
+func MasterKeyEnabled() bool {
+	return len(masterKey) > 0
+}
+
+// IsEncryptedSecret reports whether value is encrypted by EncryptSecret
+func IsEncryptedSecret(value string) bool {
+	return strings.HasPrefix(value, secretPrefix)
+}
+
+// EncryptSecret encrypts plaintext by a random data key, which is encrypted by the master key.
+// Empty or encrypted values are returned as is, and plaintext is returned if the master key is not set.
+func EncryptSecret(plaintext string) (string, error) {
+	if plaintext == "" || !MasterKeyEnabled() || IsEncryptedSecret(plaintext) {
+		return plaintext, nil
+	}
+	dataKey := make([]byte, 32)
+	if _, err := rand.Read(dataKey); err != nil {
+		return "", err
+	}
+	encryptedKey, err := seal(masterKey, dataKey)
+	if err != nil {
+		return "", err
+	}
+	ciphertext, err := seal(dataKey, []byte(plaintext))
+	if err != nil {
+		return "", err
+	}
+	return secretPrefix + base64.RawStdEncoding.EncodeToString(encryptedKey) + ":" + base64.RawStdEncoding.EncodeToString(ciphertext), nil
+}
+
+// DecryptSecret decrypts value encrypted by EncryptSecret, values not encrypted are returned as is
+func DecryptSecret(value string) (string, error) {
+	if !IsEncryptedSecret(value) {
+		return value, nil
+	}
+	if !MasterKeyEnabled() {
+		return "", errors.New("failed to decrypt secret: master key is not set")
+	}
+	parts := strings.Split(strings.TrimPrefix(value, secretPrefix), ":")
+	if len(parts) != 2 {
+		return "", errors.New("failed to decrypt secret: invalid format")
+	}
+	encryptedKey, err := base64.RawStdEncoding.DecodeString(parts[0])
+	if err != nil {
+		return "", fmt.Errorf("failed to decrypt secret: %v", err)
+	}
+	ciphertext, err := base64.RawStdEncoding.DecodeString(parts[1])
+	if err != nil {
+		return "", fmt.Errorf("failed to decrypt secret: %v", err)
+	}
+	dataKey, err := open(masterKey, encryptedKey)
+	if err != nil {
+		return "", fmt.Errorf("failed to decrypt secret, the master key may be changed: %v", err)
+	}
+	plaintext, err := open(dataKey, ciphertext)
+	if err != nil {
+		return "", fmt.Errorf("failed to decrypt secret: %v", err)
+	}
+	return string(plaintext), nil
+}
+
+// MaskSecret returns SecretMask if value is not empty
+func MaskSecret(value string) string {
+	if value == "" {
+		return ""
+	}
+	return SecretMask
+}
+
+// DecryptSecrets decrypts the values of m in place
+func DecryptSecrets(m map[string]string) (err error) {
+	for k, v := range m {
+		if m[k], err = DecryptSecret(v); err != nil {
+			return
+		}
+	}
+	return
+}
+
+// ConvertHttpHeaders replaces the values of http_header in a json object by convert, e.g. the extra_vars of http applications
+// and the health check of http tasks, whose headers may contain credentials like Authorization
+func ConvertHttpHeaders(jsonStr string, convert func(key, value string) (string, error)) (string, error) {
+	if jsonStr == "" {
+		return jsonStr, nil
+	}
+	vars := map[string]interface{}{}
+	if err := json.Unmarshal([]byte(jsonStr), &vars); err != nil {
+		return jsonStr, nil // not a json object, no headers
+	}
+	headers, ok := vars["http_header"].(map[string]interface{})
+	if !ok || len(headers) == 0 {
+		return jsonStr, nil
+	}
+	for k, v := range headers {
+		s, _ := v.(string)
+		converted, err := convert(k, s)
+		if err != nil {
+			return "", err
+		}
+		headers[k] = converted
+	}
+	b, err := json.Marshal(vars)
+	return string(b), err
+}
+
+func seal(key, plaintext []byte) ([]byte, error) {
+	gcm, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err = rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	return gcm.Seal(nonce, nonce, plaintext, nil), nil
+}
+
+func open(key, ciphertext []byte) ([]byte, error) {
+	gcm, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+	if len(ciphertext) < gcm.NonceSize() {
+		return nil, errors.New("ciphertext too short")
+	}
+	return gcm.Open(nil, ciphertext[:gcm.NonceSize()], ciphertext[gcm.NonceSize():], nil)
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
@@ -63,12 +63,18 @@ func NewSQLite(dbfile, level string) *gorm.DB {
 	return sqlite
 }
 
+// HasTenant reports whether the rows of tablename belong to tenants, which users other than admin only access of their own tenant
+func HasTenant(tablename string) bool {
+	switch tablename {
+	case "application", "application_template", "image_repository", "task_history", "scheduled_task", "image_update",
+		"notify_channel", "audit_log", "api_token", "helm_repositories":
+		return true
+	}
+	return false
+}
+
 func SetTx(tx *gorm.DB, count *int64, req *commontypes.GetDataReq, role, tenant string) {
-	if (req.Tablename == "application" ||
-		req.Tablename == "application_template" ||
-		req.Tablename == "image_repository" ||
-		req.Tablename == "task_history" ||
-		req.Tablename == "helm_repositories") && role != constant.ROLE_ADMIN {
+	if HasTenant(req.Tablename) && role != constant.ROLE_ADMIN {
 		tx.Where("tenant = ?", tenant)
 	}
 	if req.Search != "" {
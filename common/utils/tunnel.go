@@ -0,0 +1,65 @@
+package utils
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"io"
+)
+
+// In tunnel mode lizardcd-agent dials out to lizardcd-server, sends a TunnelHello and waits for a TunnelAck,
+// then serves the rpcs of lizardcd-server on the connection, i.e. the roles of grpc are reversed.
+// The handshake frames are json prefixed by a 4-byte big-endian length.
+
+const tunnelTokenIssuer = "lizardcd-agent"
+
+const maxTunnelFrame = 64 << 10
+
+// TunnelHello is sent by lizardcd-agent after connecting to the tunnel of lizardcd-server
+type TunnelHello struct {
+	ServiceKey string `json:"service_key"` // <ServicePrefix>lizardcd-agent.<namespace>.<cluster>
+	Token      string `json:"token,omitempty"`
+}
+
+// TunnelAck is replied by lizardcd-server, the tunnel is rejected if Error is not empty
+type TunnelAck struct {
+	Error string `json:"error,omitempty"`
+}
+
+func WriteTunnelFrame(w io.Writer, v any) error {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	frame := make([]byte, 4+len(b))
+	binary.BigEndian.PutUint32(frame, uint32(len(b)))
+	copy(frame[4:], b)
+	_, err = w.Write(frame)
+	return err
+}
+
+func ReadTunnelFrame(r io.Reader, v any) error {
+	var size [4]byte
+	if _, err := io.ReadFull(r, size[:]); err != nil {
+		return err
+	}
+	n := binary.BigEndian.Uint32(size[:])
+	if n > maxTunnelFrame {
+		return fmt.Errorf("tunnel frame of %d bytes is too large", n)
+	}
+	b := make([]byte, n)
+	if _, err := io.ReadFull(r, b); err != nil {
+		return err
+	}
+	return json.Unmarshal(b, v)
+}
+
+// SignTunnelToken signs the token of TunnelHello by the shared secret of rpcs
+func SignTunnelToken(secret string) (string, error) {
+	return signRpcToken([]byte(secret), tunnelTokenIssuer)
+}
+
+// VerifyTunnelToken verifies the token of TunnelHello signed by SignTunnelToken
+func VerifyTunnelToken(secret, token string) error {
+	return verifyRpcToken([]byte(secret), token, tunnelTokenIssuer)
+}
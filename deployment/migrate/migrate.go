@@ -6,18 +6,22 @@ import (
 	"os"
 	"time"
 
+	"github.com/hongyuxuan/lizardcd/common/constant"
 	"github.com/hongyuxuan/lizardcd/common/types"
 	"github.com/hongyuxuan/lizardcd/common/utils"
 	"gopkg.in/yaml.v2"
+	"gorm.io/gorm"
 )
 
 var dbfile = flag.String("d", "./lizardcd.db", "sqlite database file")
 var level = flag.String("l", "info", "sqlite database file")
+var masterKey = flag.String("k", os.Getenv("LIZARDCD_MASTER_KEY"), "master key encrypting credentials, must be the same as MasterKey of lizardcd-server")
 
 func main() {
 	flag.Parse()
 
 	db := utils.NewSQLite(*dbfile, *level)
+	utils.SetMasterKey(*masterKey)
 
 	var file *os.File
 	var err error
@@ -55,6 +59,8 @@ func main() {
 	} else {
 		utils.Log.Infof("password of user admin is: %s , please modified it when you first login", generatedPassword)
 	}
+	db.Model(&types.User{}).Where("groups is null").Update("groups", "[]")
+	db.Model(&types.User{}).Where("source is null or source = ''").Update("source", constant.USER_SOURCE_LOCAL)
 
 	// create table `tenant`
 	db.AutoMigrate(&types.Tenant{})
@@ -94,6 +100,9 @@ func main() {
 	db.AutoMigrate(&types.HelmRepositories{})
 	for i, r := range repos {
 		r.Id = i
+		if r.Password, err = utils.EncryptSecret(r.Password); err != nil {
+			utils.Log.Fatal(err)
+		}
 		if err := db.Create(&r).Error; err != nil {
 			utils.Log.Warn(err)
 		}
@@ -109,4 +118,141 @@ func main() {
 	if err = db.Model(&types.TaskHistory{}).Where("1 = 1").Update("labels", "[]").Error; err != nil {
 		utils.Log.Fatal(err)
 	}
+
+	// create table `scheduled_task`
+	if err = db.AutoMigrate(&types.ScheduledTask{}); err != nil {
+		utils.Log.Warn(err)
+	}
+
+	// create table `image_update`
+	if err = db.AutoMigrate(&types.ImageUpdate{}); err != nil {
+		utils.Log.Warn(err)
+	}
+
+	// create table `notify_channel`
+	if err = db.AutoMigrate(&types.NotifyChannel{}); err != nil {
+		utils.Log.Warn(err)
+	}
+
+	// create table `rbac_policy`
+	if err = db.AutoMigrate(&types.RbacPolicy{}); err != nil {
+		utils.Log.Warn(err)
+	}
+
+	// create table `audit_log`
+	if err = db.AutoMigrate(&types.AuditLog{}); err != nil {
+		utils.Log.Warn(err)
+	}
+
+	// create table `api_token`
+	if err = db.AutoMigrate(&types.ApiToken{}); err != nil {
+		utils.Log.Warn(err)
+	}
+
+	// create table `static_agent`
+	if err = db.AutoMigrate(&types.StaticAgent{}); err != nil {
+		utils.Log.Warn(err)
+	}
+
+	// encrypt credentials stored in plaintext
+	if utils.MasterKeyEnabled() {
+		encryptSecrets(db)
+	} else {
+		utils.Log.Warn("master key is not set, credentials are stored in plaintext")
+	}
+	stripRepoPasswords(db)
+}
+
+// encryptSecrets encrypts the credentials of image repositories, notify channels, webhooks and http deploy headers
+func encryptSecrets(db *gorm.DB) {
+	for table, columns := range map[string][]string{
+		"image_repository": {"repo_password"},
+		"notify_channel":   {"secret", "smtp_password"},
+	} {
+		var rows []map[string]interface{}
+		if err := db.Table(table).Select(append([]string{"id"}, columns...)).Find(&rows).Error; err != nil {
+			utils.Log.Warn(err)
+			continue
+		}
+		for _, row := range rows {
+			updates := map[string]interface{}{}
+			for _, column := range columns {
+				value, _ := row[column].(string)
+				encrypted, err := utils.EncryptSecret(value)
+				if err != nil {
+					utils.Log.Fatal(err)
+				}
+				if encrypted != value {
+					updates[column] = encrypted
+				}
+			}
+			if len(updates) == 0 {
+				continue
+			}
+			if err := db.Table(table).Where("id = ?", row["id"]).Updates(updates).Error; err != nil {
+				utils.Log.Warn(err)
+				continue
+			}
+			utils.Log.Infof("encrypted credentials of id=%v in %s success", row["id"], table)
+		}
+	}
+
+	encryptHeaders := func(key, value string) (string, error) {
+		return utils.EncryptSecret(value)
+	}
+	var applications []types.Application
+	db.Find(&applications)
+	for _, a := range applications {
+		secret, err := utils.EncryptSecret(a.Webhook.Secret)
+		if err != nil {
+			utils.Log.Fatal(err)
+		}
+		extraVars, err := utils.ConvertHttpHeaders(a.ExtraVars, encryptHeaders)
+		if err != nil {
+			utils.Log.Fatal(err)
+		}
+		if secret == a.Webhook.Secret && extraVars == a.ExtraVars {
+			continue
+		}
+		a.Webhook.Secret = secret
+		if err = db.Model(&types.Application{}).Where("id = ?", a.Id).Updates(map[string]interface{}{
+			"webhook":    a.Webhook,
+			"extra_vars": extraVars,
+		}).Error; err != nil {
+			utils.Log.Warn(err)
+			continue
+		}
+		utils.Log.Infof("encrypted credentials of application \"%s\" success", a.AppName)
+	}
+
+	// the health check of http workloads is saved with the deploy headers
+	var workloads []map[string]interface{}
+	db.Model(&types.TaskHistoryWorkload{}).Select("id", "health_check").Where("health_check LIKE ?", "%http_header%").Find(&workloads)
+	for _, w := range workloads {
+		healthCheck, _ := w["health_check"].(string)
+		encrypted, err := utils.ConvertHttpHeaders(healthCheck, encryptHeaders)
+		if err != nil {
+			utils.Log.Fatal(err)
+		}
+		if encrypted == healthCheck {
+			continue
+		}
+		if err = db.Model(&types.TaskHistoryWorkload{}).Where("id = ?", w["id"]).Update("health_check", encrypted).Error; err != nil {
+			utils.Log.Warn(err)
+		}
+	}
+}
+
+// stripRepoPasswords removes the passwords copied into the repo of applications, which are read from image_repository by lizardcd-server
+func stripRepoPasswords(db *gorm.DB) {
+	var applications []types.Application
+	db.Where("repo LIKE ?", `%"repo_password":"_%`).Find(&applications)
+	for _, a := range applications {
+		a.Repo.RepoPassword = ""
+		if err := db.Model(&types.Application{}).Where("id = ?", a.Id).Update("repo", a.Repo).Error; err != nil {
+			utils.Log.Warn(err)
+			continue
+		}
+		utils.Log.Infof("removed repo password from application \"%s\" success", a.AppName)
+	}
 }
@@ -3,18 +3,22 @@ module github.com/hongyuxuan/lizardcd
 go 1.19
 
 require (
+	github.com/Masterminds/semver/v3 v3.2.1
 	github.com/glebarez/sqlite v1.11.0
+	github.com/go-ldap/ldap/v3 v3.4.8
 	github.com/golang-jwt/jwt/v4 v4.5.0
 	github.com/hongyuxuan/zero-contrib/zrpc/registry/nacos v0.0.0-20240416095353-1f48e6512a23
 	github.com/imroc/req/v3 v3.20.0
 	github.com/mholt/archiver/v4 v4.0.0-alpha.8
 	github.com/nacos-group/nacos-sdk-go v1.1.2
 	github.com/nacos-group/nacos-sdk-go/v2 v2.2.3
+	github.com/robfig/cron/v3 v3.0.1
 	github.com/zeromicro/go-zero v1.6.4
 	github.com/zeromicro/zero-contrib/zrpc/registry/consul v0.0.0-20230517160033-92ba832728db
 	github.com/zeromicro/zero-contrib/zrpc/registry/nacos v0.0.0-20231030135404-af9ae855016f
 	go.opentelemetry.io/otel v1.19.0
 	go.opentelemetry.io/otel/trace v1.19.0
+	golang.org/x/oauth2 v0.17.0
 	google.golang.org/grpc v1.63.0
 	google.golang.org/protobuf v1.33.0
 	gopkg.in/yaml.v2 v2.4.0
@@ -25,6 +29,8 @@ require (
 )
 
 require (
+	github.com/Azure/go-ntlmssp v0.0.0-20221128193559-754e69321358 // indirect
+	github.com/go-asn1-ber/asn1-ber v1.5.5 // indirect
 	github.com/gookit/color v1.5.4 // indirect
 	github.com/xo/terminfo v0.0.0-20210125001918-ca9a967f8778 // indirect
 )
@@ -35,7 +41,6 @@ require (
 	github.com/BurntSushi/toml v1.3.2 // indirect
 	github.com/MakeNowJust/heredoc v1.0.0 // indirect
 	github.com/Masterminds/goutils v1.1.1 // indirect
-	github.com/Masterminds/semver/v3 v3.2.1 // indirect
 	github.com/Masterminds/sprig/v3 v3.2.3 // indirect
 	github.com/Masterminds/squirrel v1.5.4 // indirect
 	github.com/Microsoft/hcsshim v0.11.4 // indirect
@@ -265,7 +270,6 @@ require (
 	golang.org/x/crypto v0.22.0
 	golang.org/x/mod v0.14.0 // indirect
 	golang.org/x/net v0.24.0 // indirect
-	golang.org/x/oauth2 v0.17.0 // indirect
 	golang.org/x/sys v0.19.0 // indirect
 	golang.org/x/term v0.19.0 // indirect
 	golang.org/x/text v0.14.0 // indirect
@@ -1,11 +1,14 @@
 package config
 
 import (
+	"fmt"
 	"os"
 	"reflect"
 	"strconv"
 	"strings"
 
+	"github.com/hongyuxuan/lizardcd/common/constant"
+	"github.com/hongyuxuan/lizardcd/common/utils"
 	"github.com/zeromicro/go-zero/core/conf"
 	"github.com/zeromicro/go-zero/core/logx"
 	"github.com/zeromicro/go-zero/rest"
@@ -22,19 +25,89 @@ type Config struct {
 	Etcd          EtcdConf   `json:",optional"`
 	ServicePrefix string     `json:",optional"`
 	Sqlite        string
-	Rpc           RpcOption `json:",optional"`
+	Rpc           RpcOption         `json:",optional"`
+	Tunnel        TunnelConf        `json:",optional"`
+	Agents        []StaticAgentConf `json:",optional"` // agents without registry, reloaded when the config file changes
+	Task          TaskOption        `json:",optional"`
+	Oidc          OidcConf          `json:",optional"`
+	Ldap          LdapConf          `json:",optional"`
+	MasterKey     string            `json:",optional"` // encrypts the credentials stored in sqlite, overridden by env LIZARDCD_MASTER_KEY
 }
 
 type RpcOption struct {
-	Timeout       int64 `json:",optional"`
-	KeepaliveTime int64 `json:",optional"`
-	RetryInterval int64 `json:",optional"`
+	Timeout       int64         `json:",optional"`
+	KeepaliveTime int64         `json:",optional"`
+	RetryInterval int64         `json:",optional"`
+	Tls           utils.TlsConf `json:",optional"` // mutual tls to agents, CertFile and KeyFile are the client certificate verified by the agents
+	Secret        string        `json:",optional"` // shared secret with agents, signing a token for each rpc
 }
 
 func (rpc RpcOption) IsEmpty() bool {
 	return reflect.DeepEqual(rpc, RpcOption{})
 }
 
+// TunnelConf is the tunnel which agents dial out to, lizardcd-server calls the agents back over the tunnels without registry
+type TunnelConf struct {
+	ListenOn string        `json:",optional"` // e.g. 0.0.0.0:5118, the tunnel is disabled if empty
+	Tls      utils.TlsConf `json:",optional"` // CertFile and KeyFile are the server certificate, CaFile verifies the client certificates of agents
+}
+
+// StaticAgentConf is an agent without registry, which lizardcd-server connects by its address.
+// Static agents can also be managed by the api /lizardcd/agent/static.
+type StaticAgentConf struct {
+	Key     string // lizardcd-agent.<namespace>.<cluster>, without ServicePrefix
+	Address string // e.g. 10.50.89.20:5017
+}
+
+// TaskOption is the default timeout and intervals of tasks, which can be overridden by tenant settings and applications
+type TaskOption struct {
+	Timeout      int64 `json:",optional"` // seconds
+	InitialDelay int64 `json:",optional"` // seconds, waiting before the first status check
+	PollInterval int64 `json:",optional"` // seconds
+	WaitTimeout  int64 `json:",optional"` // seconds, request timeout of /lizardcd/task/run and /lizardcd/task/events, Timeout + 60 by default
+}
+
+// OidcConf is the OIDC provider of single sign-on, which is disabled if Issuer is empty
+type OidcConf struct {
+	Issuer         string         `json:",optional"`
+	ClientId       string         `json:",optional"`
+	ClientSecret   string         `json:",optional"`
+	RedirectUrl    string         `json:",optional"` // e.g. https://lizardcd.example.com/lizardcd/auth/oidc/callback
+	Scopes         []string       `json:",optional"` // openid, profile, email by default
+	UsernameClaim  string         `json:",optional"` // preferred_username by default
+	GroupsClaim    string         `json:",optional"` // groups by default
+	GroupMappings  []GroupMapping `json:",optional"` // the first mapping matched by the groups of a user decides its role and tenant
+	DefaultRole    string         `json:",optional"` // role and tenant of new users matching no mapping, the users are denied if empty
+	DefaultTenant  string         `json:",optional"`
+	AutoCreateUser bool           `json:",optional"` // create users not existing when they login
+	UiRedirectUrl  string         `json:",optional"` // the ui page receiving the token in the url fragment, /login/ by default
+}
+
+// LdapConf is the LDAP or Active Directory server authenticating users, which is disabled if Url is empty.
+// Local users are still authenticated if LDAP fails.
+type LdapConf struct {
+	Url                string         `json:",optional"` // e.g. ldap://ad.example.com:389 or ldaps://ad.example.com:636
+	StartTLS           bool           `json:",optional"`
+	InsecureSkipVerify bool           `json:",optional"`
+	BindDn             string         `json:",optional"` // the user searching users, anonymous if empty
+	BindPassword       string         `json:",optional"`
+	BaseDn             string         `json:",optional"`
+	UserFilter         string         `json:",optional"` // %s is the username, (uid=%s) by default, e.g. (sAMAccountName=%s) for Active Directory
+	GroupAttribute     string         `json:",optional"` // attribute of users listing their groups, memberOf by default
+	GroupBaseDn        string         `json:",optional"` // searches groups instead of GroupAttribute if not empty
+	GroupFilter        string         `json:",optional"` // %s is the dn of user, (member=%s) by default
+	GroupMappings      []GroupMapping `json:",optional"` // the first mapping matched by the cn of groups of a user decides its role and tenant
+	DefaultRole        string         `json:",optional"` // role and tenant of new users matching no mapping, the users are denied if empty
+	DefaultTenant      string         `json:",optional"`
+}
+
+// GroupMapping maps a group of the identity provider to the role and tenant of lizardcd
+type GroupMapping struct {
+	Group  string
+	Role   string
+	Tenant string `json:",optional"` // DefaultTenant if empty
+}
+
 type NacosConf struct {
 	Address     string
 	NamespaceId string
@@ -51,7 +124,7 @@ type ConsulConf struct {
 	Address string
 }
 
-func NewConfig(configFile, logLevel, consulAddr, etcdAddr, nacosAddr, nacosNamespaceId, nacosUsername, nacosPassword, nacosGroup, servicePrefix, listenOn, metricsListenOn, dbfile, accessSecret *string, accessExpire *int64) Config {
+func NewConfig(configFile, logLevel, consulAddr, etcdAddr, nacosAddr, nacosNamespaceId, nacosUsername, nacosPassword, nacosGroup, servicePrefix, listenOn, metricsListenOn, dbfile, accessSecret *string, accessExpire *int64, rpcTlsCert, rpcTlsKey, rpcTlsCa, rpcSecret, tunnelListenOn, tunnelTlsCert, tunnelTlsKey, tunnelTlsCa *string) Config {
 	var c = Config{}
 	if *configFile != "" {
 		conf.MustLoad(*configFile, &c)
@@ -132,10 +205,111 @@ func NewConfig(configFile, logLevel, consulAddr, etcdAddr, nacosAddr, nacosNames
 	if c.Rpc.RetryInterval == 0 {
 		c.Rpc.RetryInterval = 10
 	}
-	if c.Etcd.Address == "" && c.Consul.Address == "" && c.Nacos.Address == "" {
-		logx.Errorf("Either etcd, consul or nacos address must be specified.")
+	if *rpcTlsCert != "" {
+		c.Rpc.Tls.CertFile = *rpcTlsCert
+	}
+	if *rpcTlsKey != "" {
+		c.Rpc.Tls.KeyFile = *rpcTlsKey
+	}
+	if *rpcTlsCa != "" {
+		c.Rpc.Tls.CaFile = *rpcTlsCa
+	}
+	if *rpcSecret != "" {
+		c.Rpc.Secret = *rpcSecret
+	}
+	if *tunnelListenOn != "" {
+		c.Tunnel.ListenOn = *tunnelListenOn
+	}
+	if *tunnelTlsCert != "" {
+		c.Tunnel.Tls.CertFile = *tunnelTlsCert
+	}
+	if *tunnelTlsKey != "" {
+		c.Tunnel.Tls.KeyFile = *tunnelTlsKey
+	}
+	if *tunnelTlsCa != "" {
+		c.Tunnel.Tls.CaFile = *tunnelTlsCa
+	}
+	if c.Task.Timeout == 0 {
+		c.Task.Timeout = 300
+	}
+	if c.Task.InitialDelay == 0 {
+		c.Task.InitialDelay = 10
+	}
+	if c.Task.PollInterval == 0 {
+		c.Task.PollInterval = 3
+	}
+	if c.Task.WaitTimeout == 0 {
+		c.Task.WaitTimeout = c.Task.Timeout + 60
+	}
+	if c.Oidc.Issuer != "" {
+		if len(c.Oidc.Scopes) == 0 {
+			c.Oidc.Scopes = []string{"openid", "profile", "email"}
+		}
+		if c.Oidc.UsernameClaim == "" {
+			c.Oidc.UsernameClaim = "preferred_username"
+		}
+		if c.Oidc.GroupsClaim == "" {
+			c.Oidc.GroupsClaim = "groups"
+		}
+		if c.Oidc.UiRedirectUrl == "" {
+			c.Oidc.UiRedirectUrl = "/login/"
+		}
+	}
+	if c.Ldap.Url != "" {
+		if c.Ldap.UserFilter == "" {
+			c.Ldap.UserFilter = "(uid=%s)"
+		}
+		if c.Ldap.GroupAttribute == "" {
+			c.Ldap.GroupAttribute = "memberOf"
+		}
+		if c.Ldap.GroupFilter == "" {
+			c.Ldap.GroupFilter = "(member=%s)"
+		}
+	}
+	// users of a role matching no role of lizardcd would be denied or allowed unexpectedly
+	if err := validateRoles(c.Ldap.GroupMappings, c.Ldap.DefaultRole); err != nil {
+		logx.Errorf("Invalid role in Ldap config: %v", err)
+		os.Exit(0)
+	}
+	if err := validateRoles(c.Oidc.GroupMappings, c.Oidc.DefaultRole); err != nil {
+		logx.Errorf("Invalid role in Oidc config: %v", err)
 		os.Exit(0)
 	}
-	logx.Infof("Using config: %+v", c)
+	if masterKey := os.Getenv("LIZARDCD_MASTER_KEY"); masterKey != "" {
+		c.MasterKey = masterKey
+	}
+	if c.Etcd.Address == "" && c.Consul.Address == "" && c.Nacos.Address == "" && c.Tunnel.ListenOn == "" {
+		logx.Infof("Neither etcd, consul, nacos nor tunnel address is specified, only static agents are available")
+	}
+	logged := c
+	if logged.MasterKey != "" {
+		logged.MasterKey = utils.SecretMask
+	}
+	if logged.Rpc.Secret != "" {
+		logged.Rpc.Secret = utils.SecretMask
+	}
+	if logged.Oidc.ClientSecret != "" {
+		logged.Oidc.ClientSecret = utils.SecretMask
+	}
+	if logged.Ldap.BindPassword != "" {
+		logged.Ldap.BindPassword = utils.SecretMask
+	}
+	logx.Infof("Using config: %+v", logged)
 	return c
 }
+
+// validateRoles checks that the roles of group mappings and the default role are roles of lizardcd, an empty default role denies the users
+func validateRoles(mappings []GroupMapping, defaultRole string) error {
+	isRole := func(role string) bool {
+		return role == constant.ROLE_ADMIN || role == constant.ROLE_READWRITE || role == constant.ROLE_READONLY
+	}
+	for _, m := range mappings {
+		if !isRole(m.Role) {
+			return fmt.Errorf("role %q of group %q must be %s, %s or %s", m.Role, m.Group, constant.ROLE_ADMIN, constant.ROLE_READWRITE, constant.ROLE_READONLY)
+		}
+	}
+	if defaultRole != "" && !isRole(defaultRole) {
+		return fmt.Errorf("DefaultRole %q must be %s, %s or %s", defaultRole, constant.ROLE_ADMIN, constant.ROLE_READWRITE, constant.ROLE_READONLY)
+	}
+	return nil
+}
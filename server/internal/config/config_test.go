@@ -0,0 +1,26 @@
+package config
+
+import "testing"
+
+func TestValidateRoles(t *testing.T) {
+	tests := []struct {
+		name        string
+		mappings    []GroupMapping
+		defaultRole string
+		wantErr     bool
+	}{
+		{"valid", []GroupMapping{{Group: "ops", Role: "admin"}, {Group: "dev", Role: "readwrite", Tenant: "dev"}}, "readonly", false},
+		{"no default role", []GroupMapping{{Group: "ops", Role: "admin"}}, "", false},
+		{"role in upper case", []GroupMapping{{Group: "ops", Role: "Admin"}}, "", true},
+		{"abbreviated role", []GroupMapping{{Group: "dev", Role: "rw"}}, "", true},
+		{"empty role", []GroupMapping{{Group: "dev"}}, "", true},
+		{"invalid default role", nil, "guest", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := validateRoles(tt.mappings, tt.defaultRole); (err != nil) != tt.wantErr {
+				t.Errorf("validateRoles() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,29 @@
+package agent
+
+import (
+	"net/http"
+
+	"github.com/hongyuxuan/lizardcd/common/errorx"
+	"github.com/hongyuxuan/lizardcd/server/internal/logic/agent"
+	"github.com/hongyuxuan/lizardcd/server/internal/svc"
+	"github.com/hongyuxuan/lizardcd/server/internal/types"
+	"github.com/zeromicro/go-zero/rest/httpx"
+)
+
+func DeletestaticagentHandler(svcCtx *svc.ServiceContext) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var req types.StaticAgentIdReq
+		if err := httpx.Parse(r, &req); err != nil {
+			httpx.Error(w, errorx.NewError(http.StatusBadRequest, err.Error(), nil))
+			return
+		}
+
+		l := agent.NewDeletestaticagentLogic(r.Context(), svcCtx)
+		resp, err := l.Deletestaticagent(&req)
+		if err != nil {
+			httpx.Error(w, err)
+		} else {
+			httpx.OkJson(w, resp)
+		}
+	}
+}
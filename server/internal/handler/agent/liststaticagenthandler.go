@@ -0,0 +1,21 @@
+package agent
+
+import (
+	"net/http"
+
+	"github.com/hongyuxuan/lizardcd/server/internal/logic/agent"
+	"github.com/hongyuxuan/lizardcd/server/internal/svc"
+	"github.com/zeromicro/go-zero/rest/httpx"
+)
+
+func ListstaticagentHandler(svcCtx *svc.ServiceContext) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		l := agent.NewListstaticagentLogic(r.Context(), svcCtx)
+		resp, err := l.Liststaticagent()
+		if err != nil {
+			httpx.Error(w, err)
+		} else {
+			httpx.OkJson(w, resp)
+		}
+	}
+}
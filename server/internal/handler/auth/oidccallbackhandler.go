@@ -0,0 +1,32 @@
+package auth
+
+import (
+	"net/http"
+
+	"github.com/hongyuxuan/lizardcd/common/errorx"
+	"github.com/hongyuxuan/lizardcd/server/internal/logic/auth"
+	"github.com/hongyuxuan/lizardcd/server/internal/svc"
+	"github.com/hongyuxuan/lizardcd/server/internal/types"
+	"github.com/zeromicro/go-zero/rest/httpx"
+)
+
+func OidccallbackHandler(svcCtx *svc.ServiceContext) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var req types.OidcCallbackReq
+		if err := httpx.Parse(r, &req); err != nil {
+			httpx.Error(w, errorx.NewError(http.StatusBadRequest, err.Error(), nil))
+			return
+		}
+
+		// the state cookie set by oidclogin binds the callback to the browser starting the login
+		var stateCookie string
+		if c, err := r.Cookie(svc.OidcStateCookie); err == nil {
+			stateCookie = c.Value
+		}
+		http.SetCookie(w, svcCtx.Oidc.ClearStateCookie())
+
+		l := auth.NewOidccallbackLogic(r.Context(), svcCtx)
+		// the browser is always redirected back to the ui, with the token or the error in the url fragment
+		http.Redirect(w, r, l.Oidccallback(&req, stateCookie), http.StatusFound)
+	}
+}
@@ -0,0 +1,21 @@
+package auth
+
+import (
+	"net/http"
+
+	"github.com/hongyuxuan/lizardcd/server/internal/logic/auth"
+	"github.com/hongyuxuan/lizardcd/server/internal/svc"
+	"github.com/zeromicro/go-zero/rest/httpx"
+)
+
+func OidcconfigHandler(svcCtx *svc.ServiceContext) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		l := auth.NewOidcconfigLogic(r.Context(), svcCtx)
+		resp, err := l.Oidcconfig()
+		if err != nil {
+			httpx.Error(w, err)
+		} else {
+			httpx.OkJson(w, resp)
+		}
+	}
+}
@@ -0,0 +1,22 @@
+package auth
+
+import (
+	"net/http"
+
+	"github.com/hongyuxuan/lizardcd/server/internal/logic/auth"
+	"github.com/hongyuxuan/lizardcd/server/internal/svc"
+	"github.com/zeromicro/go-zero/rest/httpx"
+)
+
+func OidcloginHandler(svcCtx *svc.ServiceContext) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		l := auth.NewOidcloginLogic(r.Context(), svcCtx)
+		url, cookie, err := l.Oidclogin()
+		if err != nil {
+			httpx.Error(w, err)
+		} else {
+			http.SetCookie(w, cookie)
+			http.Redirect(w, r, url, http.StatusFound)
+		}
+	}
+}
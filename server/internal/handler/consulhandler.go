@@ -5,7 +5,6 @@ import (
 	"os"
 	"strings"
 	"sync"
-	"time"
 
 	capi "github.com/hashicorp/consul/api"
 	"github.com/hashicorp/consul/api/watch"
@@ -14,8 +13,6 @@ import (
 	"github.com/hongyuxuan/lizardcd/server/internal/types"
 	"github.com/zeromicro/go-zero/core/logx"
 	"github.com/zeromicro/go-zero/zrpc"
-	"google.golang.org/grpc"
-	"google.golang.org/grpc/keepalive"
 )
 
 type WatchHandler interface {
@@ -38,25 +35,21 @@ func (c ConsulWatch) Handler(_ uint64, data interface{}) {
 			}
 			if strings.HasPrefix(k, c.svcCtx.Config.ServicePrefix+"lizardcd-agent") {
 				// add lizardcd-agent service to agentList
-				if _, ok := c.svcCtx.AgentList[k]; !ok {
+				if _, ok := c.svcCtx.AgentList.Get(k); !ok {
 					cli, err := zrpc.NewClient(zrpc.RpcClientConf{
 						Timeout: c.svcCtx.Config.Rpc.Timeout,
 						Target:  fmt.Sprintf("consul://%s/%s?wait=60s", c.svcCtx.Config.Consul.Address, k),
-					}, zrpc.WithDialOption(grpc.WithKeepaliveParams(keepalive.ClientParameters{
-						Time:                time.Duration(c.svcCtx.Config.Rpc.KeepaliveTime) * time.Second,
-						Timeout:             time.Second,
-						PermitWithoutStream: true,
-					})))
+					}, c.svcCtx.AgentClientOptions(k)...)
 					if err != nil {
 						logx.Error(err)
 						continue
 					}
 					logx.Infof("A new lizardcd-agent: %s registered into consul", k)
-					c.svcCtx.AgentList[k] = &types.RpcAgent{
+					c.svcCtx.AgentList.Set(k, &types.RpcAgent{
 						Client:        lizardagent.NewLizardAgent(cli),
 						ServiceSource: "consul",
 						Cli:           cli,
-					}
+					})
 				}
 			}
 			// start creating one watch plan to watch every service
@@ -72,7 +65,7 @@ func (c ConsulWatch) Handler(_ uint64, data interface{}) {
 				plan.Stop()
 				delete(watchers, k)
 				if strings.HasPrefix(k, c.svcCtx.Config.ServicePrefix+"lizardcd-agent") {
-					delete(c.svcCtx.AgentList, k)
+					c.svcCtx.AgentList.Delete(k)
 					logx.Infof("Lizardcd-agent: %s removed from consul", k)
 				}
 			}
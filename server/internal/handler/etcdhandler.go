@@ -15,8 +15,6 @@ import (
 	"github.com/zeromicro/go-zero/zrpc"
 	"go.etcd.io/etcd/api/v3/mvccpb"
 	clientv3 "go.etcd.io/etcd/client/v3"
-	"google.golang.org/grpc"
-	"google.golang.org/grpc/keepalive"
 )
 
 func StartEtcdWatch(svcCtx *svc.ServiceContext) {
@@ -41,15 +39,15 @@ func StartEtcdWatch(svcCtx *svc.ServiceContext) {
 			if e.Type == mvccpb.PUT {
 				go addAgentList(svcCtx, etcdHosts, key)
 			} else if e.Type == mvccpb.DELETE {
-				if _, ok := svcCtx.AgentList[key]; ok {
-					if svcCtx.AgentList[key].ServiceSource == "etcd" {
-						svcCtx.AgentList[key].Count -= 1
-						if svcCtx.AgentList[key].Count == 0 {
-							delete(svcCtx.AgentList, key)
+				svcCtx.AgentList.Update(func(agents map[string]*types.RpcAgent) {
+					if agent, ok := agents[key]; ok && agent.ServiceSource == "etcd" {
+						agent.Count -= 1
+						if agent.Count == 0 {
+							delete(agents, key)
 							logx.Infof("Lizardcd-agent: %s removed from etcd", key)
 						}
 					}
-				}
+				})
 			}
 		}
 	}
@@ -57,34 +55,38 @@ func StartEtcdWatch(svcCtx *svc.ServiceContext) {
 
 func addAgentList(svcCtx *svc.ServiceContext, etcdHosts []string, key string) {
 	for {
-		if _, ok := svcCtx.AgentList[key]; !ok {
-			cli, err := zrpc.NewClient(zrpc.RpcClientConf{
-				Timeout: svcCtx.Config.Rpc.Timeout,
-				Etcd: discov.EtcdConf{
-					Hosts: etcdHosts,
-					Key:   key,
-				},
-			}, zrpc.WithDialOption(grpc.WithKeepaliveParams(keepalive.ClientParameters{ // add keepalive option
-				Time:                time.Duration(svcCtx.Config.Rpc.KeepaliveTime) * time.Second,
-				Timeout:             time.Second,
-				PermitWithoutStream: true,
-			})))
-			if err != nil {
-				logx.Error(err)
-				time.Sleep(time.Duration(svcCtx.Config.Rpc.RetryInterval) * time.Second) // sleep <RetryInterval> seconds and try again
-				continue
-			} else {
-				logx.Infof("A new lizardcd-agent: %s registered into etcd", key)
-				svcCtx.AgentList[key] = &types.RpcAgent{
-					Client:        lizardagent.NewLizardAgent(cli),
-					ServiceSource: "etcd",
-					Cli:           cli,
-					Count:         1,
-				}
-				return
+		counted := false
+		svcCtx.AgentList.Update(func(agents map[string]*types.RpcAgent) {
+			if agent, ok := agents[key]; ok {
+				agent.Count += 1
+				counted = true
 			}
+		})
+		if counted {
+			return
 		}
-		svcCtx.AgentList[key].Count += 1
-		return
+		cli, err := zrpc.NewClient(zrpc.RpcClientConf{
+			Timeout: svcCtx.Config.Rpc.Timeout,
+			Etcd: discov.EtcdConf{
+				Hosts: etcdHosts,
+				Key:   key,
+			},
+		}, svcCtx.AgentClientOptions(key)...)
+		if err != nil {
+			logx.Error(err)
+			time.Sleep(time.Duration(svcCtx.Config.Rpc.RetryInterval) * time.Second) // sleep <RetryInterval> seconds and try again
+			continue
+		}
+		if svcCtx.AgentList.Add(key, &types.RpcAgent{
+			Client:        lizardagent.NewLizardAgent(cli),
+			ServiceSource: "etcd",
+			Cli:           cli,
+			Count:         1,
+		}) {
+			logx.Infof("A new lizardcd-agent: %s registered into etcd", key)
+			return
+		}
+		// added by another instance of the key meanwhile, count it instead
+		cli.Conn().Close()
 	}
 }
@@ -12,8 +12,6 @@ import (
 	"github.com/nacos-group/nacos-sdk-go/model"
 	"github.com/nacos-group/nacos-sdk-go/util"
 	"github.com/nacos-group/nacos-sdk-go/vo"
-	"google.golang.org/grpc"
-	"google.golang.org/grpc/keepalive"
 
 	"github.com/samber/lo"
 	"github.com/zeromicro/go-zero/core/logx"
@@ -31,18 +29,18 @@ func StartNacosWatch(svcCtx *svc.ServiceContext) {
 			if !strings.HasPrefix(service, svcCtx.Config.ServicePrefix+"lizardcd-agent") {
 				continue
 			}
-			if _, ok := svcCtx.AgentList[service]; !ok {
+			if _, ok := svcCtx.AgentList.Get(service); !ok {
 				cli, err := getZrpcClient(svcCtx, service)
 				if err != nil {
 					logx.Error(err)
 					continue
 				}
 				logx.Infof("A new lizardcd-agent: %s registered into nacos", service)
-				svcCtx.AgentList[service] = &types.RpcAgent{
+				svcCtx.AgentList.Set(service, &types.RpcAgent{
 					Client:        lizardagent.NewLizardAgent(cli),
 					ServiceSource: "nacos",
 					Cli:           cli,
-				}
+				})
 				// subscribe servcie for changes
 				if err = svcCtx.NacosClient.Subscribe(&vo.SubscribeParam{
 					ServiceName: service,
@@ -52,11 +50,11 @@ func StartNacosWatch(svcCtx *svc.ServiceContext) {
 						if cli, err = getZrpcClient(svcCtx, service); err != nil {
 							logx.Error(err)
 						} else {
-							svcCtx.AgentList[service] = &types.RpcAgent{
+							svcCtx.AgentList.Set(service, &types.RpcAgent{
 								Client:        lizardagent.NewLizardAgent(cli),
 								ServiceSource: "nacos",
 								Cli:           cli,
-							}
+							})
 						}
 					},
 				}); err != nil {
@@ -65,19 +63,22 @@ func StartNacosWatch(svcCtx *svc.ServiceContext) {
 			}
 		}
 		// remove agent if services deregistered
-		for k, v := range svcCtx.AgentList {
-			_, ok := lo.Find(services, func(s string) bool {
-				return k == s
-			})
-			if v.ServiceSource == "nacos" && !ok {
-				delete(svcCtx.AgentList, k)
-				svcCtx.NacosClient.Unsubscribe(&vo.SubscribeParam{
-					ServiceName:       k,
-					GroupName:         svcCtx.Config.Nacos.Group,
-					SubscribeCallback: func(svcs []model.SubscribeService, e error) {},
-				})
-				logx.Infof("Lizardcd-agent: %s removed from nacos and unsubscribed", k)
+		var removed []string
+		svcCtx.AgentList.Update(func(agents map[string]*types.RpcAgent) {
+			for k, v := range agents {
+				if v.ServiceSource == "nacos" && !lo.Contains(services, k) {
+					delete(agents, k)
+					removed = append(removed, k)
+				}
 			}
+		})
+		for _, k := range removed {
+			svcCtx.NacosClient.Unsubscribe(&vo.SubscribeParam{
+				ServiceName:       k,
+				GroupName:         svcCtx.Config.Nacos.Group,
+				SubscribeCallback: func(svcs []model.SubscribeService, e error) {},
+			})
+			logx.Infof("Lizardcd-agent: %s removed from nacos and unsubscribed", k)
 		}
 		time.Sleep(time.Duration(10) * time.Second)
 	}
@@ -116,9 +117,5 @@ func getZrpcClient(svcCtx *svc.ServiceContext, service string) (cli zrpc.Client,
 			svcCtx.Config.Nacos.NamespaceId,
 			svcCtx.Config.Nacos.Group),
 	}
-	return zrpc.NewClient(clientConf, zrpc.WithDialOption(grpc.WithKeepaliveParams(keepalive.ClientParameters{
-		Time:                time.Duration(svcCtx.Config.Rpc.KeepaliveTime) * time.Second,
-		Timeout:             time.Second,
-		PermitWithoutStream: true,
-	})))
+	return zrpc.NewClient(clientConf, svcCtx.AgentClientOptions(service)...)
 }
@@ -3,7 +3,9 @@ package handler
 
 import (
 	"net/http"
+	"time"
 
+	agent "github.com/hongyuxuan/lizardcd/server/internal/handler/agent"
 	auth "github.com/hongyuxuan/lizardcd/server/internal/handler/auth"
 	db "github.com/hongyuxuan/lizardcd/server/internal/handler/db"
 	helm "github.com/hongyuxuan/lizardcd/server/internal/handler/helm"
@@ -11,9 +13,12 @@ import (
 	istio "github.com/hongyuxuan/lizardcd/server/internal/handler/istio"
 	kubernetes "github.com/hongyuxuan/lizardcd/server/internal/handler/kubernetes"
 	lizardcd "github.com/hongyuxuan/lizardcd/server/internal/handler/lizardcd"
+	rbac "github.com/hongyuxuan/lizardcd/server/internal/handler/rbac"
 	static "github.com/hongyuxuan/lizardcd/server/internal/handler/static"
 	task "github.com/hongyuxuan/lizardcd/server/internal/handler/task"
+	token "github.com/hongyuxuan/lizardcd/server/internal/handler/token"
 	vm "github.com/hongyuxuan/lizardcd/server/internal/handler/vm"
+	webhook "github.com/hongyuxuan/lizardcd/server/internal/handler/webhook"
 	"github.com/hongyuxuan/lizardcd/server/internal/svc"
 
 	"github.com/zeromicro/go-zero/rest"
@@ -100,6 +105,21 @@ func RegisterHandlers(server *rest.Server, serverCtx *svc.ServiceContext) {
 				Path:    "/login",
 				Handler: auth.LoginHandler(serverCtx),
 			},
+			{
+				Method:  http.MethodGet,
+				Path:    "/oidc/config",
+				Handler: auth.OidcconfigHandler(serverCtx),
+			},
+			{
+				Method:  http.MethodGet,
+				Path:    "/oidc/login",
+				Handler: auth.OidcloginHandler(serverCtx),
+			},
+			{
+				Method:  http.MethodGet,
+				Path:    "/oidc/callback",
+				Handler: auth.OidccallbackHandler(serverCtx),
+			},
 		},
 		rest.WithPrefix("/lizardcd/auth"),
 	)
@@ -113,6 +133,11 @@ func RegisterHandlers(server *rest.Server, serverCtx *svc.ServiceContext) {
 					Path:    "/:tablename",
 					Handler: db.ListdataHandler(serverCtx),
 				},
+				{
+					Method:  http.MethodGet,
+					Path:    "/:tablename/export",
+					Handler: db.ExportdataHandler(serverCtx),
+				},
 				{
 					Method:  http.MethodGet,
 					Path:    "/:tablename/:id",
@@ -141,7 +166,7 @@ func RegisterHandlers(server *rest.Server, serverCtx *svc.ServiceContext) {
 
 	server.AddRoutes(
 		rest.WithMiddlewares(
-			[]rest.Middleware{serverCtx.Validateuser},
+			[]rest.Middleware{serverCtx.Validateuser, serverCtx.Authorize},
 			[]rest.Route{
 				{
 					Method:  http.MethodGet,
@@ -231,7 +256,7 @@ func RegisterHandlers(server *rest.Server, serverCtx *svc.ServiceContext) {
 
 	server.AddRoutes(
 		rest.WithMiddlewares(
-			[]rest.Middleware{serverCtx.Validateuser},
+			[]rest.Middleware{serverCtx.Validateuser, serverCtx.Authorize},
 			[]rest.Route{
 				{
 					Method:  http.MethodGet,
@@ -256,7 +281,7 @@ func RegisterHandlers(server *rest.Server, serverCtx *svc.ServiceContext) {
 
 	server.AddRoutes(
 		rest.WithMiddlewares(
-			[]rest.Middleware{serverCtx.Validateuser},
+			[]rest.Middleware{serverCtx.Validateuser, serverCtx.Authorize},
 			[]rest.Route{
 				{
 					Method:  http.MethodPatch,
@@ -361,7 +386,57 @@ func RegisterHandlers(server *rest.Server, serverCtx *svc.ServiceContext) {
 
 	server.AddRoutes(
 		rest.WithMiddlewares(
-			[]rest.Middleware{serverCtx.Validateuser},
+			[]rest.Middleware{serverCtx.Validateuser, serverCtx.Authorize},
+			[]rest.Route{
+				{
+					Method:  http.MethodDelete,
+					Path:    "/history/:id",
+					Handler: task.DeleteHistoryHandler(serverCtx),
+				},
+				{
+					Method:  http.MethodPost,
+					Path:    "/cancel/:id",
+					Handler: task.CancelTaskHandler(serverCtx),
+				},
+				{
+					Method:  http.MethodPost,
+					Path:    "/approve/:id",
+					Handler: task.ApproveTaskHandler(serverCtx),
+				},
+				{
+					Method:  http.MethodPost,
+					Path:    "/reject/:id",
+					Handler: task.RejectTaskHandler(serverCtx),
+				},
+				{
+					Method:  http.MethodPost,
+					Path:    "/schedule",
+					Handler: task.CreateScheduleHandler(serverCtx),
+				},
+				{
+					Method:  http.MethodPut,
+					Path:    "/schedule/:id",
+					Handler: task.UpdateScheduleHandler(serverCtx),
+				},
+				{
+					Method:  http.MethodDelete,
+					Path:    "/schedule/:id",
+					Handler: task.DeleteScheduleHandler(serverCtx),
+				},
+				{
+					Method:  http.MethodPost,
+					Path:    "/notify/test/:id",
+					Handler: task.TestNotifyHandler(serverCtx),
+				},
+			}...,
+		),
+		rest.WithJwt(serverCtx.Config.Auth.AccessSecret),
+		rest.WithPrefix("/lizardcd/task"),
+	)
+
+	server.AddRoutes(
+		rest.WithMiddlewares(
+			[]rest.Middleware{serverCtx.Validateuser, serverCtx.Authorize},
 			[]rest.Route{
 				{
 					Method:  http.MethodPost,
@@ -369,19 +444,20 @@ func RegisterHandlers(server *rest.Server, serverCtx *svc.ServiceContext) {
 					Handler: task.RunTaskHandler(serverCtx),
 				},
 				{
-					Method:  http.MethodDelete,
-					Path:    "/history/:id",
-					Handler: task.DeleteHistoryHandler(serverCtx),
+					Method:  http.MethodGet,
+					Path:    "/events/:id",
+					Handler: task.TaskEventsHandler(serverCtx),
 				},
 			}...,
 		),
 		rest.WithJwt(serverCtx.Config.Auth.AccessSecret),
 		rest.WithPrefix("/lizardcd/task"),
+		rest.WithTimeout(time.Duration(serverCtx.Config.Task.WaitTimeout)*time.Second),
 	)
 
 	server.AddRoutes(
 		rest.WithMiddlewares(
-			[]rest.Middleware{serverCtx.Validateuser},
+			[]rest.Middleware{serverCtx.Validateuser, serverCtx.Authorize},
 			[]rest.Route{
 				{
 					Method:  http.MethodPost,
@@ -401,7 +477,7 @@ func RegisterHandlers(server *rest.Server, serverCtx *svc.ServiceContext) {
 
 	server.AddRoutes(
 		rest.WithMiddlewares(
-			[]rest.Middleware{serverCtx.Validateuser},
+			[]rest.Middleware{serverCtx.Validateuser, serverCtx.Authorize},
 			[]rest.Route{
 				{
 					Method:  http.MethodPost,
@@ -418,4 +494,110 @@ func RegisterHandlers(server *rest.Server, serverCtx *svc.ServiceContext) {
 		rest.WithJwt(serverCtx.Config.Auth.AccessSecret),
 		rest.WithPrefix("/lizardcd/http"),
 	)
+
+	server.AddRoutes(
+		[]rest.Route{
+			{
+				Method:  http.MethodPost,
+				Path:    "/harbor/:app_name",
+				Handler: webhook.HarborWebhookHandler(serverCtx),
+			},
+			{
+				Method:  http.MethodPost,
+				Path:    "/dockerhub/:app_name",
+				Handler: webhook.DockerHubWebhookHandler(serverCtx),
+			},
+			{
+				Method:  http.MethodPost,
+				Path:    "/generic/:app_name",
+				Handler: webhook.GenericWebhookHandler(serverCtx),
+			},
+		},
+		rest.WithPrefix("/lizardcd/webhook"),
+	)
+
+	server.AddRoutes(
+		rest.WithMiddlewares(
+			[]rest.Middleware{serverCtx.Validateuser},
+			[]rest.Route{
+				{
+					Method:  http.MethodGet,
+					Path:    "/policies",
+					Handler: rbac.ListpolicyHandler(serverCtx),
+				},
+				{
+					Method:  http.MethodPost,
+					Path:    "/policies",
+					Handler: rbac.CreatepolicyHandler(serverCtx),
+				},
+				{
+					Method:  http.MethodPut,
+					Path:    "/policies/:id",
+					Handler: rbac.UpdatepolicyHandler(serverCtx),
+				},
+				{
+					Method:  http.MethodDelete,
+					Path:    "/policies/:id",
+					Handler: rbac.DeletepolicyHandler(serverCtx),
+				},
+			}...,
+		),
+		rest.WithJwt(serverCtx.Config.Auth.AccessSecret),
+		rest.WithPrefix("/lizardcd/rbac"),
+	)
+
+	server.AddRoutes(
+		rest.WithMiddlewares(
+			[]rest.Middleware{serverCtx.Validateuser},
+			[]rest.Route{
+				{
+					Method:  http.MethodGet,
+					Path:    "/tokens",
+					Handler: token.ListtokenHandler(serverCtx),
+				},
+				{
+					Method:  http.MethodPost,
+					Path:    "/tokens",
+					Handler: token.CreatetokenHandler(serverCtx),
+				},
+				{
+					Method:  http.MethodDelete,
+					Path:    "/tokens/:id",
+					Handler: token.RevoketokenHandler(serverCtx),
+				},
+			}...,
+		),
+		rest.WithJwt(serverCtx.Config.Auth.AccessSecret),
+		rest.WithPrefix("/lizardcd/token"),
+	)
+
+	server.AddRoutes(
+		rest.WithMiddlewares(
+			[]rest.Middleware{serverCtx.Validateuser},
+			[]rest.Route{
+				{
+					Method:  http.MethodGet,
+					Path:    "/static",
+					Handler: agent.ListstaticagentHandler(serverCtx),
+				},
+				{
+					Method:  http.MethodPost,
+					Path:    "/static",
+					Handler: agent.CreatestaticagentHandler(serverCtx),
+				},
+				{
+					Method:  http.MethodPut,
+					Path:    "/static/:id",
+					Handler: agent.UpdatestaticagentHandler(serverCtx),
+				},
+				{
+					Method:  http.MethodDelete,
+					Path:    "/static/:id",
+					Handler: agent.DeletestaticagentHandler(serverCtx),
+				},
+			}...,
+		),
+		rest.WithJwt(serverCtx.Config.Auth.AccessSecret),
+		rest.WithPrefix("/lizardcd/agent"),
+	)
 }
@@ -0,0 +1,12 @@
+package handler
+
+import (
+	"context"
+
+	"github.com/hongyuxuan/lizardcd/server/internal/logic/task"
+	"github.com/hongyuxuan/lizardcd/server/internal/svc"
+)
+
+func StartScheduler(svcCtx *svc.ServiceContext) {
+	task.NewSchedulerLogic(context.Background(), svcCtx).Start()
+}
@@ -0,0 +1,56 @@
+package handler
+
+import (
+	"context"
+	"os"
+	"time"
+
+	"github.com/hongyuxuan/lizardcd/server/internal/config"
+	"github.com/hongyuxuan/lizardcd/server/internal/svc"
+	"github.com/zeromicro/go-zero/core/conf"
+	"github.com/zeromicro/go-zero/core/logx"
+)
+
+const staticAgentReloadInterval = 10 * time.Second
+
+// StartStaticAgentWatch adds the static agents to AgentList and syncs them periodically, reloading the Agents of configFile when it changes.
+// Syncing at every tick retries the keys taken by agents of registries or tunnels, once those agents are gone.
+func StartStaticAgentWatch(svcCtx *svc.ServiceContext, configFile string) {
+	var modTime time.Time
+	if configFile != "" {
+		if fi, err := os.Stat(configFile); err == nil {
+			modTime = fi.ModTime()
+		}
+	}
+	ticker := time.NewTicker(staticAgentReloadInterval)
+	defer ticker.Stop()
+	for {
+		if err := svcCtx.StaticAgents.Sync(context.Background()); err != nil {
+			logx.Errorf("Failed to sync static agents: %v", err)
+		}
+		<-ticker.C
+		if configFile != "" {
+			modTime = reloadStaticAgents(svcCtx, configFile, modTime)
+		}
+	}
+}
+
+// reloadStaticAgents sets the Agents of configFile if it is modified after modTime, and returns its modification time
+func reloadStaticAgents(svcCtx *svc.ServiceContext, configFile string, modTime time.Time) time.Time {
+	fi, err := os.Stat(configFile)
+	if err != nil {
+		logx.Errorf("Failed to stat config file %s: %v", configFile, err)
+		return modTime
+	}
+	if fi.ModTime().Equal(modTime) {
+		return modTime
+	}
+	var c config.Config
+	if err = conf.Load(configFile, &c); err != nil {
+		logx.Errorf("Failed to reload config file %s: %v", configFile, err)
+		return fi.ModTime()
+	}
+	logx.Infof("Reload static agents from config file %s", configFile)
+	svcCtx.StaticAgents.SetConfigAgents(c.Agents)
+	return fi.ModTime()
+}
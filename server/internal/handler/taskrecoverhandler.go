@@ -0,0 +1,12 @@
+package handler
+
+import (
+	"context"
+
+	"github.com/hongyuxuan/lizardcd/server/internal/logic/task"
+	"github.com/hongyuxuan/lizardcd/server/internal/svc"
+)
+
+func StartTaskRecover(svcCtx *svc.ServiceContext) {
+	task.NewRecoverTaskLogic(context.Background(), svcCtx).RecoverTask()
+}
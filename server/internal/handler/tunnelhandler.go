@@ -0,0 +1,189 @@
+package handler
+
+import (
+	"crypto/tls"
+	"fmt"
+	"net"
+	"os"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/hongyuxuan/lizardcd/agent/lizardagent"
+	"github.com/hongyuxuan/lizardcd/common/utils"
+	"github.com/hongyuxuan/lizardcd/server/internal/svc"
+	"github.com/hongyuxuan/lizardcd/server/internal/types"
+	"github.com/samber/lo"
+	"github.com/zeromicro/go-zero/core/logx"
+	"github.com/zeromicro/go-zero/zrpc"
+)
+
+const tunnelHandshakeTimeout = 10 * time.Second
+
+var (
+	tunnelLock sync.Mutex          // guards tunnelKeys
+	tunnelKeys = map[string]bool{} // keys of the tunnels connected or handshaking
+)
+
+// StartTunnel accepts the tunnels dialed out by agents in tunnel mode, the rpcs to each agent are multiplexed over its tunnel
+func StartTunnel(svcCtx *svc.ServiceContext) {
+	c := svcCtx.Config.Tunnel
+	// agents on the tunnel are not registered by a registry, so they must be authenticated
+	if c.Tls.CaFile == "" && svcCtx.Config.Rpc.Secret == "" {
+		logx.Errorf("Neither Tunnel.Tls.CaFile nor Rpc.Secret is set, refuse to start tunnel at %s since anyone reaching it can connect as lizardcd-agent", c.ListenOn)
+		os.Exit(0)
+	}
+	lis, err := net.Listen("tcp", c.ListenOn)
+	if err != nil {
+		logx.Errorf("Failed to listen on tunnel address %s: %v", c.ListenOn, err)
+		os.Exit(0)
+	}
+	if c.Tls.Enabled() {
+		tlsConfig, err := c.Tls.ServerConfig()
+		if err != nil {
+			logx.Errorf("Failed to load tls config of tunnel: %v", err)
+			os.Exit(0)
+		}
+		lis = tls.NewListener(lis, tlsConfig)
+	}
+	logx.Infof("Starting tunnel at %s...", c.ListenOn)
+	for {
+		conn, err := lis.Accept()
+		if err != nil {
+			logx.Errorf("Failed to accept tunnel: %v", err)
+			time.Sleep(time.Second)
+			continue
+		}
+		go serveTunnel(svcCtx, conn)
+	}
+}
+
+func serveTunnel(svcCtx *svc.ServiceContext, conn net.Conn) {
+	key, err := acceptTunnel(svcCtx, conn)
+	if err != nil {
+		logx.Errorf("Rejected tunnel from %s: %v", conn.RemoteAddr(), err)
+		utils.WriteTunnelFrame(conn, utils.TunnelAck{Error: err.Error()})
+		conn.Close()
+		return
+	}
+	if err = utils.WriteTunnelFrame(conn, utils.TunnelAck{}); err != nil {
+		logx.Errorf("Failed to accept tunnel from %s: %v", conn.RemoteAddr(), err)
+		removeTunnelAgent(svcCtx, key, nil)
+		conn.Close()
+		return
+	}
+	conn.SetDeadline(time.Time{})
+
+	tc := &tunnelConn{Conn: conn, closed: make(chan struct{})}
+	cli, err := zrpc.NewClient(zrpc.RpcClientConf{
+		Timeout:   svcCtx.Config.Rpc.Timeout,
+		Endpoints: []string{conn.RemoteAddr().String()}, // the address is not dialed, see ServiceContext.TunnelClientOptions
+	}, svcCtx.TunnelClientOptions(key, tc)...)
+	if err != nil {
+		logx.Errorf("Failed to connect to lizardcd-agent: %s over tunnel: %v", key, err)
+		removeTunnelAgent(svcCtx, key, nil)
+		tc.Close()
+		return
+	}
+	if !svcCtx.AgentList.Add(key, &types.RpcAgent{
+		Client:        lizardagent.NewLizardAgent(cli),
+		ServiceSource: "tunnel",
+		Cli:           cli,
+		Count:         1,
+		Address:       conn.RemoteAddr().String(),
+	}) {
+		logx.Errorf("Rejected tunnel from %s: lizardcd-agent: %s is registered meanwhile", conn.RemoteAddr(), key)
+		removeTunnelAgent(svcCtx, key, nil)
+		cli.Conn().Close()
+		tc.Close()
+		return
+	}
+	logx.Infof("A new lizardcd-agent: %s connected by tunnel from %s", key, conn.RemoteAddr())
+
+	<-tc.closed
+	removeTunnelAgent(svcCtx, key, cli)
+	cli.Conn().Close()
+	logx.Infof("Lizardcd-agent: %s disconnected from tunnel", key)
+}
+
+// acceptTunnel verifies the TunnelHello of agent and reserves its key
+func acceptTunnel(svcCtx *svc.ServiceContext, conn net.Conn) (key string, err error) {
+	conn.SetDeadline(time.Now().Add(tunnelHandshakeTimeout))
+	var hello utils.TunnelHello
+	if err = utils.ReadTunnelFrame(conn, &hello); err != nil {
+		return
+	}
+	key = hello.ServiceKey
+	if !strings.HasPrefix(key, svcCtx.Config.ServicePrefix+"lizardcd-agent") {
+		return "", fmt.Errorf("service key %q must be <ServicePrefix>lizardcd-agent.<namespace>.<cluster>", key)
+	}
+	if _, err = utils.GetServiceMata(svcCtx.Config.ServicePrefix, key); err != nil {
+		return "", err
+	}
+	if _, err = regexp.Compile(key); err != nil { // keys are matched as regexps, see ServiceContext.GetAgent
+		return "", fmt.Errorf("invalid service key %q: %v", key, err)
+	}
+	if err = checkTunnelIdentity(conn, key); err != nil {
+		return "", err
+	}
+	if svcCtx.Config.Rpc.Secret != "" {
+		if err = utils.VerifyTunnelToken(svcCtx.Config.Rpc.Secret, hello.Token); err != nil {
+			return "", fmt.Errorf("invalid tunnel token: %v", err)
+		}
+	}
+	tunnelLock.Lock()
+	defer tunnelLock.Unlock()
+	if _, ok := svcCtx.AgentList.Get(key); ok || tunnelKeys[key] {
+		// replicas of the agent wait until the connected one is gone
+		return "", fmt.Errorf("lizardcd-agent: %s is already connected", key)
+	}
+	tunnelKeys[key] = true
+	return
+}
+
+// checkTunnelIdentity checks that the client certificate verified by Tunnel.Tls.CaFile is issued for the service key,
+// by its common name or dns names, so an agent cannot connect as other agents
+func checkTunnelIdentity(conn net.Conn, key string) error {
+	tlsConn, ok := conn.(*tls.Conn)
+	if !ok {
+		return nil
+	}
+	chains := tlsConn.ConnectionState().VerifiedChains
+	if len(chains) == 0 { // client certificates are not verified without CaFile
+		return nil
+	}
+	cert := chains[0][0]
+	if cert.Subject.CommonName == key || lo.Contains(cert.DNSNames, key) {
+		return nil
+	}
+	return fmt.Errorf("client certificate %q is not issued for service key %q", cert.Subject.CommonName, key)
+}
+
+// removeTunnelAgent releases the key reserved by acceptTunnel, and removes the agent connected by cli if not nil
+func removeTunnelAgent(svcCtx *svc.ServiceContext, key string, cli zrpc.Client) {
+	if cli != nil {
+		svcCtx.AgentList.Update(func(agents map[string]*types.RpcAgent) {
+			if agent, ok := agents[key]; ok && agent.Cli == cli {
+				delete(agents, key)
+			}
+		})
+	}
+	tunnelLock.Lock()
+	defer tunnelLock.Unlock()
+	delete(tunnelKeys, key)
+}
+
+// tunnelConn closes closed when the grpc client closes the tunnel, e.g. the agent is disconnected
+type tunnelConn struct {
+	net.Conn
+	closed    chan struct{}
+	closeOnce sync.Once
+}
+
+func (c *tunnelConn) Close() error {
+	c.closeOnce.Do(func() {
+		close(c.closed)
+	})
+	return c.Conn.Close()
+}
@@ -0,0 +1,83 @@
+package handler
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"math/big"
+	"net"
+	"testing"
+	"time"
+)
+
+// newCertificate issues a certificate of commonName and dnsNames by parent, or a self-signed ca if parent is nil
+func newCertificate(t *testing.T, commonName string, dnsNames []string, parent *tls.Certificate) tls.Certificate {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject:      pkix.Name{CommonName: commonName},
+		DNSNames:     dnsNames,
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth, x509.ExtKeyUsageServerAuth},
+	}
+	issuer, signer := template, interface{}(key)
+	if parent == nil {
+		template.IsCA = true
+		template.BasicConstraintsValid = true
+	} else {
+		issuer, signer = parent.Leaf, parent.PrivateKey
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, issuer, &key.PublicKey, signer)
+	if err != nil {
+		t.Fatal(err)
+	}
+	leaf, _ := x509.ParseCertificate(der)
+	return tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key, Leaf: leaf}
+}
+
+func TestCheckTunnelIdentity(t *testing.T) {
+	ca := newCertificate(t, "lizardcd-ca", nil, nil)
+	pool := x509.NewCertPool()
+	pool.AddCert(ca.Leaf)
+	server := newCertificate(t, "lizardcd-server", []string{"lizardcd-server"}, &ca)
+	key := "lizardcd-agent.*.tektonk8s"
+
+	tests := []struct {
+		name    string
+		client  tls.Certificate
+		wantErr bool
+	}{
+		{"common name", newCertificate(t, key, nil, &ca), false},
+		{"dns name", newCertificate(t, "agent", []string{"agent", key}, &ca), false},
+		{"certificate of another agent", newCertificate(t, "lizardcd-agent.*.prod", nil, &ca), true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			serverConn, clientConn := net.Pipe()
+			defer serverConn.Close()
+			defer clientConn.Close()
+			go tls.Client(clientConn, &tls.Config{RootCAs: pool, ServerName: "lizardcd-server", Certificates: []tls.Certificate{tt.client}}).Handshake()
+			conn := tls.Server(serverConn, &tls.Config{Certificates: []tls.Certificate{server}, ClientCAs: pool, ClientAuth: tls.RequireAndVerifyClientCert})
+			if err := conn.Handshake(); err != nil {
+				t.Fatal(err)
+			}
+			if err := checkTunnelIdentity(conn, key); (err != nil) != tt.wantErr {
+				t.Errorf("checkTunnelIdentity() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+	// tunnels without tls are authenticated by Rpc.Secret
+	serverConn, clientConn := net.Pipe()
+	defer clientConn.Close()
+	if err := checkTunnelIdentity(serverConn, key); err != nil {
+		t.Errorf("checkTunnelIdentity() of plain tunnel error = %v", err)
+	}
+}
@@ -0,0 +1,113 @@
+package webhook
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/hongyuxuan/lizardcd/common/constant"
+	"github.com/hongyuxuan/lizardcd/common/errorx"
+	commontypes "github.com/hongyuxuan/lizardcd/common/types"
+	"github.com/hongyuxuan/lizardcd/common/utils"
+	"github.com/hongyuxuan/lizardcd/server/internal/svc"
+	"github.com/zeromicro/go-zero/rest/httpx"
+	"github.com/zeromicro/go-zero/rest/pathvar"
+)
+
+// newTestServiceContext returns a service context with the application "web", whose webhook deploys tags starting with v
+func newTestServiceContext(t *testing.T) *svc.ServiceContext {
+	// the same as lizardserver, so the status codes of errorx are responded
+	httpx.SetErrorHandler(func(err error) (int, interface{}) {
+		if e, ok := err.(*errorx.LizardcdError); ok {
+			return e.Code, e.GetData()
+		}
+		return http.StatusInternalServerError, err.Error()
+	})
+	db := utils.NewSQLite(filepath.Join(t.TempDir(), "lizardcd.db"), "error")
+	if err := db.AutoMigrate(&commontypes.Application{}); err != nil {
+		t.Fatal(err)
+	}
+	// json columns are written as strings like the db api does
+	if err := db.Table("application").Create(map[string]interface{}{
+		"app_name":    "web",
+		"deploy_type": constant.DEPLOY_TYPE_CONTAINER,
+		"repo":        `{"repo_type":"Harbor","repo_url":"https://harbor.example.com"}`,
+		"repo_name":   "library",
+		"image_name":  "web",
+		"workload":    `[]`,
+		"webhook":     `{"enabled":true,"secret":"s3cret","tag_filter":"^v"}`,
+		"tenant":      "dev",
+	}).Error; err != nil {
+		t.Fatal(err)
+	}
+	return &svc.ServiceContext{Sqlite: db}
+}
+
+func TestWebhookHandlers(t *testing.T) {
+	svcCtx := newTestServiceContext(t)
+	tests := []struct {
+		name     string
+		handler  http.HandlerFunc
+		appName  string
+		query    string
+		header   map[string]string
+		body     string
+		wantCode int
+		wantBody string
+	}{
+		{"generic bad secret", GenericWebhookHandler(svcCtx), "web", "?secret=wrong",
+			nil, `{"tag":"v1.0.0"}`, http.StatusUnauthorized, "webhook认证失败"},
+		{"generic no secret", GenericWebhookHandler(svcCtx), "web", "",
+			nil, `{"tag":"v1.0.0"}`, http.StatusUnauthorized, "webhook认证失败"},
+		{"generic bad bearer secret", GenericWebhookHandler(svcCtx), "web", "",
+			map[string]string{"Authorization": "Bearer wrong"}, `{"tag":"v1.0.0"}`, http.StatusUnauthorized, "webhook认证失败"},
+		{"unknown application is not told", GenericWebhookHandler(svcCtx), "unknown", "?secret=s3cret",
+			nil, `{"tag":"v1.0.0"}`, http.StatusUnauthorized, "webhook认证失败"},
+		{"generic tag ignored by filter", GenericWebhookHandler(svcCtx), "web", "",
+			map[string]string{"Authorization": "Bearer s3cret"}, `{"tag":"dev-1"}`, http.StatusOK, "已忽略"},
+		{"generic invalid tag", GenericWebhookHandler(svcCtx), "web", "?secret=s3cret",
+			nil, `{"tag":"v1/../../other/image"}`, http.StatusBadRequest, "不正确"},
+		{"generic artifact url of another registry", GenericWebhookHandler(svcCtx), "web", "?secret=s3cret",
+			nil, `{"tag":"v1.0.0","artifact_url":"evil.example.com/library/web:v1.0.0"}`, http.StatusBadRequest, "不一致"},
+		{"generic artifact url of another image", GenericWebhookHandler(svcCtx), "web", "?secret=s3cret",
+			nil, `{"tag":"v1.0.0","artifact_url":"harbor.example.com/library/other:v1.0.0"}`, http.StatusBadRequest, "不一致"},
+		{"harbor bad secret", HarborWebhookHandler(svcCtx), "web", "",
+			map[string]string{"Authorization": "wrong"}, harborPush("library/web", "v1.0.0"), http.StatusUnauthorized, "webhook认证失败"},
+		{"harbor tag ignored by filter", HarborWebhookHandler(svcCtx), "web", "",
+			map[string]string{"Authorization": "s3cret"}, harborPush("library/web", "latest"), http.StatusOK, "已忽略"},
+		{"harbor repository mismatch", HarborWebhookHandler(svcCtx), "web", "",
+			map[string]string{"Authorization": "s3cret"}, harborPush("other/web", "v1.0.0"), http.StatusBadRequest, "不一致"},
+		{"harbor other events ignored", HarborWebhookHandler(svcCtx), "web", "?secret=s3cret",
+			nil, `{"type":"DELETE_ARTIFACT","event_data":{"repository":{}}}`, http.StatusOK, "已忽略"},
+		{"dockerhub bad secret", DockerHubWebhookHandler(svcCtx), "web", "?secret=wrong",
+			nil, dockerhubPush("library/web", "v1.0.0"), http.StatusUnauthorized, "webhook认证失败"},
+		{"dockerhub repository mismatch", DockerHubWebhookHandler(svcCtx), "web", "?secret=s3cret",
+			nil, dockerhubPush("evil/web", "v1.0.0"), http.StatusBadRequest, "不一致"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodPost, "/lizardcd/webhook/"+tt.appName+tt.query, strings.NewReader(tt.body))
+			r.Header.Set("Content-Type", "application/json")
+			for k, v := range tt.header {
+				r.Header.Set(k, v)
+			}
+			r = pathvar.WithVars(r, map[string]string{"app_name": tt.appName})
+			w := httptest.NewRecorder()
+			tt.handler(w, r)
+			if w.Code != tt.wantCode || !strings.Contains(w.Body.String(), tt.wantBody) {
+				t.Errorf("response = %d %s, want %d containing %q", w.Code, w.Body.String(), tt.wantCode, tt.wantBody)
+			}
+		})
+	}
+}
+
+func harborPush(repository, tag string) string {
+	return `{"type":"PUSH_ARTIFACT","event_data":{"repository":{"repo_full_name":"` + repository + `"},` +
+		`"resources":[{"tag":"` + tag + `","resource_url":"harbor.example.com/` + repository + `:` + tag + `"}]}}`
+}
+
+func dockerhubPush(repository, tag string) string {
+	return `{"push_data":{"tag":"` + tag + `"},"repository":{"repo_name":"` + repository + `"}}`
+}
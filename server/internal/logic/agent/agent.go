@@ -0,0 +1,20 @@
+package agent
+
+import (
+	"net/http"
+
+	"github.com/hongyuxuan/lizardcd/common/errorx"
+	"github.com/hongyuxuan/lizardcd/server/internal/svc"
+	"github.com/hongyuxuan/lizardcd/server/internal/types"
+)
+
+// validate checks the static agent of req, whose key must not be an agent of config file
+func validate(svcCtx *svc.ServiceContext, req *types.StaticAgentReq) error {
+	if err := svc.ValidateStaticAgent(req.ServiceKey, req.Address); err != nil {
+		return err
+	}
+	if svcCtx.StaticAgents.InConfig(req.ServiceKey) {
+		return errorx.NewError(http.StatusBadRequest, "该agent已在配置文件中配置，请修改配置文件", nil)
+	}
+	return nil
+}
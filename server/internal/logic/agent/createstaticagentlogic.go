@@ -0,0 +1,57 @@
+package agent
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/hongyuxuan/lizardcd/common/errorx"
+	commontypes "github.com/hongyuxuan/lizardcd/common/types"
+	"github.com/hongyuxuan/lizardcd/server/internal/svc"
+	"github.com/hongyuxuan/lizardcd/server/internal/types"
+
+	"github.com/zeromicro/go-zero/core/logx"
+)
+
+type CreatestaticagentLogic struct {
+	logx.Logger
+	ctx    context.Context
+	svcCtx *svc.ServiceContext
+}
+
+func NewCreatestaticagentLogic(ctx context.Context, svcCtx *svc.ServiceContext) *CreatestaticagentLogic {
+	return &CreatestaticagentLogic{
+		Logger: logx.WithContext(ctx),
+		ctx:    ctx,
+		svcCtx: svcCtx,
+	}
+}
+
+func (l *CreatestaticagentLogic) Createstaticagent(req *types.StaticAgentReq) (resp *types.Response, err error) {
+	if err = svc.RequireAdmin(l.ctx, "只有管理员可以管理静态agent"); err != nil {
+		return
+	}
+	if err = validate(l.svcCtx, req); err != nil {
+		return
+	}
+	staticAgent := commontypes.StaticAgent{
+		ServiceKey: req.ServiceKey,
+		Address:    req.Address,
+		UpdateAt:   time.Now(),
+	}
+	if err = l.svcCtx.Sqlite.WithContext(context.WithValue(l.ctx, commontypes.TraceIDKey{}, "sqlite.CreateStaticAgent")).Create(&staticAgent).Error; err != nil {
+		l.Logger.Error(err)
+		return nil, errorx.NewDefaultError("Failed to create static agent: %v", err)
+	}
+	if err = l.svcCtx.StaticAgents.Sync(l.ctx); err != nil {
+		l.Logger.Error(err)
+		return
+	}
+	l.Logger.Infof("Create static agent %s at %s", staticAgent.ServiceKey, staticAgent.Address)
+	resp = &types.Response{
+		Code:    http.StatusOK,
+		Data:    staticAgent,
+		Message: "静态agent创建成功",
+	}
+	return
+}
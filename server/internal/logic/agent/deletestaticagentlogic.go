@@ -0,0 +1,51 @@
+package agent
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/hongyuxuan/lizardcd/common/errorx"
+	commontypes "github.com/hongyuxuan/lizardcd/common/types"
+	"github.com/hongyuxuan/lizardcd/server/internal/svc"
+	"github.com/hongyuxuan/lizardcd/server/internal/types"
+
+	"github.com/zeromicro/go-zero/core/logx"
+)
+
+type DeletestaticagentLogic struct {
+	logx.Logger
+	ctx    context.Context
+	svcCtx *svc.ServiceContext
+}
+
+func NewDeletestaticagentLogic(ctx context.Context, svcCtx *svc.ServiceContext) *DeletestaticagentLogic {
+	return &DeletestaticagentLogic{
+		Logger: logx.WithContext(ctx),
+		ctx:    ctx,
+		svcCtx: svcCtx,
+	}
+}
+
+func (l *DeletestaticagentLogic) Deletestaticagent(req *types.StaticAgentIdReq) (resp *types.Response, err error) {
+	if err = svc.RequireAdmin(l.ctx, "只有管理员可以管理静态agent"); err != nil {
+		return
+	}
+	res := l.svcCtx.Sqlite.WithContext(context.WithValue(l.ctx, commontypes.TraceIDKey{}, "sqlite.DeleteStaticAgent")).Where("id = ?", req.Id).Delete(&commontypes.StaticAgent{})
+	if err = res.Error; err != nil {
+		l.Logger.Error(err)
+		return
+	}
+	if res.RowsAffected == 0 {
+		return nil, errorx.NewError(http.StatusNotFound, "静态agent不存在", nil)
+	}
+	if err = l.svcCtx.StaticAgents.Sync(l.ctx); err != nil {
+		l.Logger.Error(err)
+		return
+	}
+	l.Logger.Infof("Delete static agent id=%d", req.Id)
+	resp = &types.Response{
+		Code:    http.StatusOK,
+		Message: "静态agent删除成功",
+	}
+	return
+}
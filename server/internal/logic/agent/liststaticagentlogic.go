@@ -0,0 +1,41 @@
+package agent
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/hongyuxuan/lizardcd/server/internal/svc"
+	"github.com/hongyuxuan/lizardcd/server/internal/types"
+
+	"github.com/zeromicro/go-zero/core/logx"
+)
+
+type ListstaticagentLogic struct {
+	logx.Logger
+	ctx    context.Context
+	svcCtx *svc.ServiceContext
+}
+
+func NewListstaticagentLogic(ctx context.Context, svcCtx *svc.ServiceContext) *ListstaticagentLogic {
+	return &ListstaticagentLogic{
+		Logger: logx.WithContext(ctx),
+		ctx:    ctx,
+		svcCtx: svcCtx,
+	}
+}
+
+func (l *ListstaticagentLogic) Liststaticagent() (resp *types.Response, err error) {
+	if err = svc.RequireAdmin(l.ctx, "只有管理员可以管理静态agent"); err != nil {
+		return
+	}
+	agents, err := l.svcCtx.StaticAgents.List(l.ctx)
+	if err != nil {
+		l.Logger.Error(err)
+		return
+	}
+	resp = &types.Response{
+		Code: http.StatusOK,
+		Data: agents,
+	}
+	return
+}
@@ -0,0 +1,63 @@
+package agent
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/hongyuxuan/lizardcd/common/errorx"
+	commontypes "github.com/hongyuxuan/lizardcd/common/types"
+	"github.com/hongyuxuan/lizardcd/server/internal/svc"
+	"github.com/hongyuxuan/lizardcd/server/internal/types"
+
+	"github.com/zeromicro/go-zero/core/logx"
+)
+
+type UpdatestaticagentLogic struct {
+	logx.Logger
+	ctx    context.Context
+	svcCtx *svc.ServiceContext
+}
+
+func NewUpdatestaticagentLogic(ctx context.Context, svcCtx *svc.ServiceContext) *UpdatestaticagentLogic {
+	return &UpdatestaticagentLogic{
+		Logger: logx.WithContext(ctx),
+		ctx:    ctx,
+		svcCtx: svcCtx,
+	}
+}
+
+func (l *UpdatestaticagentLogic) Updatestaticagent(req *types.StaticAgentReq) (resp *types.Response, err error) {
+	if err = svc.RequireAdmin(l.ctx, "只有管理员可以管理静态agent"); err != nil {
+		return
+	}
+	if err = validate(l.svcCtx, req); err != nil {
+		return
+	}
+	staticAgent := commontypes.StaticAgent{
+		Id:         req.Id,
+		ServiceKey: req.ServiceKey,
+		Address:    req.Address,
+		UpdateAt:   time.Now(),
+	}
+	res := l.svcCtx.Sqlite.WithContext(context.WithValue(l.ctx, commontypes.TraceIDKey{}, "sqlite.UpdateStaticAgent")).
+		Model(&commontypes.StaticAgent{}).Where("id = ?", req.Id).Select("service_key", "address", "update_at").Updates(&staticAgent)
+	if err = res.Error; err != nil {
+		l.Logger.Error(err)
+		return nil, errorx.NewDefaultError("Failed to update static agent: %v", err)
+	}
+	if res.RowsAffected == 0 {
+		return nil, errorx.NewError(http.StatusNotFound, "静态agent不存在", nil)
+	}
+	if err = l.svcCtx.StaticAgents.Sync(l.ctx); err != nil {
+		l.Logger.Error(err)
+		return
+	}
+	l.Logger.Infof("Update static agent id=%d %s at %s", staticAgent.Id, staticAgent.ServiceKey, staticAgent.Address)
+	resp = &types.Response{
+		Code:    http.StatusOK,
+		Data:    staticAgent,
+		Message: "静态agent更新成功",
+	}
+	return
+}
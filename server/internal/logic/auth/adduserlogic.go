@@ -5,6 +5,7 @@ import (
 	"net/http"
 
 	"github.com/hongyuxuan/lizardcd/common/errorx"
+	commontypes "github.com/hongyuxuan/lizardcd/common/types"
 	"github.com/hongyuxuan/lizardcd/common/utils"
 	"github.com/hongyuxuan/lizardcd/server/internal/svc"
 	"github.com/hongyuxuan/lizardcd/server/internal/types"
@@ -35,6 +36,12 @@ func (l *AdduserLogic) Adduser(req *types.AddUserReq) (resp *types.Response, err
 	} else {
 		l.Logger.Infof("Successfully create user \"%s\" with password \"\"", req.Username, generatedPassword)
 	}
+	if len(req.Groups) > 0 {
+		if err = l.svcCtx.Sqlite.Model(&commontypes.User{}).Where("username = ?", req.Username).Update("groups", commontypes.StringList(req.Groups)).Error; err != nil {
+			l.Logger.Error(err)
+			return
+		}
+	}
 	// add user must also add related settings
 	utils.AddSettings(req.Tenant, l.svcCtx.Sqlite)
 
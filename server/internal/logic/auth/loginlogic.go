@@ -3,15 +3,21 @@ package auth
 import (
 	"context"
 	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
 	"strings"
 	"time"
 
 	"github.com/golang-jwt/jwt/v4"
+	"github.com/hongyuxuan/lizardcd/common/constant"
+	"github.com/hongyuxuan/lizardcd/common/errorx"
 	commontypes "github.com/hongyuxuan/lizardcd/common/types"
 	"github.com/hongyuxuan/lizardcd/common/utils"
 	"github.com/hongyuxuan/lizardcd/server/internal/svc"
 	"github.com/hongyuxuan/lizardcd/server/internal/types"
 	"github.com/samber/lo"
+	"gorm.io/gorm"
 
 	"github.com/zeromicro/go-zero/core/logx"
 )
@@ -22,11 +28,6 @@ type LoginLogic struct {
 	svcCtx *svc.ServiceContext
 }
 
-type NsCluster struct {
-	Namespace string `json:"namespace"`
-	Cluster   string `json:"cluster"`
-}
-
 func NewLoginLogic(ctx context.Context, svcCtx *svc.ServiceContext) *LoginLogic {
 	return &LoginLogic{
 		Logger: logx.WithContext(ctx),
@@ -36,30 +37,67 @@ func NewLoginLogic(ctx context.Context, svcCtx *svc.ServiceContext) *LoginLogic
 }
 
 func (l *LoginLogic) Login(req *types.LoginReq) (resp *commontypes.LoginRes, err error) {
+	// local users with passwords, e.g. admin, are always authenticated locally, so a ldap user of the same name never logins as them
+	var locals int64
+	if err = l.svcCtx.Sqlite.Model(&commontypes.User{}).Where("username = ? AND source = ? AND password <> ''", req.Username, constant.USER_SOURCE_LOCAL).Count(&locals).Error; err != nil {
+		l.Logger.Error(err)
+		return
+	}
+	if l.svcCtx.Ldap.Enabled() && locals == 0 {
+		var identity *svc.UserIdentity
+		identity, err = l.svcCtx.Ldap.Authenticate(req.Username, req.Password)
+		switch {
+		case err == nil:
+			role, tenant, matched := l.svcCtx.Ldap.MapUser(identity.Groups)
+			var username string
+			if username, err = l.syncUser(identity, role, tenant, matched, true); err != nil {
+				return
+			}
+			l.Logger.Infof("user \"%s\" login by LDAP success", req.Username)
+			return l.IssueToken(username)
+		case svc.IsLdapUnavailable(err):
+			// users in the user table can still login when the ldap server is unreachable,
+			// others are told that ldap is unavailable rather than a wrong password
+			l.Logger.Errorf("user \"%s\" falls back to local login since LDAP is unavailable: %v", req.Username, err)
+			if utils.ValidatedUser(req.Username, req.Password, l.svcCtx.Sqlite) != nil {
+				return
+			}
+			err = nil
+			l.Logger.Infof("user \"%s\" login success", req.Username)
+			return l.IssueToken(req.Username)
+		default:
+			l.Logger.Infof("user \"%s\" failed to login by LDAP: %v", req.Username, err)
+			return
+		}
+	}
 	if err = utils.ValidatedUser(req.Username, req.Password, l.svcCtx.Sqlite); err != nil {
 		return
 	}
 	l.Logger.Infof("user \"%s\" login success", req.Username)
+	return l.IssueToken(req.Username)
+}
 
+// IssueToken returns the jwt token of an authenticated user
+func (l *LoginLogic) IssueToken(username string) (resp *commontypes.LoginRes, err error) {
 	// get user info
 	var user struct {
 		commontypes.User
 		Namespaces string
 	}
-	if err = l.svcCtx.Sqlite.Model(&commontypes.User{}).Select("user.*,tenant.namespaces").Joins("left join tenant on user.tenant = tenant.tenant_name").Where("username = ?", req.Username).First(&user).Error; err != nil {
+	if err = l.svcCtx.Sqlite.Model(&commontypes.User{}).Select("user.*,tenant.namespaces").Joins("left join tenant on user.tenant = tenant.tenant_name").Where("username = ?", username).First(&user).Error; err != nil {
 		l.Logger.Error(err)
 		return
 	}
-	var nscluster []NsCluster
+	var nscluster []commontypes.NsCluster
 	if err = json.Unmarshal([]byte(user.Namespaces), &nscluster); err != nil {
 		l.Logger.Error(err)
 		return
 	}
-	ns := lo.Map(nscluster, func(item NsCluster, _ int) string {
+	ns := lo.Map(nscluster, func(item commontypes.NsCluster, _ int) string {
 		return item.Namespace
 	})
 	payloads := map[string]interface{}{
-		"username":  req.Username,
+		"username":  username,
 		"role":      user.Role,
 		"tenant":    user.Tenant,
 		"namespace": strings.Join(ns, ","),
@@ -79,6 +117,56 @@ func (l *LoginLogic) Login(req *types.LoginReq) (resp *commontypes.LoginRes, err
 	return
 }
 
+// syncUser finds the user by the source and subject of an identity provider, or creates it if not existed,
+// then updates its groups, and its role and tenant if matched by a group mapping.
+// Users of other sources, e.g. local users, are never linked or changed even if their usernames are the same.
+func (l *LoginLogic) syncUser(identity *svc.UserIdentity, role, tenant string, matched, autoCreate bool) (username string, err error) {
+	var user commontypes.User
+	err = l.svcCtx.Sqlite.Where("source = ? AND subject = ?", identity.Source, identity.Subject).First(&user).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		var count int64
+		if err = l.svcCtx.Sqlite.Model(&commontypes.User{}).Where("username = ?", identity.Username).Count(&count).Error; err != nil {
+			return
+		}
+		if count > 0 {
+			return "", errorx.NewError(http.StatusForbidden, fmt.Sprintf("用户名\"%s\"已被其他账号使用", identity.Username), nil)
+		}
+		if !autoCreate || role == "" || tenant == "" {
+			return "", errorx.NewError(http.StatusForbidden, fmt.Sprintf("用户\"%s\"不存在", identity.Username), nil)
+		}
+		if err = l.svcCtx.Sqlite.Where("tenant_name = ?", tenant).First(&commontypes.Tenant{}).Error; err != nil {
+			return "", errorx.NewError(http.StatusForbidden, fmt.Sprintf("租户\"%s\"不存在", tenant), nil)
+		}
+		// the user has no password, so it can only login by the identity provider
+		user = commontypes.User{
+			Username: identity.Username,
+			Role:     role,
+			Tenant:   tenant,
+			Groups:   commontypes.StringList(identity.Groups),
+			Source:   identity.Source,
+			Subject:  identity.Subject,
+			UpdateAt: time.Now(),
+		}
+		if err = l.svcCtx.Sqlite.Create(&user).Error; err != nil {
+			return "", errorx.NewDefaultError("Failed to create user \"%s\": %v", identity.Username, err)
+		}
+		utils.AddSettings(tenant, l.svcCtx.Sqlite)
+		l.Logger.Infof("Successfully create %s user \"%s\" with role=%s tenant=%s", identity.Source, identity.Username, role, tenant)
+	} else if err != nil {
+		return
+	}
+
+	// groups, role and tenant are synced from the identity provider at every login
+	updates := map[string]interface{}{
+		"groups": commontypes.StringList(identity.Groups),
+	}
+	if matched {
+		updates["role"] = role
+		updates["tenant"] = tenant
+	}
+	return user.Username, l.svcCtx.Sqlite.Model(&commontypes.User{}).Where("id = ?", user.Id).Updates(updates).Error
+}
+
 func (l *LoginLogic) getToken(iat int64, payloads map[string]interface{}, seconds int64) (string, error) {
 	claims := make(jwt.MapClaims)
 	claims["exp"] = iat + seconds
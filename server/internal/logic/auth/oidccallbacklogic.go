@@ -0,0 +1,64 @@
+package auth
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+
+	"github.com/hongyuxuan/lizardcd/common/errorx"
+	commontypes "github.com/hongyuxuan/lizardcd/common/types"
+	"github.com/hongyuxuan/lizardcd/server/internal/svc"
+	"github.com/hongyuxuan/lizardcd/server/internal/types"
+
+	"github.com/zeromicro/go-zero/core/logx"
+)
+
+type OidccallbackLogic struct {
+	logx.Logger
+	ctx    context.Context
+	svcCtx *svc.ServiceContext
+}
+
+func NewOidccallbackLogic(ctx context.Context, svcCtx *svc.ServiceContext) *OidccallbackLogic {
+	return &OidccallbackLogic{
+		Logger: logx.WithContext(ctx),
+		ctx:    ctx,
+		svcCtx: svcCtx,
+	}
+}
+
+// Oidccallback returns the url of ui with the jwt token or the error in its fragment, stateCookie is the value of the state cookie set by Oidclogin
+func (l *OidccallbackLogic) Oidccallback(req *types.OidcCallbackReq, stateCookie string) string {
+	values := url.Values{}
+	if resp, err := l.login(req, stateCookie); err != nil {
+		l.Logger.Error(err)
+		values.Set("error", err.Error())
+	} else {
+		values.Set("access_token", resp.AccessToken)
+		values.Set("access_expire", fmt.Sprint(resp.AccessExpire))
+		values.Set("refresh_after", fmt.Sprint(resp.RefreshAfter))
+	}
+	return l.svcCtx.Oidc.UiRedirectUrl() + "#" + values.Encode()
+}
+
+func (l *OidccallbackLogic) login(req *types.OidcCallbackReq, stateCookie string) (resp *commontypes.LoginRes, err error) {
+	if !l.svcCtx.Oidc.Enabled() {
+		return nil, errorx.NewError(http.StatusNotFound, "未启用OIDC单点登录", nil)
+	}
+	if req.Error != "" {
+		return nil, errorx.NewError(http.StatusUnauthorized, fmt.Sprintf("OIDC登录失败: %s %s", req.Error, req.ErrorDescription), nil)
+	}
+	identity, err := l.svcCtx.Oidc.Exchange(l.ctx, req.Code, req.State, stateCookie)
+	if err != nil {
+		return
+	}
+	role, tenant, matched := l.svcCtx.Oidc.MapUser(identity.Groups)
+	login := NewLoginLogic(l.ctx, l.svcCtx)
+	username, err := login.syncUser(identity, role, tenant, matched, l.svcCtx.Oidc.AutoCreateUser())
+	if err != nil {
+		return
+	}
+	l.Logger.Infof("user \"%s\" login by OIDC success", username)
+	return login.IssueToken(username)
+}
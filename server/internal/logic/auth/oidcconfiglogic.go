@@ -0,0 +1,35 @@
+package auth
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/hongyuxuan/lizardcd/server/internal/svc"
+	"github.com/hongyuxuan/lizardcd/server/internal/types"
+
+	"github.com/zeromicro/go-zero/core/logx"
+)
+
+type OidcconfigLogic struct {
+	logx.Logger
+	ctx    context.Context
+	svcCtx *svc.ServiceContext
+}
+
+func NewOidcconfigLogic(ctx context.Context, svcCtx *svc.ServiceContext) *OidcconfigLogic {
+	return &OidcconfigLogic{
+		Logger: logx.WithContext(ctx),
+		ctx:    ctx,
+		svcCtx: svcCtx,
+	}
+}
+
+func (l *OidcconfigLogic) Oidcconfig() (resp *types.Response, err error) {
+	resp = &types.Response{
+		Code: http.StatusOK,
+		Data: map[string]interface{}{
+			"enabled": l.svcCtx.Oidc.Enabled(),
+		},
+	}
+	return
+}
@@ -0,0 +1,36 @@
+package auth
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/hongyuxuan/lizardcd/common/errorx"
+	"github.com/hongyuxuan/lizardcd/server/internal/svc"
+
+	"github.com/zeromicro/go-zero/core/logx"
+)
+
+type OidcloginLogic struct {
+	logx.Logger
+	ctx    context.Context
+	svcCtx *svc.ServiceContext
+}
+
+func NewOidcloginLogic(ctx context.Context, svcCtx *svc.ServiceContext) *OidcloginLogic {
+	return &OidcloginLogic{
+		Logger: logx.WithContext(ctx),
+		ctx:    ctx,
+		svcCtx: svcCtx,
+	}
+}
+
+// Oidclogin returns the url of the provider to login and the state cookie which is checked by the callback
+func (l *OidcloginLogic) Oidclogin() (url string, cookie *http.Cookie, err error) {
+	if !l.svcCtx.Oidc.Enabled() {
+		return "", nil, errorx.NewError(http.StatusNotFound, "未启用OIDC单点登录", nil)
+	}
+	if url, cookie, err = l.svcCtx.Oidc.AuthCodeURL(l.ctx); err != nil {
+		l.Logger.Error(err)
+	}
+	return
+}
@@ -30,6 +30,18 @@ func NewCreatedataLogic(ctx context.Context, svcCtx *svc.ServiceContext) *Create
 }
 
 func (l *CreatedataLogic) Createdata(req *types.CreateDataReq) (resp *types.Response, err error) {
+	if err = checkWritable(l.ctx, req.Tablename); err != nil {
+		return
+	}
+	if err = setTenant(l.ctx, req.Tablename, req.Body); err != nil {
+		return
+	}
+	if err = checkApplication(l.ctx, l.svcCtx, req.Tablename, req.Body); err != nil {
+		return
+	}
+	if err = svc.EncryptSecrets(req.Tablename, req.Body, nil); err != nil {
+		return
+	}
 	if err = l.svcCtx.Sqlite.WithContext(context.WithValue(l.ctx, commontypes.TraceIDKey{}, "sqlite.CreateData")).Table(req.Tablename).Create(&req.Body).Error; err != nil {
 		return
 	}
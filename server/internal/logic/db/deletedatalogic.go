@@ -9,6 +9,7 @@ import (
 	"github.com/hongyuxuan/lizardcd/server/internal/types"
 
 	"github.com/zeromicro/go-zero/core/logx"
+	"gorm.io/gorm"
 )
 
 type DeletedataLogic struct {
@@ -26,8 +27,17 @@ func NewDeletedataLogic(ctx context.Context, svcCtx *svc.ServiceContext) *Delete
 }
 
 func (l *DeletedataLogic) Deletedata(req *types.DataByIdReq) (resp *types.Response, err error) {
+	if err = checkWritable(l.ctx, req.Tablename); err != nil {
+		return
+	}
+	// users other than admin cannot delete the rows of other tenants
 	data := map[string]interface{}{}
-	if err = l.svcCtx.Sqlite.WithContext(context.WithValue(l.ctx, commontypes.TraceIDKey{}, "sqlite.DeleteData")).Table(req.Tablename).Where("id = ?", req.Id).Delete(&data).Error; err != nil {
+	tx := byId(l.ctx, l.svcCtx.Sqlite.WithContext(context.WithValue(l.ctx, commontypes.TraceIDKey{}, "sqlite.DeleteData")), req.Tablename, req.Id).Delete(&data)
+	if err = tx.Error; err != nil {
+		return
+	}
+	if tx.RowsAffected == 0 {
+		err = notFound(gorm.ErrRecordNotFound)
 		return
 	}
 	resp = &types.Response{
@@ -0,0 +1,71 @@
+package db
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/hongyuxuan/lizardcd/common/errorx"
+	commontypes "github.com/hongyuxuan/lizardcd/common/types"
+	"github.com/hongyuxuan/lizardcd/common/utils"
+	"github.com/hongyuxuan/lizardcd/server/internal/svc"
+
+	"github.com/zeromicro/go-zero/core/logx"
+)
+
+const exportBatchSize = 500
+
+type ExportdataLogic struct {
+	logx.Logger
+	ctx    context.Context
+	svcCtx *svc.ServiceContext
+}
+
+func NewExportdataLogic(ctx context.Context, svcCtx *svc.ServiceContext) *ExportdataLogic {
+	return &ExportdataLogic{
+		Logger: logx.WithContext(ctx),
+		ctx:    ctx,
+		svcCtx: svcCtx,
+	}
+}
+
+// Exportdata writes all the rows matching search/filter/range/sort as JSON lines, page and size are ignored
+func (l *ExportdataLogic) Exportdata(req *commontypes.GetDataReq, w http.ResponseWriter) (err error) {
+	if req.Tablename != "audit_log" {
+		return errorx.NewError(http.StatusBadRequest, fmt.Sprintf("不支持导出表%s", req.Tablename), nil)
+	}
+	_, role, tenant, _ := utils.GetPayload(l.ctx)
+	if req.Sort == "" {
+		req.Sort = "id"
+	}
+	req.Size = exportBatchSize
+	enc := json.NewEncoder(w)
+	for req.Page = 1; ; req.Page++ {
+		var data []commontypes.AuditLog
+		var count int64
+		tx := l.svcCtx.Sqlite.WithContext(context.WithValue(l.ctx, commontypes.TraceIDKey{}, "sqlite.ExportAuditLog")).Model(commontypes.AuditLog{})
+		utils.SetTx(tx, &count, req, role, tenant)
+		if err = tx.Find(&data).Error; err != nil {
+			if req.Page == 1 {
+				return
+			}
+			// the response has been partly written, so the error cannot be returned
+			l.Logger.Error(err)
+			return nil
+		}
+		if req.Page == 1 {
+			w.Header().Set("Content-Type", "application/x-ndjson")
+			w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%s.jsonl", req.Tablename))
+		}
+		for _, row := range data {
+			if err = enc.Encode(row); err != nil {
+				l.Logger.Error(err)
+				return nil
+			}
+		}
+		if len(data) < exportBatchSize {
+			return
+		}
+	}
+}
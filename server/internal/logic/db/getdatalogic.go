@@ -33,12 +33,13 @@ func (l *GetdataLogic) Getdata(req *types.DataByIdReq) (resp *types.Response, er
 		var taskHistory commontypes.TaskHistory
 		tx := l.svcCtx.Sqlite.WithContext(context.WithValue(l.ctx, commontypes.TraceIDKey{}, "sqlite.GetTaskHistory"))
 		if role != constant.ROLE_ADMIN {
-			tx.Where("tenant = ?", tenant)
+			tx = tx.Where("tenant = ?", tenant)
 		}
 		if err = tx.Preload("TaskHistoryWorkloads").First(&taskHistory, "id = ?", req.Id).Error; err != nil {
 			l.Logger.Error(err)
 			return
 		}
+		svc.MaskTaskHistory(&taskHistory)
 		resp = &types.Response{
 			Code: http.StatusOK,
 			Data: taskHistory,
@@ -33,7 +33,7 @@ func (l *ListdataLogic) Listdata(req *commontypes.GetDataReq) (resp *types.Respo
 		return l.list(l.svcCtx.Sqlite.WithContext(context.WithValue(l.ctx, commontypes.TraceIDKey{}, "sqlite.ListApplication")).Model(commontypes.Application{}), data, req)
 	} else if req.Tablename == "user" {
 		var data []commontypes.User
-		tx := l.svcCtx.Sqlite.WithContext(context.WithValue(l.ctx, commontypes.TraceIDKey{}, "sqlite.ListUser")).Model(&commontypes.User{}).Select("id", "username", "role", "tenant", "update_at")
+		tx := l.svcCtx.Sqlite.WithContext(context.WithValue(l.ctx, commontypes.TraceIDKey{}, "sqlite.ListUser")).Model(&commontypes.User{}).Select("id", "username", "role", "tenant", "groups", "update_at")
 		return l.list(tx, data, req)
 	} else if req.Tablename == "task_history" {
 		var data []commontypes.TaskHistory
@@ -42,6 +42,18 @@ func (l *ListdataLogic) Listdata(req *commontypes.GetDataReq) (resp *types.Respo
 			tx.Preload("TaskHistoryWorkloads")
 		}
 		return l.list(tx, data, req)
+	} else if req.Tablename == "scheduled_task" {
+		var data []commontypes.ScheduledTask
+		return l.list(l.svcCtx.Sqlite.WithContext(context.WithValue(l.ctx, commontypes.TraceIDKey{}, "sqlite.ListScheduledTask")).Model(commontypes.ScheduledTask{}), data, req)
+	} else if req.Tablename == "image_update" {
+		var data []commontypes.ImageUpdate
+		return l.list(l.svcCtx.Sqlite.WithContext(context.WithValue(l.ctx, commontypes.TraceIDKey{}, "sqlite.ListImageUpdate")).Model(commontypes.ImageUpdate{}), data, req)
+	} else if req.Tablename == "notify_channel" {
+		var data []commontypes.NotifyChannel
+		return l.list(l.svcCtx.Sqlite.WithContext(context.WithValue(l.ctx, commontypes.TraceIDKey{}, "sqlite.ListNotifyChannel")).Model(commontypes.NotifyChannel{}), data, req)
+	} else if req.Tablename == "audit_log" {
+		var data []commontypes.AuditLog
+		return l.list(l.svcCtx.Sqlite.WithContext(context.WithValue(l.ctx, commontypes.TraceIDKey{}, "sqlite.ListAuditLog")).Model(commontypes.AuditLog{}), data, req)
 	} else if req.Tablename == "helm_repositories" {
 		var data []commontypes.HelmRepositories
 		return l.list(l.svcCtx.Sqlite.WithContext(context.WithValue(l.ctx, commontypes.TraceIDKey{}, "sqlite.ListHelmRepositories")).Model(commontypes.HelmRepositories{}), data, req)
@@ -59,6 +71,7 @@ func (l *ListdataLogic) Listdata(req *commontypes.GetDataReq) (resp *types.Respo
 		if data == nil {
 			data = []map[string]interface{}{}
 		}
+		svc.MaskSecrets(req.Tablename, data)
 		resp = &types.Response{
 			Code: http.StatusOK,
 			Data: map[string]interface{}{
@@ -78,6 +91,7 @@ func (l *ListdataLogic) list(tx *gorm.DB, models any, req *commontypes.GetDataRe
 		l.Logger.Error(err)
 		return
 	}
+	maskSecrets(models)
 	resp = &types.Response{
 		Code: http.StatusOK,
 		Data: map[string]interface{}{
@@ -87,3 +101,26 @@ func (l *ListdataLogic) list(tx *gorm.DB, models any, req *commontypes.GetDataRe
 	}
 	return
 }
+
+// maskSecrets masks the credentials of models read by list
+func maskSecrets(models any) {
+	switch data := models.(type) {
+	case []commontypes.Application:
+		for i := range data {
+			svc.MaskApplication(&data[i])
+		}
+	case []commontypes.TaskHistory:
+		for i := range data {
+			svc.MaskTaskHistory(&data[i])
+		}
+	case []commontypes.NotifyChannel:
+		for i := range data {
+			data[i].Secret = utils.MaskSecret(data[i].Secret)
+			data[i].SmtpPassword = utils.MaskSecret(data[i].SmtpPassword)
+		}
+	case []commontypes.HelmRepositories:
+		for i := range data {
+			data[i].Password = utils.MaskSecret(data[i].Password)
+		}
+	}
+}
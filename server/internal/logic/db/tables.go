@@ -0,0 +1,106 @@
+package db
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/hongyuxuan/lizardcd/common/constant"
+	"github.com/hongyuxuan/lizardcd/common/errorx"
+	commontypes "github.com/hongyuxuan/lizardcd/common/types"
+	"github.com/hongyuxuan/lizardcd/common/utils"
+	"github.com/hongyuxuan/lizardcd/server/internal/svc"
+	"gorm.io/gorm"
+)
+
+// checkWritable returns 403 for the tables which cannot be modified by the generic db apis
+func checkWritable(ctx context.Context, tablename string) error {
+	switch tablename {
+	case "audit_log":
+		return errorx.NewError(http.StatusForbidden, "审计日志不可修改", nil)
+	case "rbac_policy":
+		return errorx.NewError(http.StatusForbidden, "请使用/lizardcd/rbac接口管理权限策略", nil)
+	case "api_token":
+		return errorx.NewError(http.StatusForbidden, "请使用/lizardcd/token接口管理API令牌", nil)
+	case "static_agent":
+		return errorx.NewError(http.StatusForbidden, "请使用/lizardcd/agent接口管理静态agent", nil)
+	case "scheduled_task":
+		return errorx.NewError(http.StatusForbidden, "请使用/lizardcd/task/schedule接口管理定时任务", nil)
+	case "image_update":
+		return errorx.NewError(http.StatusForbidden, "镜像更新记录不可修改", nil)
+	case "tenant", "settings":
+		// namespaces of tenants decide what users of the tenants can access
+		return svc.RequireAdmin(ctx, "只有管理员可以修改租户和系统设置")
+	case "user":
+		// role, groups and source of users decide their permissions
+		return svc.RequireAdmin(ctx, "只有管理员可以修改用户")
+	}
+	return nil
+}
+
+// byId selects the row of id in tablename, users other than admin only select the rows of their tenant
+func byId(ctx context.Context, tx *gorm.DB, tablename, id string) *gorm.DB {
+	tx = tx.Table(tablename).Where("id = ?", id)
+	if _, role, tenant, _ := utils.GetPayload(ctx); role != constant.ROLE_ADMIN && utils.HasTenant(tablename) {
+		tx = tx.Where("tenant = ?", tenant)
+	}
+	return tx
+}
+
+// notFound returns 404 if err is gorm.ErrRecordNotFound, or err itself
+func notFound(err error) error {
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return errorx.NewError(http.StatusNotFound, "数据不存在", nil)
+	}
+	return err
+}
+
+// setTenant sets the tenant of a row written by the generic db apis to the tenant of a user other than admin,
+// e.g. notify channels of a tenant receive the task events of the tenant
+func setTenant(ctx context.Context, tablename string, body map[string]interface{}) error {
+	_, role, tenant, _ := utils.GetPayload(ctx)
+	if role == constant.ROLE_ADMIN || !utils.HasTenant(tablename) {
+		return nil
+	}
+	if t, ok := body["tenant"].(string); ok && t != "" && t != tenant {
+		return errorx.NewError(http.StatusForbidden, fmt.Sprintf("不能设置其它租户\"%s\"的数据", t), nil)
+	}
+	body["tenant"] = tenant
+	return nil
+}
+
+// checkApplication checks the workload namespaces of an application written by the generic db apis.
+// Tasks triggered by webhook, scheduler and image updater deploy the workloads by the namespaces of the tenant of the application.
+func checkApplication(ctx context.Context, svcCtx *svc.ServiceContext, tablename string, body map[string]interface{}) error {
+	if tablename != "application" {
+		return nil
+	}
+	if _, role, _, _ := utils.GetPayload(ctx); role == constant.ROLE_ADMIN {
+		return nil
+	}
+	var workloads []commontypes.WorkLoad
+	switch v := body["workload"].(type) {
+	case nil:
+		return nil
+	case string:
+		if err := json.Unmarshal([]byte(v), &workloads); err != nil {
+			return errorx.NewError(http.StatusBadRequest, "workload格式不正确: "+err.Error(), nil)
+		}
+	default:
+		b, _ := json.Marshal(v)
+		if err := json.Unmarshal(b, &workloads); err != nil {
+			return errorx.NewError(http.StatusBadRequest, "workload格式不正确: "+err.Error(), nil)
+		}
+	}
+	for _, w := range workloads {
+		if w.Cluster == "" || w.Namespace == "" {
+			continue
+		}
+		if err := svcCtx.Rbac.CheckNamespace(ctx, w.Cluster, w.Namespace); err != nil {
+			return err
+		}
+	}
+	return nil
+}
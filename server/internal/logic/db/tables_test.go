@@ -0,0 +1,141 @@
+package db
+
+import (
+	"context"
+	"net/http"
+	"path/filepath"
+	"testing"
+
+	"github.com/hongyuxuan/lizardcd/common/constant"
+	"github.com/hongyuxuan/lizardcd/common/errorx"
+	commontypes "github.com/hongyuxuan/lizardcd/common/types"
+	"github.com/hongyuxuan/lizardcd/common/utils"
+	"github.com/hongyuxuan/lizardcd/server/internal/svc"
+	"github.com/hongyuxuan/lizardcd/server/internal/types"
+)
+
+func withPayloads(role, tenant string) context.Context {
+	return context.WithValue(context.Background(), "payloads", map[string]interface{}{
+		"username":  "alice",
+		"role":      role,
+		"tenant":    tenant,
+		"namespace": "",
+	})
+}
+
+// newTestServiceContext returns a service context with the application template "web" of tenant dev
+func newTestServiceContext(t *testing.T) *svc.ServiceContext {
+	db := utils.NewSQLite(filepath.Join(t.TempDir(), "lizardcd.db"), "error")
+	if err := db.AutoMigrate(&commontypes.ApplicationTemplate{}); err != nil {
+		t.Fatal(err)
+	}
+	if err := db.Create(&commontypes.ApplicationTemplate{Id: 1, Name: "web", Content: "dev", Tenant: "dev"}).Error; err != nil {
+		t.Fatal(err)
+	}
+	return &svc.ServiceContext{Sqlite: db}
+}
+
+func errorCode(err error) int {
+	if e, ok := err.(*errorx.LizardcdError); ok {
+		return e.Code
+	}
+	if err != nil {
+		return http.StatusInternalServerError
+	}
+	return http.StatusOK
+}
+
+func TestWriteOtherTenant(t *testing.T) {
+	tests := []struct {
+		name     string
+		role     string
+		tenant   string
+		wantCode int
+	}{
+		{"same tenant", constant.ROLE_READWRITE, "dev", http.StatusOK},
+		{"other tenant", constant.ROLE_READWRITE, "prod", http.StatusNotFound},
+		{"admin", constant.ROLE_ADMIN, "prod", http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svcCtx := newTestServiceContext(t)
+			ctx := withPayloads(tt.role, tt.tenant)
+			req := &types.UpdateDataReq{DataByIdReq: types.DataByIdReq{Tablename: "application_template", Id: "1"}, Body: map[string]interface{}{"content": "changed"}}
+			_, err := NewUpdatedataLogic(ctx, svcCtx).Updatedata(req)
+			if code := errorCode(err); code != tt.wantCode {
+				t.Fatalf("Updatedata() error = %v, want %d", err, tt.wantCode)
+			}
+			var template commontypes.ApplicationTemplate
+			svcCtx.Sqlite.First(&template, 1)
+			if changed := template.Content == "changed"; changed != (tt.wantCode == http.StatusOK) {
+				t.Errorf("content = %s after Updatedata() of %d", template.Content, tt.wantCode)
+			}
+
+			_, err = NewDeletedataLogic(ctx, svcCtx).Deletedata(&req.DataByIdReq)
+			if code := errorCode(err); code != tt.wantCode {
+				t.Fatalf("Deletedata() error = %v, want %d", err, tt.wantCode)
+			}
+			var count int64
+			svcCtx.Sqlite.Model(&commontypes.ApplicationTemplate{}).Count(&count)
+			if deleted := count == 0; deleted != (tt.wantCode == http.StatusOK) {
+				t.Errorf("%d rows after Deletedata() of %d", count, tt.wantCode)
+			}
+		})
+	}
+}
+
+func TestCheckWritable(t *testing.T) {
+	tests := []struct {
+		tablename string
+		role      string
+		wantErr   bool
+	}{
+		{"tenant", constant.ROLE_READWRITE, true},
+		{"tenant", constant.ROLE_ADMIN, false},
+		{"settings", constant.ROLE_READWRITE, true},
+		{"settings", constant.ROLE_ADMIN, false},
+		{"user", constant.ROLE_READWRITE, true},
+		{"user", constant.ROLE_ADMIN, false},
+		{"audit_log", constant.ROLE_ADMIN, true},
+		{"application", constant.ROLE_READWRITE, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.tablename+" by "+tt.role, func(t *testing.T) {
+			if err := checkWritable(withPayloads(tt.role, "dev"), tt.tablename); (err != nil) != tt.wantErr {
+				t.Errorf("checkWritable() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestWriteTenantOfNotifyChannel(t *testing.T) {
+	svcCtx := newTestServiceContext(t)
+	if err := svcCtx.Sqlite.AutoMigrate(&commontypes.NotifyChannel{}); err != nil {
+		t.Fatal(err)
+	}
+	ctx := withPayloads(constant.ROLE_READWRITE, "dev")
+	create := func(body map[string]interface{}) error {
+		_, err := NewCreatedataLogic(ctx, svcCtx).Createdata(&types.CreateDataReq{Tablename: "notify_channel", Body: body})
+		return err
+	}
+	if err := create(map[string]interface{}{"name": "other", "url": "https://example.com", "tenant": "prod"}); errorCode(err) != http.StatusForbidden {
+		t.Fatalf("Createdata() of other tenant error = %v, want 403", err)
+	}
+	if err := create(map[string]interface{}{"name": "own", "url": "https://example.com"}); err != nil {
+		t.Fatalf("Createdata() error = %v", err)
+	}
+	var channel commontypes.NotifyChannel
+	svcCtx.Sqlite.Where("name = ?", "own").First(&channel)
+	if channel.Tenant != "dev" {
+		t.Fatalf("tenant of created channel = %q, want dev", channel.Tenant)
+	}
+
+	req := &types.UpdateDataReq{DataByIdReq: types.DataByIdReq{Tablename: "notify_channel", Id: "1"}, Body: map[string]interface{}{"tenant": "prod"}}
+	if _, err := NewUpdatedataLogic(ctx, svcCtx).Updatedata(req); errorCode(err) != http.StatusForbidden {
+		t.Fatalf("Updatedata() to other tenant error = %v, want 403", err)
+	}
+	svcCtx.Sqlite.First(&channel, channel.Id)
+	if channel.Tenant != "dev" {
+		t.Errorf("tenant of updated channel = %q, want dev", channel.Tenant)
+	}
+}
@@ -30,7 +30,28 @@ func NewUpdatedataLogic(ctx context.Context, svcCtx *svc.ServiceContext) *Update
 }
 
 func (l *UpdatedataLogic) Updatedata(req *types.UpdateDataReq) (resp *types.Response, err error) {
-	if err = l.svcCtx.Sqlite.WithContext(context.WithValue(l.ctx, commontypes.TraceIDKey{}, "sqlite.UpdateData")).Table(req.Tablename).Where("id = ?", req.Id).Updates(req.Body).Error; err != nil {
+	if err = checkWritable(l.ctx, req.Tablename); err != nil {
+		return
+	}
+	if err = setTenant(l.ctx, req.Tablename, req.Body); err != nil {
+		return
+	}
+	if err = checkApplication(l.ctx, l.svcCtx, req.Tablename, req.Body); err != nil {
+		return
+	}
+	// users other than admin cannot update the rows of other tenants
+	old := map[string]interface{}{}
+	if err = byId(l.ctx, l.svcCtx.Sqlite.WithContext(context.WithValue(l.ctx, commontypes.TraceIDKey{}, "sqlite.GetData")), req.Tablename, req.Id).Take(&old).Error; err != nil {
+		err = notFound(err)
+		return
+	}
+	if svc.HasSecrets(req.Tablename) {
+		// masked credentials in body are kept as the old row
+		if err = svc.EncryptSecrets(req.Tablename, req.Body, old); err != nil {
+			return
+		}
+	}
+	if err = byId(l.ctx, l.svcCtx.Sqlite.WithContext(context.WithValue(l.ctx, commontypes.TraceIDKey{}, "sqlite.UpdateData")), req.Tablename, req.Id).Updates(req.Body).Error; err != nil {
 		return
 	}
 	// update application may also update a istio CRD
@@ -35,11 +35,16 @@ func (l *AddRepoLogic) AddRepo(req *types.AddRepoReq) (resp *types.Response, err
 		l.Logger.Error(err)
 		return
 	}
+	var password string
+	if password, err = utils.EncryptSecret(req.Password); err != nil {
+		l.Logger.Error(err)
+		return
+	}
 	if err = l.svcCtx.Sqlite.Create(&commontypes.HelmRepositories{
 		Name:     req.Name,
 		URL:      req.Url,
 		Username: req.Username,
-		Password: req.Password,
+		Password: password,
 		Tenant:   tenant,
 	}).Error; err != nil {
 		l.Logger.Error(err)
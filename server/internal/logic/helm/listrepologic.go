@@ -39,6 +39,9 @@ func (l *ListRepoLogic) ListRepo() (resp *types.Response, err error) {
 		l.Logger.Error(err)
 		return
 	}
+	for _, r := range res {
+		r.Password = utils.MaskSecret(r.Password)
+	}
 	resp = &types.Response{
 		Code: http.StatusOK,
 		Data: res,
@@ -40,6 +40,10 @@ func (l *UpdateChartsLogic) UpdateCharts(req *types.ListWorkloadReq) (resp *type
 	}
 	var entries []*repo.Entry
 	for _, r := range res {
+		if r.Password, err = utils.DecryptSecret(r.Password); err != nil {
+			l.Logger.Error(err)
+			return
+		}
 		entries = append(entries, &repo.Entry{
 			Name:     r.Name,
 			URL:      r.URL,
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"net/http"
 
+	"github.com/hongyuxuan/lizardcd/common/errorx"
 	"github.com/hongyuxuan/lizardcd/common/utils"
 	"github.com/hongyuxuan/lizardcd/server/internal/svc"
 	"github.com/hongyuxuan/lizardcd/server/internal/types"
@@ -32,7 +33,10 @@ func NewGetserviceLogic(ctx context.Context, svcCtx *svc.ServiceContext) *Getser
 }
 
 func (l *GetserviceLogic) Getservice(req *types.GetServiceReq) (resp *types.Response, err error) {
-	agent := l.svcCtx.AgentList[req.ServiceName]
+	agent, ok := l.svcCtx.AgentList.Get(req.ServiceName)
+	if !ok {
+		return nil, errorx.NewError(http.StatusNotFound, fmt.Sprintf("服务不存在: %s", req.ServiceName), nil)
+	}
 	if agent.ServiceSource == "etcd" {
 		var res *clientv3.GetResponse
 		if res, err = l.svcCtx.EtcdClient.Get(l.ctx, req.ServiceName, clientv3.WithPrefix()); err != nil {
@@ -65,6 +69,17 @@ func (l *GetserviceLogic) Getservice(req *types.GetServiceReq) (resp *types.Resp
 			Data: service,
 		}
 	}
+	if agent.ServiceSource == "tunnel" || agent.ServiceSource == "static" {
+		meta, _ := utils.GetServiceMata(l.svcCtx.Config.ServicePrefix, req.ServiceName)
+		resp = &types.Response{
+			Code: http.StatusOK,
+			Data: []map[string]interface{}{{
+				"ServiceID":   fmt.Sprintf("%s-%s", req.ServiceName, agent.Address),
+				"ServiceName": req.ServiceName,
+				"ServiceMeta": meta,
+			}},
+		}
+	}
 	if agent.ServiceSource == "nacos" {
 		var res model.Service
 		res, err = l.svcCtx.NacosClient.GetService(vo.GetServiceParam{
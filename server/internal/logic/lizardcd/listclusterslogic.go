@@ -69,10 +69,11 @@ func (l *ListclustersLogic) Listclusters(req *types.ListClusterReq) (resp *types
 }
 
 func (l *ListclustersLogic) getNamespaces(serviceName string) (res []string) {
-	if _, ok := l.svcCtx.AgentList[serviceName]; !ok {
+	agent, ok := l.svcCtx.AgentList.Get(serviceName)
+	if !ok {
 		return
 	}
-	rpcResponse, err := l.svcCtx.AgentList[serviceName].Client.GetNamespaces(l.ctx, &lizardagent.LabelSelector{LabelSelector: ""})
+	rpcResponse, err := agent.Client.GetNamespaces(l.ctx, &lizardagent.LabelSelector{LabelSelector: ""})
 	if err != nil {
 		l.Logger.Error(err)
 		return
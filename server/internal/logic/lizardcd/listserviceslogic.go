@@ -30,7 +30,7 @@ func NewListservicesLogic(ctx context.Context, svcCtx *svc.ServiceContext) *List
 func (l *ListservicesLogic) Listservices() (resp *types.Response, err error) {
 	_, role, _, namespaces := utils.GetPayload(l.ctx)
 	var services []map[string]string
-	for k, v := range l.svcCtx.AgentList {
+	l.svcCtx.AgentList.Range(func(k string, v *types.RpcAgent) bool {
 		meta, _ := utils.GetServiceMata(l.svcCtx.Config.ServicePrefix, k)
 		if _, ok := lo.Find(namespaces, func(s string) bool {
 			if meta != nil {
@@ -38,13 +38,14 @@ func (l *ListservicesLogic) Listservices() (resp *types.Response, err error) {
 			}
 			return false
 		}); !ok && role != constant.ROLE_ADMIN {
-			continue
+			return true
 		}
 		services = append(services, map[string]string{
 			"service_name":   k,
 			"service_source": v.ServiceSource,
 		})
-	}
+		return true
+	})
 	resp = &types.Response{
 		Code: http.StatusOK,
 		Data: services,
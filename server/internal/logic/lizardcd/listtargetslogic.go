@@ -28,13 +28,12 @@ func NewListtargetsLogic(ctx context.Context, svcCtx *svc.ServiceContext) *Listt
 func (l *ListtargetsLogic) Listtargets() (resp *types.Response, err error) {
 	_, role, _, namespaces := utils.GetPayload(l.ctx)
 	var targets []string
-	for k := range l.svcCtx.AgentList {
-		target, err := utils.GetTarget(l.svcCtx.Config.ServicePrefix, k, namespaces, role)
-		if err != nil {
-			continue
+	l.svcCtx.AgentList.Range(func(k string, _ *types.RpcAgent) bool {
+		if target, err := utils.GetTarget(l.svcCtx.Config.ServicePrefix, k, namespaces, role); err == nil {
+			targets = append(targets, target)
 		}
-		targets = append(targets, target)
-	}
+		return true
+	})
 	resp = &types.Response{
 		Code: http.StatusOK,
 		Data: targets,
@@ -0,0 +1,51 @@
+package rbac
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/hongyuxuan/lizardcd/common/errorx"
+	commontypes "github.com/hongyuxuan/lizardcd/common/types"
+	"github.com/hongyuxuan/lizardcd/server/internal/svc"
+	"github.com/hongyuxuan/lizardcd/server/internal/types"
+
+	"github.com/zeromicro/go-zero/core/logx"
+)
+
+type CreatepolicyLogic struct {
+	logx.Logger
+	ctx    context.Context
+	svcCtx *svc.ServiceContext
+}
+
+func NewCreatepolicyLogic(ctx context.Context, svcCtx *svc.ServiceContext) *CreatepolicyLogic {
+	return &CreatepolicyLogic{
+		Logger: logx.WithContext(ctx),
+		ctx:    ctx,
+		svcCtx: svcCtx,
+	}
+}
+
+func (l *CreatepolicyLogic) Createpolicy(req *types.RbacPolicyReq) (resp *types.Response, err error) {
+	if err = svc.RequireAdmin(l.ctx, "只有管理员可以管理权限策略"); err != nil {
+		return
+	}
+	policy := newPolicy(req)
+	policy.Id = 0
+	policy.UpdateAt = time.Now()
+	if err = svc.ValidatePolicy(policy); err != nil {
+		return
+	}
+	if err = l.svcCtx.Sqlite.WithContext(context.WithValue(l.ctx, commontypes.TraceIDKey{}, "sqlite.CreateRbacPolicy")).Create(&policy).Error; err != nil {
+		l.Logger.Error(err)
+		return nil, errorx.NewDefaultError("Failed to create rbac policy: %v", err)
+	}
+	l.Logger.Infof("Create rbac policy id=%d of %s \"%s\"", policy.Id, policy.SubjectType, policy.Subject)
+	resp = &types.Response{
+		Code:    http.StatusOK,
+		Data:    policy,
+		Message: "权限策略创建成功",
+	}
+	return
+}
@@ -0,0 +1,47 @@
+package rbac
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/hongyuxuan/lizardcd/common/errorx"
+	commontypes "github.com/hongyuxuan/lizardcd/common/types"
+	"github.com/hongyuxuan/lizardcd/server/internal/svc"
+	"github.com/hongyuxuan/lizardcd/server/internal/types"
+
+	"github.com/zeromicro/go-zero/core/logx"
+)
+
+type DeletepolicyLogic struct {
+	logx.Logger
+	ctx    context.Context
+	svcCtx *svc.ServiceContext
+}
+
+func NewDeletepolicyLogic(ctx context.Context, svcCtx *svc.ServiceContext) *DeletepolicyLogic {
+	return &DeletepolicyLogic{
+		Logger: logx.WithContext(ctx),
+		ctx:    ctx,
+		svcCtx: svcCtx,
+	}
+}
+
+func (l *DeletepolicyLogic) Deletepolicy(req *types.RbacPolicyIdReq) (resp *types.Response, err error) {
+	if err = svc.RequireAdmin(l.ctx, "只有管理员可以管理权限策略"); err != nil {
+		return
+	}
+	res := l.svcCtx.Sqlite.WithContext(context.WithValue(l.ctx, commontypes.TraceIDKey{}, "sqlite.DeleteRbacPolicy")).Where("id = ?", req.Id).Delete(&commontypes.RbacPolicy{})
+	if err = res.Error; err != nil {
+		l.Logger.Error(err)
+		return
+	}
+	if res.RowsAffected == 0 {
+		return nil, errorx.NewError(http.StatusNotFound, "权限策略不存在", nil)
+	}
+	l.Logger.Infof("Delete rbac policy id=%d", req.Id)
+	resp = &types.Response{
+		Code:    http.StatusOK,
+		Message: "权限策略删除成功",
+	}
+	return
+}
@@ -0,0 +1,49 @@
+package rbac
+
+import (
+	"context"
+	"net/http"
+
+	commontypes "github.com/hongyuxuan/lizardcd/common/types"
+	"github.com/hongyuxuan/lizardcd/server/internal/svc"
+	"github.com/hongyuxuan/lizardcd/server/internal/types"
+
+	"github.com/zeromicro/go-zero/core/logx"
+)
+
+type ListpolicyLogic struct {
+	logx.Logger
+	ctx    context.Context
+	svcCtx *svc.ServiceContext
+}
+
+func NewListpolicyLogic(ctx context.Context, svcCtx *svc.ServiceContext) *ListpolicyLogic {
+	return &ListpolicyLogic{
+		Logger: logx.WithContext(ctx),
+		ctx:    ctx,
+		svcCtx: svcCtx,
+	}
+}
+
+func (l *ListpolicyLogic) Listpolicy(req *types.ListRbacPolicyReq) (resp *types.Response, err error) {
+	if err = svc.RequireAdmin(l.ctx, "只有管理员可以管理权限策略"); err != nil {
+		return
+	}
+	tx := l.svcCtx.Sqlite.WithContext(context.WithValue(l.ctx, commontypes.TraceIDKey{}, "sqlite.ListRbacPolicy")).Model(&commontypes.RbacPolicy{})
+	if req.SubjectType != "" {
+		tx = tx.Where("subject_type = ?", req.SubjectType)
+	}
+	if req.Subject != "" {
+		tx = tx.Where("subject = ?", req.Subject)
+	}
+	policies := []commontypes.RbacPolicy{}
+	if err = tx.Order("id").Find(&policies).Error; err != nil {
+		l.Logger.Error(err)
+		return
+	}
+	resp = &types.Response{
+		Code: http.StatusOK,
+		Data: policies,
+	}
+	return
+}
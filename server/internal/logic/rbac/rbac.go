@@ -0,0 +1,20 @@
+package rbac
+
+import (
+	commontypes "github.com/hongyuxuan/lizardcd/common/types"
+	"github.com/hongyuxuan/lizardcd/server/internal/types"
+)
+
+func newPolicy(req *types.RbacPolicyReq) commontypes.RbacPolicy {
+	return commontypes.RbacPolicy{
+		Id:          req.Id,
+		Name:        req.Name,
+		SubjectType: req.SubjectType,
+		Subject:     req.Subject,
+		Cluster:     req.Cluster,
+		Namespace:   req.Namespace,
+		Resource:    req.Resource,
+		Verbs:       req.Verbs,
+		Effect:      req.Effect,
+	}
+}
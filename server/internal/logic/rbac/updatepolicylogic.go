@@ -0,0 +1,56 @@
+package rbac
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/hongyuxuan/lizardcd/common/errorx"
+	commontypes "github.com/hongyuxuan/lizardcd/common/types"
+	"github.com/hongyuxuan/lizardcd/server/internal/svc"
+	"github.com/hongyuxuan/lizardcd/server/internal/types"
+
+	"github.com/zeromicro/go-zero/core/logx"
+)
+
+type UpdatepolicyLogic struct {
+	logx.Logger
+	ctx    context.Context
+	svcCtx *svc.ServiceContext
+}
+
+func NewUpdatepolicyLogic(ctx context.Context, svcCtx *svc.ServiceContext) *UpdatepolicyLogic {
+	return &UpdatepolicyLogic{
+		Logger: logx.WithContext(ctx),
+		ctx:    ctx,
+		svcCtx: svcCtx,
+	}
+}
+
+func (l *UpdatepolicyLogic) Updatepolicy(req *types.RbacPolicyReq) (resp *types.Response, err error) {
+	if err = svc.RequireAdmin(l.ctx, "只有管理员可以管理权限策略"); err != nil {
+		return
+	}
+	policy := newPolicy(req)
+	policy.UpdateAt = time.Now()
+	if err = svc.ValidatePolicy(policy); err != nil {
+		return
+	}
+	// Select("*") also updates zero values, e.g. an empty namespace pattern
+	res := l.svcCtx.Sqlite.WithContext(context.WithValue(l.ctx, commontypes.TraceIDKey{}, "sqlite.UpdateRbacPolicy")).
+		Model(&commontypes.RbacPolicy{}).Where("id = ?", req.Id).Select("*").Omit("id").Updates(&policy)
+	if err = res.Error; err != nil {
+		l.Logger.Error(err)
+		return
+	}
+	if res.RowsAffected == 0 {
+		return nil, errorx.NewError(http.StatusNotFound, "权限策略不存在", nil)
+	}
+	l.Logger.Infof("Update rbac policy id=%d of %s \"%s\"", policy.Id, policy.SubjectType, policy.Subject)
+	resp = &types.Response{
+		Code:    http.StatusOK,
+		Data:    policy,
+		Message: "权限策略更新成功",
+	}
+	return
+}
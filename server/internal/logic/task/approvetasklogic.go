@@ -0,0 +1,85 @@
+package task
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/hongyuxuan/lizardcd/common/constant"
+	"github.com/hongyuxuan/lizardcd/common/errorx"
+	commontypes "github.com/hongyuxuan/lizardcd/common/types"
+	"github.com/hongyuxuan/lizardcd/common/utils"
+	"github.com/hongyuxuan/lizardcd/server/internal/svc"
+	"github.com/hongyuxuan/lizardcd/server/internal/types"
+
+	"github.com/zeromicro/go-zero/core/logx"
+	"gorm.io/gorm"
+)
+
+type ApproveTaskLogic struct {
+	logx.Logger
+	ctx    context.Context
+	svcCtx *svc.ServiceContext
+}
+
+func NewApproveTaskLogic(ctx context.Context, svcCtx *svc.ServiceContext) *ApproveTaskLogic {
+	return &ApproveTaskLogic{
+		Logger: logx.WithContext(ctx),
+		ctx:    ctx,
+		svcCtx: svcCtx,
+	}
+}
+
+func (l *ApproveTaskLogic) ApproveTask(req *types.ApproveTaskReq) (resp *types.Response, err error) {
+	username, _, _, _ := utils.GetPayload(l.ctx)
+	var stageTask commontypes.TaskHistory
+	if stageTask, err = getWaitingStageTask(l.ctx, l.svcCtx, req.Id); err != nil {
+		l.Logger.Error(err)
+		return
+	}
+	if err = authorizeTask(l.ctx, l.svcCtx, stageTask.Id, "approve"); err != nil {
+		l.Logger.Error(err)
+		return
+	}
+	// the pipeline task runs the stage after it finds the approval
+	res := l.svcCtx.Sqlite.WithContext(context.WithValue(l.ctx, commontypes.TraceIDKey{}, "sqlite.ApproveTaskHistory")).
+		Model(&stageTask).Where("status = ?", constant.TASK_STATUS_WAITING_APPROVAL).Updates(commontypes.TaskHistory{
+		ApprovedBy: username,
+		ApproveAt:  sql.NullTime{Time: time.Now(), Valid: true},
+	})
+	if err = res.Error; err != nil {
+		l.Logger.Error(err)
+		return
+	}
+	if res.RowsAffected == 0 { // approved or rejected by others
+		return nil, errorx.NewError(http.StatusBadRequest, "任务不在等待审批状态", nil)
+	}
+	l.svcCtx.TaskEvents.PublishTask(stageTask.Id)
+	l.Logger.Infof("Stage=%s of pipeline task id=%s is approved by %s", stageTask.Stage, stageTask.ParentId, username)
+	resp = &types.Response{
+		Code:    http.StatusOK,
+		Message: "审批通过",
+		Data: map[string]string{
+			"id":    stageTask.Id,
+			"stage": stageTask.Stage,
+		},
+	}
+	return
+}
+
+// getWaitingStageTask gets the stage task which is waiting for approval by the id of itself or its pipeline task.
+// Users other than admin can only get the stage tasks of their tenant.
+func getWaitingStageTask(ctx context.Context, svcCtx *svc.ServiceContext, id string) (stageTask commontypes.TaskHistory, err error) {
+	_, role, tenant, _ := utils.GetPayload(ctx)
+	tx := svcCtx.Sqlite.WithContext(context.WithValue(ctx, commontypes.TraceIDKey{}, "sqlite.GetTaskHistory")).
+		Where("(id = ? OR parent_id = ?) AND parent_id <> '' AND status = ?", id, id, constant.TASK_STATUS_WAITING_APPROVAL)
+	if role != constant.ROLE_ADMIN {
+		tx = tx.Where("tenant = ?", tenant)
+	}
+	if err = tx.First(&stageTask).Error; errors.Is(err, gorm.ErrRecordNotFound) {
+		err = errorx.NewError(http.StatusBadRequest, "没有等待审批的流水线阶段", nil)
+	}
+	return
+}
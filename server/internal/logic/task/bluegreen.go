@@ -0,0 +1,448 @@
+package task
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/hongyuxuan/lizardcd/agent/lizardagent"
+	"github.com/hongyuxuan/lizardcd/agent/types/agent"
+	"github.com/hongyuxuan/lizardcd/common/constant"
+	"github.com/hongyuxuan/lizardcd/common/errorx"
+	commontypes "github.com/hongyuxuan/lizardcd/common/types"
+	"github.com/hongyuxuan/lizardcd/server/internal/types"
+	"github.com/samber/lo"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	v1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	uyaml "k8s.io/apimachinery/pkg/util/yaml"
+)
+
+const defaultColorLabel = "lizardcd.io/color"
+
+const (
+	colorBlue  = "blue"  // the original deployment, which is never relabeled
+	colorGreen = "green" // the parallel deployment named with suffix -green
+)
+
+// blueGreen is the blue/green deploy of a workload
+type blueGreen struct {
+	ag           lizardagent.LizardAgent
+	taskWorkload commontypes.TaskHistoryWorkload
+	service      string
+	label        string
+	blueLabels   map[string]string // pod labels of the blue deployment
+	keys         []string          // label keys selected by the blue deployment or the service, which differ between blue and green pods
+	active       string            // color which the service selects before deploying
+	target       string            // color which is deployed and switched to
+	previous     string            // image of the container before deploying, restored to the target deployment by rollback
+}
+
+// validateBlueGreen checks whether the workloads of an application can be deployed by blue/green strategy
+func validateBlueGreen(application commontypes.Application, req *types.RunTaskReq) error {
+	if application.DeployType != constant.DEPLOY_TYPE_CONTAINER || req.TaskType != constant.K8S_TASK_TYPE_DEPLOY {
+		return errorx.NewError(http.StatusBadRequest, "蓝绿发布策略只支持容器应用的deploy任务", nil)
+	}
+	if len(application.Stages) > 0 {
+		return errorx.NewError(http.StatusBadRequest, "蓝绿发布策略不支持多阶段流水线", nil)
+	}
+	for _, w := range req.Workloads {
+		if w.WorkloadType != constant.K8S_RESOURCE_TYPE_DEPLOYMENTS {
+			return errorx.NewError(http.StatusBadRequest, fmt.Sprintf("蓝绿发布策略只支持deployments: %s", w.WorkloadName), nil)
+		}
+	}
+	return nil
+}
+
+// colorName returns the deployment name of a color
+func colorName(workloadName, color string) string {
+	if color == colorGreen {
+		return workloadName + "-" + colorGreen
+	}
+	return workloadName
+}
+
+func otherColor(color string) string {
+	if color == colorGreen {
+		return colorBlue
+	}
+	return colorGreen
+}
+
+// greenLabels returns the pod labels of the green deployment. Values of the keys are suffixed with -green and the color label is added,
+// so green pods are selected by neither the blue deployment nor the service until the service is switched to green.
+func greenLabels(blueLabels map[string]string, keys []string, colorLabel string) map[string]string {
+	labels := make(map[string]string, len(blueLabels)+1)
+	for k, v := range blueLabels {
+		labels[k] = v
+	}
+	for _, k := range keys {
+		if v, ok := labels[k]; ok {
+			labels[k] = v + "-" + colorGreen
+		}
+	}
+	labels[colorLabel] = colorGreen
+	return labels
+}
+
+// setImage sets the image of container in deployment
+func setImage(deployment *v1.Deployment, containerName, image string) error {
+	for i, c := range deployment.Spec.Template.Spec.Containers {
+		if c.Name == containerName {
+			deployment.Spec.Template.Spec.Containers[i].Image = image
+			return nil
+		}
+	}
+	return errorx.NewDefaultError("Cannot find container %s in deployment %s", containerName, deployment.Name)
+}
+
+func blueGreenStepName(action string, w commontypes.WorkLoad) string {
+	return fmt.Sprintf("%s %s/%s/%s", action, w.Cluster, w.Namespace, w.WorkloadName)
+}
+
+// findStep returns the last step with the name
+func findStep(steps []commontypes.TaskStep, name string) *commontypes.TaskStep {
+	for i := len(steps) - 1; i >= 0; i-- {
+		if steps[i].Name == name {
+			return &steps[i]
+		}
+	}
+	return nil
+}
+
+// executeBlueGreen deploys a parallel deployment of the other color for each workload, waits until all of them are ready,
+// then switches the services to them. The previous deployments are kept, so the services can be switched back instantly.
+func (l *RunTaskLogic) executeBlueGreen(application commontypes.Application, task commontypes.TaskHistory, workloads []commontypes.TaskHistoryWorkload) {
+	opts := l.getTaskOptions(application)
+	ctx, rt := newTaskContext(l.svcCtx.Sqlite, task.Id, opts.timeout)
+	defer rt.stop(task.Id)
+	if !task.StartAt.Valid {
+		task.StartAt = sql.NullTime{Time: time.Now(), Valid: true}
+	}
+	l.updateTask(task, commontypes.TaskHistory{
+		Status:  constant.TASK_STATUS_RUNNING,
+		StartAt: task.StartAt,
+	})
+
+	results := make(chan ResultChan, len(workloads))
+	var deploys []*blueGreen
+	for _, taskWorkload := range workloads {
+		if taskWorkload.Success.Valid && !taskWorkload.Success.Bool { // failed before lizardcd-server restarted
+			results <- NewResultChan(taskWorkload, false, taskWorkload.ErrMessage)
+			continue
+		}
+		if ctx.Err() != nil {
+			l.setStatus(taskWorkload, false, nil, taskError(ctx), results)
+			continue
+		}
+		bg, err := l.prepareBlueGreen(application, &task, taskWorkload)
+		if err != nil {
+			l.Logger.Error(err)
+			l.setStatus(taskWorkload, false, nil, err, results)
+			continue
+		}
+		deploys = append(deploys, bg)
+		// the status of the target deployment is the status of the workload
+		target := taskWorkload
+		target.Workload.WorkloadName = colorName(taskWorkload.Workload.WorkloadName, bg.target)
+		if taskWorkload.Success.Valid {
+			results <- NewResultChan(target, true, "")
+		} else {
+			go l.getWorkloadStatus(ctx, opts, target, results)
+		}
+	}
+	var failedWorkload []string
+	for i := 0; i < len(workloads); i++ {
+		if res := <-results; !res.Success {
+			failedWorkload = append(failedWorkload, res.ToString())
+		}
+	}
+
+	var errMessage string
+	if len(failedWorkload) > 0 {
+		failB, _ := json.Marshal(failedWorkload)
+		errMessage = string(failB)
+	} else {
+		// switch the services only when all the workloads are ready
+		var switched []*blueGreen
+		for _, bg := range deploys {
+			if err := l.switchBlueGreen(&task, bg, bg.target); err != nil {
+				errMessage = fmt.Sprintf("Failed to switch service %s to %s: %v", bg.service, bg.target, err)
+				break
+			}
+			switched = append(switched, bg)
+		}
+		if errMessage != "" {
+			// the services are either all switched or all not
+			for _, bg := range switched {
+				if err := l.switchBlueGreen(&task, bg, bg.active); err != nil {
+					l.Logger.Error(err)
+				}
+			}
+		}
+	}
+
+	success := errMessage == ""
+	cancelled := !success && errors.Is(ctx.Err(), context.Canceled)
+	taskStatus := constant.TASK_STATUS_FINISHED
+	if cancelled {
+		taskStatus = constant.TASK_STATUS_CANCELLED
+	}
+	if success {
+		l.Logger.Infof("Successfully run blue/green task, id=%s", task.Id)
+	} else {
+		// the previous deployments still receive the traffic, rollback only restores the images of the deployments not switched to
+		l.Logger.Errorf("Failed run blue/green task, id=%s: %s", task.Id, errMessage)
+		if (cancelled && rt.rollback.Load()) || (!cancelled && application.AutoRollback && task.RollbackOf == "") {
+			l.rollbackBlueGreen(&task, deploys)
+		}
+	}
+	l.updateTask(task, commontypes.TaskHistory{
+		Status:     taskStatus,
+		Reason:     lo.Ternary(success, "", taskReason(ctx)),
+		Success:    sql.NullBool{Bool: success, Valid: true},
+		ErrMessage: errMessage,
+		Steps:      task.Steps,
+		FinishAt:   sql.NullTime{Time: time.Now(), Valid: true},
+		Expire:     time.Since(task.StartAt.Time).Truncate(time.Duration(1) * time.Millisecond).String(),
+	})
+}
+
+// prepareBlueGreen creates or updates the deployment of the color which the service does not select.
+// The live deployment is never changed, a task resumed after lizardcd-server restarted continues with the color saved in its steps.
+func (l *RunTaskLogic) prepareBlueGreen(application commontypes.Application, task *commontypes.TaskHistory, taskWorkload commontypes.TaskHistoryWorkload) (bg *blueGreen, err error) {
+	w := taskWorkload.Workload
+	bg = &blueGreen{
+		taskWorkload: taskWorkload,
+		service:      application.BlueGreen.Service,
+		label:        application.BlueGreen.ColorLabel,
+	}
+	if bg.service == "" {
+		bg.service = w.WorkloadName
+	}
+	if bg.label == "" {
+		bg.label = defaultColorLabel
+	}
+	if bg.ag, err = l.svcCtx.GetAgent(w.Cluster, w.Namespace); err != nil {
+		return
+	}
+	var service *corev1.Service
+	if service, err = l.getService(bg); err != nil {
+		return
+	}
+	var blue *v1.Deployment
+	if blue, err = l.getDeployment(bg.ag, w.Namespace, w.WorkloadName); err != nil {
+		return
+	}
+	if blue.Spec.Selector == nil || len(blue.Spec.Selector.MatchExpressions) > 0 {
+		return nil, errorx.NewDefaultError("Blue/green deploy only supports deployment %s selected by matchLabels", w.WorkloadName)
+	}
+	bg.blueLabels = blue.Spec.Template.Labels
+	for k := range blue.Spec.Selector.MatchLabels {
+		bg.keys = append(bg.keys, k)
+	}
+	for k := range service.Spec.Selector {
+		if k != bg.label && blue.Spec.Selector.MatchLabels[k] == "" {
+			bg.keys = append(bg.keys, k)
+		}
+	}
+	sort.Strings(bg.keys)
+
+	stepName := blueGreenStepName("deploy", w)
+	if step := findStep(task.Steps, stepName); step != nil && taskWorkload.StartAt.Valid {
+		bg.target = step.Target
+		bg.active = otherColor(step.Target)
+		bg.previous = taskWorkload.PreviousArtifactUrl
+		return
+	}
+	bg.active = colorBlue
+	if service.Spec.Selector[bg.label] == colorGreen {
+		bg.active = colorGreen
+	}
+	bg.target = otherColor(bg.active)
+
+	active, target := blue, blue
+	if bg.active == colorGreen {
+		if active, err = l.getDeployment(bg.ag, w.Namespace, colorName(w.WorkloadName, colorGreen)); err != nil {
+			return
+		}
+	} else if target, err = l.getDeployment(bg.ag, w.Namespace, colorName(w.WorkloadName, colorGreen)); status.Code(err) == codes.NotFound {
+		// the first blue/green deploy, create the green deployment from the blue one
+		target = blue.DeepCopy()
+		target.ObjectMeta = metav1.ObjectMeta{
+			Name:        colorName(w.WorkloadName, colorGreen),
+			Namespace:   w.Namespace,
+			Labels:      target.Labels,
+			Annotations: target.Annotations,
+		}
+		delete(target.Annotations, "deployment.kubernetes.io/revision")
+		target.Spec.Selector = &metav1.LabelSelector{
+			MatchLabels: greenLabels(blue.Spec.Selector.MatchLabels, bg.keys, bg.label),
+		}
+		target.Status = v1.DeploymentStatus{}
+		err = nil
+	} else if err != nil {
+		return
+	}
+	if bg.target == colorGreen {
+		// labels of blue pods may be changed, e.g. a version label selected by the service
+		target.Spec.Template.Labels = greenLabels(bg.blueLabels, bg.keys, bg.label)
+	}
+	target.Spec.Replicas = active.Spec.Replicas
+	for _, c := range active.Spec.Template.Spec.Containers {
+		if c.Name == w.ContainerName {
+			bg.previous = c.Image
+		}
+	}
+	if err = setImage(target, w.ContainerName, w.ArtifactUrl); err != nil {
+		return nil, err
+	}
+
+	task.Steps = append(task.Steps, commontypes.TaskStep{
+		Name:    stepName,
+		Target:  bg.target,
+		StartAt: time.Now(),
+	})
+	l.updateTask(*task, commontypes.TaskHistory{Steps: task.Steps})
+	l.setStarted(taskWorkload, nil)
+	l.updateWorkload(taskWorkload, commontypes.TaskHistoryWorkload{
+		PreviousArtifactUrl: bg.previous,
+		UpdateAt:            time.Now(),
+	})
+	err = l.applyDeployment(bg.ag, target)
+	step := findStep(task.Steps, stepName)
+	step.Success = sql.NullBool{Bool: err == nil, Valid: true}
+	step.FinishAt = sql.NullTime{Time: time.Now(), Valid: true}
+	if err != nil {
+		step.Message = err.Error()
+	}
+	l.updateTask(*task, commontypes.TaskHistory{Steps: task.Steps})
+	l.Logger.Infof("Blue/green deploy cluster=%s namespace=%s deployment=%s image=%s", w.Cluster, w.Namespace, target.Name, w.ArtifactUrl)
+	return
+}
+
+// rollbackBlueGreen restores the images of the deployments deployed by a failed task, the services are not switched to them
+func (l *RunTaskLogic) rollbackBlueGreen(task *commontypes.TaskHistory, deploys []*blueGreen) {
+	for _, bg := range deploys {
+		if bg.previous == "" {
+			continue
+		}
+		w := bg.taskWorkload.Workload
+		step := commontypes.TaskStep{
+			Name:    blueGreenStepName("rollback", w),
+			Target:  bg.target,
+			StartAt: time.Now(),
+		}
+		target, err := l.getDeployment(bg.ag, w.Namespace, colorName(w.WorkloadName, bg.target))
+		if err == nil {
+			if err = setImage(target, w.ContainerName, bg.previous); err == nil {
+				err = l.applyDeployment(bg.ag, target)
+			}
+		}
+		step.Success = sql.NullBool{Bool: err == nil, Valid: true}
+		step.FinishAt = sql.NullTime{Time: time.Now(), Valid: true}
+		if err != nil {
+			step.Message = err.Error()
+		}
+		task.Steps = append(task.Steps, step)
+		l.updateTask(*task, commontypes.TaskHistory{Steps: task.Steps})
+		l.Logger.Infof("Rollback blue/green deploy cluster=%s namespace=%s deployment=%s image=%s: %v", w.Cluster, w.Namespace, colorName(w.WorkloadName, bg.target), bg.previous, err)
+	}
+}
+
+// switchBlueGreen switches the service to the deployment of color and records the step
+func (l *RunTaskLogic) switchBlueGreen(task *commontypes.TaskHistory, bg *blueGreen, color string) (err error) {
+	w := bg.taskWorkload.Workload
+	step := commontypes.TaskStep{
+		Name:    blueGreenStepName("switch", w),
+		Target:  color,
+		StartAt: time.Now(),
+	}
+	var service *corev1.Service
+	if service, err = l.getService(bg); err == nil {
+		err = l.applyService(bg, service, color)
+	}
+	step.Success = sql.NullBool{Bool: err == nil, Valid: true}
+	step.FinishAt = sql.NullTime{Time: time.Now(), Valid: true}
+	if err != nil {
+		step.Message = err.Error()
+	}
+	task.Steps = append(task.Steps, step)
+	l.updateTask(*task, commontypes.TaskHistory{Steps: task.Steps})
+	l.Logger.Infof("Switch service cluster=%s namespace=%s service=%s to %s=%s: %v", w.Cluster, w.Namespace, bg.service, bg.label, color, err)
+	return
+}
+
+func (l *RunTaskLogic) getService(bg *blueGreen) (service *corev1.Service, err error) {
+	var rpcResponse *agent.YamlResponse
+	if rpcResponse, err = bg.ag.Getyaml(context.Background(), &agent.GetYamlRequest{
+		Namespace:    bg.taskWorkload.Workload.Namespace,
+		ResourceType: "services",
+		ResourceName: bg.service,
+	}); err != nil {
+		return
+	}
+	if err = uyaml.Unmarshal([]byte(rpcResponse.Data), &service); err != nil {
+		return
+	}
+	if service == nil || len(service.Spec.Selector) == 0 {
+		return nil, errorx.NewDefaultError("Service %s is not found or has no selector", bg.service)
+	}
+	return
+}
+
+// applyService switches the selector of service to the pods of color
+func (l *RunTaskLogic) applyService(bg *blueGreen, service *corev1.Service, color string) (err error) {
+	labels := bg.blueLabels
+	if color == colorGreen {
+		labels = greenLabels(bg.blueLabels, bg.keys, bg.label)
+	}
+	for _, k := range bg.keys {
+		if _, ok := service.Spec.Selector[k]; ok && labels[k] != "" {
+			service.Spec.Selector[k] = labels[k]
+		}
+	}
+	if color == colorGreen {
+		service.Spec.Selector[bg.label] = colorGreen
+	} else {
+		delete(service.Spec.Selector, bg.label)
+	}
+	service.TypeMeta = metav1.TypeMeta{APIVersion: "v1", Kind: "Service"}
+	b, _ := json.Marshal(service)
+	_, err = bg.ag.ApplyYaml(context.Background(), &agent.YamlRequest{
+		Namespace: service.Namespace,
+		Ymlstring: string(b),
+		Kind:      "Service",
+	})
+	return
+}
+
+func (l *RunTaskLogic) getDeployment(ag lizardagent.LizardAgent, namespace, name string) (deployment *v1.Deployment, err error) {
+	var rpcResponse *agent.Response
+	if rpcResponse, err = ag.GetDeployment(context.Background(), &agent.GetWorkloadRequest{
+		Namespace:    namespace,
+		WorkloadName: name,
+	}); err != nil {
+		return
+	}
+	err = json.Unmarshal(rpcResponse.Data, &deployment)
+	return
+}
+
+func (l *RunTaskLogic) applyDeployment(ag lizardagent.LizardAgent, deployment *v1.Deployment) (err error) {
+	deployment.TypeMeta = metav1.TypeMeta{APIVersion: "apps/v1", Kind: "Deployment"}
+	b, _ := json.Marshal(deployment)
+	_, err = ag.ApplyYaml(context.Background(), &agent.YamlRequest{
+		Namespace: deployment.Namespace,
+		Ymlstring: string(b),
+		Kind:      "Deployment",
+	})
+	return
+}
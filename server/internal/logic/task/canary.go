@@ -0,0 +1,318 @@
+package task
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/hongyuxuan/lizardcd/common/constant"
+	"github.com/hongyuxuan/lizardcd/common/errorx"
+	commontypes "github.com/hongyuxuan/lizardcd/common/types"
+	"github.com/hongyuxuan/lizardcd/common/utils"
+	"github.com/hongyuxuan/lizardcd/server/internal/svc"
+	"github.com/hongyuxuan/lizardcd/server/internal/types"
+	"github.com/samber/lo"
+	"go.opentelemetry.io/otel"
+)
+
+var defaultCanarySteps = []int{10, 30, 50, 100}
+
+const defaultCanaryInterval = 60 // seconds
+
+// validateCanary checks whether the workloads of an application can be deployed by canary strategy
+func validateCanary(application commontypes.Application, req *types.RunTaskReq) error {
+	if application.DeployType != constant.DEPLOY_TYPE_CONTAINER || req.TaskType != constant.K8S_TASK_TYPE_DEPLOY {
+		return errorx.NewError(http.StatusBadRequest, "灰度发布策略只支持容器应用的deploy任务", nil)
+	}
+	if !application.EnableTrafficControl || application.TrafficPolicy != "weight" {
+		return errorx.NewError(http.StatusBadRequest, "灰度发布策略需要应用开启基于权重的灰度发布", nil)
+	}
+	if len(application.Stages) > 0 {
+		return errorx.NewError(http.StatusBadRequest, "灰度发布策略不支持多阶段流水线", nil)
+	}
+	var deployed []commontypes.WorkLoad
+	for _, w := range req.Workloads {
+		deployed = append(deployed, commontypes.WorkLoad{Cluster: w.Cluster, Namespace: w.Namespace, WorkloadName: w.WorkloadName})
+	}
+	canary := canaryIndexes(application.Workload, deployed)
+	if len(canary) == 0 || len(canary) != len(deployed) {
+		return errorx.NewError(http.StatusBadRequest, "灰度发布的工作负载必须属于应用", nil)
+	}
+	if len(canary) == len(application.Workload) {
+		return errorx.NewError(http.StatusBadRequest, "灰度发布策略需要至少保留一个不发布的稳定版本工作负载", nil)
+	}
+	for _, step := range canarySteps(application) {
+		if step <= 0 || step > 100 {
+			return errorx.NewError(http.StatusBadRequest, fmt.Sprintf("灰度发布权重必须在1-100之间: %d", step), nil)
+		}
+	}
+	return nil
+}
+
+// canaryIndexes returns the indexes of the deployed workloads in the workloads of application
+func canaryIndexes(appWorkloads []commontypes.WorkLoad, deployed []commontypes.WorkLoad) (indexes []int) {
+	for i, w := range appWorkloads {
+		for _, d := range deployed {
+			if w.Cluster == d.Cluster && w.Namespace == d.Namespace && w.WorkloadName == d.WorkloadName {
+				indexes = append(indexes, i)
+				break
+			}
+		}
+	}
+	return
+}
+
+// canarySteps returns the weight steps of canary, which always end with 100
+func canarySteps(application commontypes.Application) []int {
+	steps := application.Canary.Steps
+	if len(steps) == 0 {
+		steps = defaultCanarySteps
+	}
+	if steps[len(steps)-1] != 100 {
+		steps = append(steps, 100)
+	}
+	return steps
+}
+
+// canaryWeights splits weight to the canary workloads and the rest to other workloads evenly
+func canaryWeights(appWorkloads []commontypes.WorkLoad, canary []int, weight int) []commontypes.WorkLoad {
+	workloads := make([]commontypes.WorkLoad, len(appWorkloads))
+	copy(workloads, appWorkloads)
+	isCanary := make(map[int]bool)
+	for _, i := range canary {
+		isCanary[i] = true
+	}
+	stableCount := len(workloads) - len(canary)
+	canaryFirst, stableFirst := true, true
+	for i := range workloads {
+		if isCanary[i] {
+			workloads[i].Weight = weight / len(canary)
+			if canaryFirst { // the remainder goes to the first workload
+				workloads[i].Weight += weight % len(canary)
+				canaryFirst = false
+			}
+		} else {
+			workloads[i].Weight = (100 - weight) / stableCount
+			if stableFirst {
+				workloads[i].Weight += (100 - weight) % stableCount
+				stableFirst = false
+			}
+		}
+	}
+	return workloads
+}
+
+// executeCanary deploys the workloads which receive no traffic, then shifts istio weight to them step by step.
+// The health gate is checked after each step, and the weights are reverted when it fails.
+func (l *RunTaskLogic) executeCanary(application commontypes.Application, task commontypes.TaskHistory, workloads []commontypes.TaskHistoryWorkload) {
+	opts := l.getTaskOptions(application)
+	steps := canarySteps(application)
+	interval := firstPositive(application.Canary.Interval, defaultCanaryInterval)
+	// the task timeout is for rolling out, waiting between steps takes extra time
+	ctx, rt := newTaskContext(l.svcCtx.Sqlite, task.Id, opts.timeout+interval*time.Duration(len(steps)))
+	defer rt.stop(task.Id)
+
+	var deployed []commontypes.WorkLoad
+	for _, taskWorkload := range workloads {
+		deployed = append(deployed, taskWorkload.Workload)
+	}
+	canary := canaryIndexes(application.Workload, deployed)
+	if !task.StartAt.Valid { // started by rolloutWorkloads, used to calculate the expire
+		task.StartAt = sql.NullTime{Time: time.Now(), Valid: true}
+	}
+	// steps which have passed before lizardcd-server restarted are skipped
+	passed := 0
+	for _, step := range task.Steps {
+		if step.Success.Valid && step.Success.Bool {
+			passed++
+		}
+	}
+
+	var errMessage string
+	if passed == 0 {
+		// the deployed workloads receive no traffic until they are ready
+		if err := l.setWeights(application, canaryWeights(application.Workload, canary, 0)); err != nil {
+			errMessage = "Failed to set istio weight: " + err.Error()
+		}
+	}
+	if errMessage == "" {
+		results := l.rolloutWorkloads(ctx, opts, task, workloads)
+		var failedWorkload []string
+		for i := 0; i < len(workloads); i++ {
+			if res := <-results; !res.Success {
+				failedWorkload = append(failedWorkload, res.ToString())
+			}
+		}
+		if len(failedWorkload) > 0 {
+			failB, _ := json.Marshal(failedWorkload)
+			errMessage = string(failB)
+		}
+	}
+	for i := passed; errMessage == "" && i < len(steps); i++ {
+		task.Steps = append(task.Steps, commontypes.TaskStep{
+			Name:    fmt.Sprintf("canary weight %d%%", steps[i]),
+			Weight:  steps[i],
+			StartAt: time.Now(),
+		})
+		l.updateTask(task, commontypes.TaskHistory{Steps: task.Steps})
+		l.Logger.Infof("Canary task id=%s shift weight %d%% to workloads", task.Id, steps[i])
+		err := l.setWeights(application, canaryWeights(application.Workload, canary, steps[i]))
+		if err == nil {
+			if sleep(ctx, interval); ctx.Err() != nil {
+				err = taskError(ctx)
+			} else {
+				err = l.checkHealthGate(ctx, application, workloads)
+			}
+		}
+		step := &task.Steps[len(task.Steps)-1]
+		step.Success = sql.NullBool{Bool: err == nil, Valid: true}
+		step.FinishAt = sql.NullTime{Time: time.Now(), Valid: true}
+		if err != nil {
+			step.Message = err.Error()
+			errMessage = fmt.Sprintf("Canary weight %d%% failed: %v", steps[i], err)
+		}
+		l.updateTask(task, commontypes.TaskHistory{Steps: task.Steps})
+	}
+
+	success := errMessage == ""
+	if success {
+		// later deploys start from the new weights
+		final := canaryWeights(application.Workload, canary, 100)
+		if err := l.svcCtx.Sqlite.Model(&application).Update("workload", commontypes.WorkLoadList(final)).Error; err != nil {
+			l.Logger.Error(err)
+		}
+	} else {
+		l.Logger.Errorf("Canary task id=%s failed: %s, revert istio weight", task.Id, errMessage)
+		revert := commontypes.TaskStep{
+			Name:     "revert weight",
+			Success:  sql.NullBool{Bool: true, Valid: true},
+			StartAt:  time.Now(),
+			FinishAt: sql.NullTime{Time: time.Now(), Valid: true},
+		}
+		// the saved weights may still send traffic to the failed workloads, e.g. they have passed a previous canary
+		reverted := canaryWeights(application.Workload, canary, 0)
+		if err := l.setWeights(application, reverted); err != nil {
+			l.Logger.Error(err)
+			revert.Success.Bool = false
+			revert.Message = err.Error()
+		} else if err = l.svcCtx.Sqlite.Model(&application).Update("workload", commontypes.WorkLoadList(reverted)).Error; err != nil {
+			l.Logger.Error(err)
+		}
+		task.Steps = append(task.Steps, revert)
+	}
+	cancelled := !success && errors.Is(ctx.Err(), context.Canceled)
+	status := constant.TASK_STATUS_FINISHED
+	if cancelled {
+		status = constant.TASK_STATUS_CANCELLED
+	}
+	l.updateTask(task, commontypes.TaskHistory{
+		Status:     status,
+		Reason:     lo.Ternary(success, "", taskReason(ctx)),
+		Success:    sql.NullBool{Bool: success, Valid: true},
+		ErrMessage: errMessage,
+		Steps:      task.Steps,
+		FinishAt:   sql.NullTime{Time: time.Now(), Valid: true},
+		Expire:     time.Since(task.StartAt.Time).Truncate(time.Duration(1) * time.Millisecond).String(),
+	})
+	if (cancelled && rt.rollback.Load()) || (!success && !cancelled && application.AutoRollback && task.RollbackOf == "") {
+		l.rollback(application, task)
+	}
+}
+
+// setWeights patches the istio virtualservice of application
+func (l *RunTaskLogic) setWeights(application commontypes.Application, workloads []commontypes.WorkLoad) (err error) {
+	// istio resources are created in the cluster and namespace of the first workload
+	w := application.Workload[0]
+	ag, err := l.svcCtx.GetAgent(w.Cluster, w.Namespace)
+	if err != nil {
+		return
+	}
+	return svc.NewIstioService(context.Background(), l.svcCtx).SaveVirtualService(w.Cluster, w.Namespace, application.AppName, application.TrafficPolicy, workloads, ag, false)
+}
+
+// checkHealthGate checks the readiness of pods, and the http probe and prometheus query if configured
+func (l *RunTaskLogic) checkHealthGate(ctx context.Context, application commontypes.Application, workloads []commontypes.TaskHistoryWorkload) (err error) {
+	for _, taskWorkload := range workloads {
+		w := taskWorkload.Workload
+		ag, err := l.svcCtx.GetAgent(w.Cluster, w.Namespace)
+		if err != nil {
+			return err
+		}
+		status, err := l.getRolloutStatus(ag, w)
+		if err != nil {
+			return err
+		}
+		if status.Failed || status.ReadyReplicas < status.Replicas {
+			return errorx.NewDefaultError("Workload %s is not ready: ready=%d/%d %s", w.WorkloadName, status.ReadyReplicas, status.Replicas, status.Message)
+		}
+	}
+	gate := application.Canary.HealthGate
+	client := utils.NewHttpClient(otel.Tracer("imroc/req"))
+	if gate.HttpUrl != "" {
+		if err = client.Get(gate.HttpUrl).Do(context.WithValue(ctx, commontypes.TraceIDKey{}, "http.CanaryHttpProbe")).Err; err != nil {
+			return errorx.NewDefaultError("Http probe %s failed: %v", gate.HttpUrl, err)
+		}
+	}
+	if gate.PrometheusUrl != "" && gate.PrometheusQuery != "" {
+		var value float64
+		if value, err = queryPrometheus(ctx, client, gate.PrometheusUrl, gate.PrometheusQuery); err != nil {
+			return
+		}
+		if !compare(value, gate.Operator, gate.Threshold) {
+			return errorx.NewDefaultError("Prometheus query value %v is not %s %v", value, gate.Operator, gate.Threshold)
+		}
+	}
+	return nil
+}
+
+// queryPrometheus returns the first value of an instant query
+func queryPrometheus(ctx context.Context, client *utils.HttpClient, url, query string) (value float64, err error) {
+	var res struct {
+		Status string `json:"status"`
+		Data   struct {
+			ResultType string          `json:"resultType"`
+			Result     json.RawMessage `json:"result"`
+		} `json:"data"`
+	}
+	if err = client.Get(strings.TrimSuffix(url, "/")+"/api/v1/query").SetQueryParam("query", query).SetResult(&res).Do(context.WithValue(ctx, commontypes.TraceIDKey{}, "http.QueryPrometheus")).Err; err != nil {
+		return 0, errorx.NewDefaultError("Prometheus query failed: %v", err)
+	}
+	// a sample is [timestamp, "value"]
+	var sample []interface{}
+	switch res.Data.ResultType {
+	case "scalar":
+		json.Unmarshal(res.Data.Result, &sample)
+	case "vector":
+		var vector []struct {
+			Value []interface{} `json:"value"`
+		}
+		json.Unmarshal(res.Data.Result, &vector)
+		if len(vector) > 0 {
+			sample = vector[0].Value
+		}
+	}
+	if len(sample) != 2 {
+		return 0, errorx.NewDefaultError("Prometheus query returns no data: %s", query)
+	}
+	return strconv.ParseFloat(fmt.Sprint(sample[1]), 64)
+}
+
+func compare(value float64, operator string, threshold float64) bool {
+	switch operator {
+	case "<":
+		return value < threshold
+	case "<=":
+		return value <= threshold
+	case ">":
+		return value > threshold
+	case ">=":
+		return value >= threshold
+	}
+	return value == threshold
+}
@@ -0,0 +1,245 @@
+package task
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/hongyuxuan/lizardcd/common/constant"
+	"github.com/hongyuxuan/lizardcd/common/errorx"
+	commontypes "github.com/hongyuxuan/lizardcd/common/types"
+	"github.com/hongyuxuan/lizardcd/common/utils"
+	"github.com/hongyuxuan/lizardcd/server/internal/svc"
+	"github.com/hongyuxuan/lizardcd/server/internal/types"
+
+	"github.com/google/uuid"
+	"github.com/samber/lo"
+	"github.com/zeromicro/go-zero/core/logx"
+	"gorm.io/gorm"
+)
+
+// runningTasks stores the running tasks of this lizardcd-server by task id
+var (
+	runningTasks   = make(map[string]*runningTask)
+	runningTasksMu sync.Mutex
+)
+
+// unfinishedStatus is the status of tasks which are not finished
+var unfinishedStatus = []string{constant.TASK_STATUS_INITIALIZE, constant.TASK_STATUS_RUNNING, constant.TASK_STATUS_RECOVERED, constant.TASK_STATUS_WAITING_APPROVAL}
+
+// cancelPollInterval is the interval of checking whether a running task is cancelled by other lizardcd-servers
+const cancelPollInterval = 5 * time.Second
+
+// taskLeaseDuration is how long a task is owned by the lizardcd-server running it without renewing the lease,
+// after which the task is resumed by other lizardcd-servers
+const taskLeaseDuration = 30 * time.Second
+
+// serverId identifies this lizardcd-server as the owner of the tasks it runs
+var serverId = uuid.New().String()
+
+// newLease returns the time until which a task is owned by this lizardcd-server
+func newLease() sql.NullTime {
+	return sql.NullTime{Time: time.Now().Add(taskLeaseDuration), Valid: true}
+}
+
+type runningTask struct {
+	cancel   context.CancelFunc
+	rollback atomic.Bool
+}
+
+// newTaskContext creates the context for running a task, which is done when the task timeout or is cancelled.
+// A task without timeout is only done when it is cancelled.
+// The task is also cancelled when it is marked cancelled in db by other lizardcd-servers.
+func newTaskContext(db *gorm.DB, taskId string, timeout time.Duration) (context.Context, *runningTask) {
+	ctx, cancel := context.WithCancel(context.Background())
+	if timeout > 0 {
+		ctx, cancel = context.WithTimeout(context.Background(), timeout)
+	}
+	rt := &runningTask{cancel: cancel}
+	runningTasksMu.Lock()
+	runningTasks[taskId] = rt
+	runningTasksMu.Unlock()
+	renewLease(db, taskId)
+	go watchTask(ctx, db, taskId, cancel)
+	return ctx, rt
+}
+
+// renewLease keeps the task owned by this lizardcd-server
+func renewLease(db *gorm.DB, taskId string) {
+	if err := db.Model(&commontypes.TaskHistory{}).Where("id = ?", taskId).Updates(commontypes.TaskHistory{
+		Owner:      serverId,
+		LeaseUntil: newLease(),
+	}).Error; err != nil {
+		logx.Errorf("Failed to renew the lease of task id=%s: %v", taskId, err)
+	}
+}
+
+// watchTask renews the lease of the task, and cancels the task when its status in db is cancelled, until ctx is done
+func watchTask(ctx context.Context, db *gorm.DB, taskId string, cancel context.CancelFunc) {
+	ticker := time.NewTicker(cancelPollInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		renewLease(db, taskId)
+		var count int64
+		if err := db.Model(&commontypes.TaskHistory{}).Where("id = ? AND status = ?", taskId, constant.TASK_STATUS_CANCELLED).Count(&count).Error; err != nil {
+			logx.Error(err)
+			continue
+		}
+		if count > 0 {
+			logx.Infof("Task id=%s is cancelled by other lizardcd-server", taskId)
+			cancel()
+			return
+		}
+	}
+}
+
+func (rt *runningTask) stop(taskId string) {
+	runningTasksMu.Lock()
+	if runningTasks[taskId] == rt {
+		delete(runningTasks, taskId)
+	}
+	runningTasksMu.Unlock()
+	rt.cancel()
+}
+
+// cancelRunningTask cancels a task if it is running in this lizardcd-server
+func cancelRunningTask(taskId string, rollback bool) bool {
+	runningTasksMu.Lock()
+	rt, ok := runningTasks[taskId]
+	runningTasksMu.Unlock()
+	if ok {
+		rt.rollback.Store(rollback)
+		rt.cancel()
+	}
+	return ok
+}
+
+// taskError returns the reason why a task context is done
+func taskError(ctx context.Context) error {
+	if errors.Is(ctx.Err(), context.Canceled) {
+		return errorx.NewDefaultError("CANCELLED")
+	}
+	return errorx.NewDefaultError("TIMEOUT and TERMINATED")
+}
+
+// taskReason returns the reason of a failed task whose context is done, or empty if it failed by itself
+func taskReason(ctx context.Context) string {
+	if errors.Is(ctx.Err(), context.Canceled) {
+		return constant.TASK_REASON_CANCELLED
+	}
+	if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		return constant.TASK_REASON_TIMEOUT
+	}
+	return ""
+}
+
+// sleep pauses for d, or until ctx is done
+func sleep(ctx context.Context, d time.Duration) {
+	select {
+	case <-ctx.Done():
+	case <-time.After(d):
+	}
+}
+
+// authorizeTask checks the rbac policies of verb on the task by the cluster and namespace of each workload of the task and its stage tasks,
+// since the routes of tasks have no cluster and namespace
+func authorizeTask(ctx context.Context, svcCtx *svc.ServiceContext, taskId, verb string) error {
+	var workloads []commontypes.TaskHistoryWorkload
+	stageTaskIds := svcCtx.Sqlite.Model(&commontypes.TaskHistory{}).Select("id").Where("parent_id = ?", taskId)
+	if err := svcCtx.Sqlite.WithContext(context.WithValue(ctx, commontypes.TraceIDKey{}, "sqlite.ListTaskHistoryWorkload")).
+		Where("task_history_id = ? OR task_history_id IN (?)", taskId, stageTaskIds).Find(&workloads).Error; err != nil {
+		return err
+	}
+	for _, w := range workloads {
+		if err := svcCtx.Rbac.Authorize(ctx, w.Workload.Cluster, w.Workload.Namespace, "task", verb); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+type CancelTaskLogic struct {
+	logx.Logger
+	ctx    context.Context
+	svcCtx *svc.ServiceContext
+}
+
+func NewCancelTaskLogic(ctx context.Context, svcCtx *svc.ServiceContext) *CancelTaskLogic {
+	return &CancelTaskLogic{
+		Logger: logx.WithContext(ctx),
+		ctx:    ctx,
+		svcCtx: svcCtx,
+	}
+}
+
+func (l *CancelTaskLogic) CancelTask(req *types.CancelTaskReq) (resp *types.Response, err error) {
+	// users other than admin can only cancel the tasks of their tenant
+	_, role, tenant, _ := utils.GetPayload(l.ctx)
+	var task commontypes.TaskHistory
+	tx := l.svcCtx.Sqlite.WithContext(context.WithValue(l.ctx, commontypes.TraceIDKey{}, "sqlite.GetTaskHistory")).Where("id = ?", req.Id)
+	if role != constant.ROLE_ADMIN {
+		tx = tx.Where("tenant = ?", tenant)
+	}
+	if err = tx.First(&task).Error; err != nil {
+		l.Logger.Error(err)
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			err = errorx.NewError(http.StatusNotFound, "任务不存在", nil)
+		}
+		return
+	}
+	if err = authorizeTask(l.ctx, l.svcCtx, task.Id, "cancel"); err != nil {
+		l.Logger.Error(err)
+		return
+	}
+	if cancelRunningTask(req.Id, req.Rollback) {
+		l.Logger.Infof("Cancel task id=%s rollback=%v", req.Id, req.Rollback)
+		resp = &types.Response{
+			Code:    http.StatusOK,
+			Message: "任务已取消",
+		}
+		return
+	}
+	if !lo.Contains(unfinishedStatus, task.Status) {
+		err = errorx.NewError(http.StatusBadRequest, "任务已结束，无法取消", nil)
+		return
+	}
+	// task is not running in this lizardcd-server, e.g. waiting to be resumed or running in other lizardcd-servers,
+	// which stop the task when they find it cancelled in db, but cannot roll it back
+	if req.Rollback {
+		err = errorx.NewError(http.StatusBadRequest, "任务不在当前lizardcd-server运行，无法取消并回滚，请不带回滚取消任务", nil)
+		return
+	}
+	if err = l.svcCtx.Sqlite.WithContext(context.WithValue(l.ctx, commontypes.TraceIDKey{}, "sqlite.CancelTaskHistory")).
+		Model(&task).Updates(commontypes.TaskHistory{
+		Status:     constant.TASK_STATUS_CANCELLED,
+		Success:    sql.NullBool{Bool: false, Valid: true},
+		ErrMessage: "CANCELLED",
+		FinishAt:   sql.NullTime{Time: time.Now(), Valid: true},
+	}).Error; err != nil {
+		l.Logger.Error(err)
+		return
+	}
+	l.svcCtx.TaskEvents.PublishTask(task.Id)
+	// stage tasks of a pipeline task are run by the pipeline, so cancel them together
+	l.svcCtx.Sqlite.WithContext(context.WithValue(l.ctx, commontypes.TraceIDKey{}, "sqlite.CancelTaskHistory")).
+		Model(&commontypes.TaskHistory{}).Where("parent_id = ? AND status IN ?", task.Id, unfinishedStatus).Updates(commontypes.TaskHistory{
+		Status:     constant.TASK_STATUS_CANCELLED,
+		Success:    sql.NullBool{Bool: false, Valid: true},
+		ErrMessage: "CANCELLED",
+		FinishAt:   sql.NullTime{Time: time.Now(), Valid: true},
+	})
+	resp = &types.Response{
+		Code:    http.StatusOK,
+		Message: "任务已取消",
+	}
+	return
+}
@@ -0,0 +1,67 @@
+package task
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"github.com/hongyuxuan/lizardcd/common/constant"
+	commontypes "github.com/hongyuxuan/lizardcd/common/types"
+	"github.com/hongyuxuan/lizardcd/common/utils"
+	"github.com/hongyuxuan/lizardcd/server/internal/svc"
+)
+
+func withPayloads(username, role, tenant string) context.Context {
+	return context.WithValue(context.Background(), "payloads", map[string]interface{}{
+		"username":  username,
+		"role":      role,
+		"tenant":    tenant,
+		"namespace": "",
+	})
+}
+
+func TestAuthorizeTask(t *testing.T) {
+	db := utils.NewSQLite(filepath.Join(t.TempDir(), "lizardcd.db"), "error")
+	if err := db.AutoMigrate(&commontypes.User{}, &commontypes.RbacPolicy{}, &commontypes.TaskHistory{}, &commontypes.TaskHistoryWorkload{}); err != nil {
+		t.Fatal(err)
+	}
+	db.Create(&commontypes.User{Username: "alice", Role: constant.ROLE_READWRITE, Tenant: "dev", Groups: commontypes.StringList{}})
+	db.Create(&[]commontypes.RbacPolicy{
+		{SubjectType: constant.RBAC_SUBJECT_USER, Subject: "alice", Cluster: "*", Namespace: "*", Resource: "*", Verbs: commontypes.StringList{"*"}, Effect: constant.RBAC_EFFECT_ALLOW},
+		{SubjectType: constant.RBAC_SUBJECT_USER, Subject: "alice", Cluster: "*", Namespace: "prod", Resource: "task", Verbs: commontypes.StringList{"cancel", "approve"}, Effect: constant.RBAC_EFFECT_DENY},
+	})
+	// a pipeline task whose second stage deploys to the namespace denied to alice
+	db.Create(&[]commontypes.TaskHistory{
+		{Id: "test", Tenant: "dev"},
+		{Id: "pipeline", Tenant: "dev"},
+		{Id: "stage-test", ParentId: "pipeline", Tenant: "dev"},
+		{Id: "stage-prod", ParentId: "pipeline", Tenant: "dev"},
+	})
+	db.Create(&[]commontypes.TaskHistoryWorkload{
+		{TaskHistoryId: "test", Workload: commontypes.WorkLoad{Cluster: "k8s", Namespace: "test"}},
+		{TaskHistoryId: "stage-test", Workload: commontypes.WorkLoad{Cluster: "k8s", Namespace: "test"}},
+		{TaskHistoryId: "stage-prod", Workload: commontypes.WorkLoad{Cluster: "k8s", Namespace: "prod"}},
+	})
+	svcCtx := &svc.ServiceContext{Sqlite: db, Rbac: svc.NewRbac(db)}
+
+	tests := []struct {
+		name    string
+		ctx     context.Context
+		taskId  string
+		verb    string
+		wantErr bool
+	}{
+		{"allowed namespace", withPayloads("alice", constant.ROLE_READWRITE, "dev"), "test", "cancel", false},
+		{"denied namespace of a stage", withPayloads("alice", constant.ROLE_READWRITE, "dev"), "pipeline", "cancel", true},
+		{"denied stage", withPayloads("alice", constant.ROLE_READWRITE, "dev"), "stage-prod", "approve", true},
+		{"allowed stage", withPayloads("alice", constant.ROLE_READWRITE, "dev"), "stage-test", "approve", false},
+		{"admin", withPayloads("admin", constant.ROLE_ADMIN, "admin"), "pipeline", "cancel", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := authorizeTask(tt.ctx, svcCtx, tt.taskId, tt.verb); (err != nil) != tt.wantErr {
+				t.Errorf("authorizeTask() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
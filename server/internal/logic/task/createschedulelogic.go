@@ -0,0 +1,114 @@
+package task
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/hongyuxuan/lizardcd/common/errorx"
+	commontypes "github.com/hongyuxuan/lizardcd/common/types"
+	"github.com/hongyuxuan/lizardcd/common/utils"
+	"github.com/hongyuxuan/lizardcd/server/internal/svc"
+	"github.com/hongyuxuan/lizardcd/server/internal/types"
+	"gorm.io/gorm"
+
+	"github.com/zeromicro/go-zero/core/logx"
+)
+
+type CreateScheduleLogic struct {
+	logx.Logger
+	ctx    context.Context
+	svcCtx *svc.ServiceContext
+}
+
+func NewCreateScheduleLogic(ctx context.Context, svcCtx *svc.ServiceContext) *CreateScheduleLogic {
+	return &CreateScheduleLogic{
+		Logger: logx.WithContext(ctx),
+		ctx:    ctx,
+		svcCtx: svcCtx,
+	}
+}
+
+func (l *CreateScheduleLogic) CreateSchedule(req *types.ScheduleTaskReq) (resp *types.Response, err error) {
+	username, _, tenant, _ := utils.GetPayload(l.ctx)
+	st := commontypes.ScheduledTask{
+		Id:        uuid.New().String(),
+		Tenant:    tenant,
+		CreatedBy: username,
+	}
+	if err = setScheduledTask(l.ctx, l.svcCtx, &st, req); err != nil {
+		return
+	}
+	if err = l.svcCtx.Sqlite.WithContext(context.WithValue(l.ctx, commontypes.TraceIDKey{}, "sqlite.CreateScheduledTask")).Create(&st).Error; err != nil {
+		l.Logger.Error(err)
+		return
+	}
+	NewSchedulerLogic(context.Background(), l.svcCtx).Schedule(st)
+	l.Logger.Infof("Create scheduled task id=%s name=%s cron=\"%s\" run_at=%s", st.Id, st.Name, st.Cron, req.RunAt)
+	resp = &types.Response{
+		Code:    http.StatusOK,
+		Message: "定时任务创建成功",
+		Data: map[string]string{
+			"id": st.Id,
+		},
+	}
+	return
+}
+
+// setScheduledTask validates the request and sets it to the scheduled task
+func setScheduledTask(ctx context.Context, svcCtx *svc.ServiceContext, st *commontypes.ScheduledTask, req *types.ScheduleTaskReq) (err error) {
+	var application commontypes.Application
+	if err = svcCtx.Sqlite.WithContext(context.WithValue(ctx, commontypes.TraceIDKey{}, "sqlite.GetApplication")).
+		Where("app_name = ?", req.AppName).First(&application).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			err = errorx.NewError(http.StatusBadRequest, "应用不存在: "+req.AppName, nil)
+		}
+		return
+	}
+	// scheduled tasks are run without a role, so the workloads are checked by the tenant and rbac policies of the user saving them
+	if err = checkAppWorkloads(application, req.Workloads); err != nil {
+		return
+	}
+	for _, w := range req.Workloads {
+		if w.Cluster != "" && w.Namespace != "" {
+			if err = svcCtx.Rbac.CheckNamespace(ctx, w.Cluster, w.Namespace); err != nil {
+				return
+			}
+		}
+		if err = svcCtx.Rbac.Authorize(ctx, w.Cluster, w.Namespace, "task", "run"); err != nil {
+			return
+		}
+	}
+	if st.RunAt, err = parseRunAt(req.RunAt); err != nil {
+		return
+	}
+	if _, err = parseSchedule(req.Cron, st.RunAt); err != nil {
+		return
+	}
+	if req.Cron == "" && req.Enabled && st.RunAt.Time.Before(time.Now()) {
+		return errorx.NewError(http.StatusBadRequest, "run_at不能早于当前时间", nil)
+	}
+	st.Name = req.Name
+	st.AppName = req.AppName
+	st.TaskType = req.TaskType
+	st.Cron = req.Cron
+	st.Enabled = req.Enabled
+	st.Strategy = req.Strategy
+	st.ArtifactUrl = req.ArtifactUrl
+	st.Labels = req.Labels
+	st.Workloads = nil
+	for _, w := range req.Workloads {
+		st.Workloads = append(st.Workloads, commontypes.WorkLoad{
+			Cluster:       w.Cluster,
+			Namespace:     w.Namespace,
+			WorkloadType:  w.WorkloadType,
+			WorkloadName:  w.WorkloadName,
+			ContainerName: w.ContainerName,
+			ArtifactUrl:   w.ArtifactUrl,
+		})
+	}
+	st.UpdateAt = time.Now()
+	return
+}
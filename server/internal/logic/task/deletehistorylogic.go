@@ -34,6 +34,19 @@ func (l *DeleteHistoryLogic) DeleteHistory(req *types.TaskIdReq) (resp *types.Re
 		l.Logger.Error(err)
 		return
 	}
+	// delete stage tasks of a pipeline task
+	var stageTaskIds []string
+	l.svcCtx.Sqlite.WithContext(context.WithValue(l.ctx, commontypes.TraceIDKey{}, "sqlite.ListStageTask")).Model(&commontypes.TaskHistory{}).Where("parent_id = ?", req.Id).Pluck("id", &stageTaskIds)
+	if len(stageTaskIds) > 0 {
+		if err = l.svcCtx.Sqlite.WithContext(context.WithValue(l.ctx, commontypes.TraceIDKey{}, "sqlite.DeleteHistory")).Where("id IN ?", stageTaskIds).Delete(&commontypes.TaskHistory{}).Error; err != nil {
+			l.Logger.Error(err)
+			return
+		}
+		if err = l.svcCtx.Sqlite.WithContext(context.WithValue(l.ctx, commontypes.TraceIDKey{}, "sqlite.DeleteHistoryWorkload")).Where("task_history_id IN ?", stageTaskIds).Delete(&commontypes.TaskHistoryWorkload{}).Error; err != nil {
+			l.Logger.Error(err)
+			return
+		}
+	}
 	resp = &types.Response{
 		Code:    http.StatusOK,
 		Message: "删除成功",
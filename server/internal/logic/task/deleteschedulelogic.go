@@ -0,0 +1,53 @@
+package task
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/hongyuxuan/lizardcd/common/constant"
+	"github.com/hongyuxuan/lizardcd/common/errorx"
+	commontypes "github.com/hongyuxuan/lizardcd/common/types"
+	"github.com/hongyuxuan/lizardcd/common/utils"
+	"github.com/hongyuxuan/lizardcd/server/internal/svc"
+	"github.com/hongyuxuan/lizardcd/server/internal/types"
+
+	"github.com/zeromicro/go-zero/core/logx"
+)
+
+type DeleteScheduleLogic struct {
+	logx.Logger
+	ctx    context.Context
+	svcCtx *svc.ServiceContext
+}
+
+func NewDeleteScheduleLogic(ctx context.Context, svcCtx *svc.ServiceContext) *DeleteScheduleLogic {
+	return &DeleteScheduleLogic{
+		Logger: logx.WithContext(ctx),
+		ctx:    ctx,
+		svcCtx: svcCtx,
+	}
+}
+
+func (l *DeleteScheduleLogic) DeleteSchedule(req *types.TaskIdReq) (resp *types.Response, err error) {
+	_, role, tenant, _ := utils.GetPayload(l.ctx)
+	tx := l.svcCtx.Sqlite.WithContext(context.WithValue(l.ctx, commontypes.TraceIDKey{}, "sqlite.DeleteScheduledTask")).Where("id = ?", req.Id)
+	if role != constant.ROLE_ADMIN {
+		tx = tx.Where("tenant = ?", tenant)
+	}
+	res := tx.Delete(&commontypes.ScheduledTask{})
+	if err = res.Error; err != nil {
+		l.Logger.Error(err)
+		return
+	}
+	if res.RowsAffected == 0 {
+		err = errorx.NewError(http.StatusNotFound, "定时任务不存在", nil)
+		return
+	}
+	NewSchedulerLogic(context.Background(), l.svcCtx).Unschedule(req.Id)
+	l.Logger.Infof("Delete scheduled task id=%s", req.Id)
+	resp = &types.Response{
+		Code:    http.StatusOK,
+		Message: "定时任务删除成功",
+	}
+	return
+}
@@ -0,0 +1,117 @@
+package task
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+var weekdays = map[string]time.Weekday{
+	"sun": time.Sunday,
+	"mon": time.Monday,
+	"tue": time.Tuesday,
+	"wed": time.Wednesday,
+	"thu": time.Thursday,
+	"fri": time.Friday,
+	"sat": time.Saturday,
+}
+
+// deployWindow is a time range in which tasks are allowed to run, on some weekdays or every day
+type deployWindow struct {
+	days       map[time.Weekday]bool // empty means every day
+	start, end int                   // minutes of day, the window crosses midnight if end <= start
+}
+
+// parseDeployWindows parses windows separated by ";", e.g. "Mon-Fri 09:00-18:00; Sat,Sun 10:00-12:00; 22:00-02:00"
+func parseDeployWindows(windows string) (result []deployWindow, err error) {
+	for _, s := range strings.Split(windows, ";") {
+		fields := strings.Fields(s)
+		if len(fields) == 0 {
+			continue
+		}
+		if len(fields) > 2 {
+			return nil, fmt.Errorf("invalid deploy window \"%s\"", s)
+		}
+		w := deployWindow{days: make(map[time.Weekday]bool)}
+		if len(fields) == 2 {
+			if err = w.parseDays(fields[0]); err != nil {
+				return
+			}
+		}
+		hours := strings.Split(fields[len(fields)-1], "-")
+		if len(hours) != 2 {
+			return nil, fmt.Errorf("invalid hours \"%s\" of deploy window", fields[len(fields)-1])
+		}
+		if w.start, err = parseMinutes(hours[0]); err != nil {
+			return
+		}
+		if w.end, err = parseMinutes(hours[1]); err != nil {
+			return
+		}
+		result = append(result, w)
+	}
+	return
+}
+
+// parseDays parses weekdays like "Mon-Fri" or "Sat,Sun"
+func (w *deployWindow) parseDays(days string) error {
+	for _, d := range strings.Split(strings.ToLower(days), ",") {
+		from, to, isRange := strings.Cut(d, "-")
+		start, ok := weekdays[from]
+		if !ok {
+			return fmt.Errorf("invalid weekday \"%s\" of deploy window", from)
+		}
+		end := start
+		if isRange {
+			if end, ok = weekdays[to]; !ok {
+				return fmt.Errorf("invalid weekday \"%s\" of deploy window", to)
+			}
+		}
+		for day := start; ; day = (day + 1) % 7 {
+			w.days[day] = true
+			if day == end {
+				break
+			}
+		}
+	}
+	return nil
+}
+
+func parseMinutes(hhmm string) (int, error) {
+	t, err := time.Parse("15:04", hhmm)
+	if err != nil {
+		return 0, fmt.Errorf("invalid time \"%s\" of deploy window", hhmm)
+	}
+	return t.Hour()*60 + t.Minute(), nil
+}
+
+// contains reports whether t is in the window. The part after midnight belongs to the weekday the window starts.
+func (w deployWindow) contains(t time.Time) bool {
+	minutes := t.Hour()*60 + t.Minute()
+	day := t.Weekday()
+	switch {
+	case w.start < w.end:
+		if minutes < w.start || minutes >= w.end {
+			return false
+		}
+	case minutes >= w.start: // before midnight
+	case minutes < w.end: // after midnight
+		day = (day + 6) % 7
+	default:
+		return false
+	}
+	return len(w.days) == 0 || w.days[day]
+}
+
+// inDeployWindows reports whether t is in any of the windows, no windows means always allowed
+func inDeployWindows(windows []deployWindow, t time.Time) bool {
+	if len(windows) == 0 {
+		return true
+	}
+	for _, w := range windows {
+		if w.contains(t) {
+			return true
+		}
+	}
+	return false
+}
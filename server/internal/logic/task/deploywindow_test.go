@@ -0,0 +1,85 @@
+package task
+
+import (
+	"testing"
+	"time"
+)
+
+// at returns the time of the weekday in the week of 2024-01-01, which is a Monday
+func at(day time.Weekday, hhmm string) time.Time {
+	t, err := time.Parse("15:04", hhmm)
+	if err != nil {
+		panic(err)
+	}
+	return time.Date(2024, 1, 1+(int(day)+6)%7, t.Hour(), t.Minute(), 0, 0, time.Local)
+}
+
+func TestParseDeployWindows(t *testing.T) {
+	tests := []struct {
+		name    string
+		windows string
+		want    int
+		wantErr bool
+	}{
+		{"empty", "", 0, false},
+		{"blank windows", " ; ", 0, false},
+		{"every day", "09:00-18:00", 1, false},
+		{"weekdays and hours", "Mon-Fri 09:00-18:00; Sat,Sun 10:00-12:00; 22:00-02:00", 3, false},
+		{"invalid weekday", "Monday 09:00-18:00", 0, true},
+		{"invalid range end", "Mon-Friday 09:00-18:00", 0, true},
+		{"invalid hours", "Mon 09:00", 0, true},
+		{"invalid time", "Mon 09:00-25:00", 0, true},
+		{"too many fields", "Mon 09:00-18:00 UTC", 0, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseDeployWindows(tt.windows)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseDeployWindows() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if len(got) != tt.want {
+				t.Errorf("parseDeployWindows() got %d windows, want %d", len(got), tt.want)
+			}
+		})
+	}
+}
+
+func TestInDeployWindows(t *testing.T) {
+	tests := []struct {
+		name    string
+		windows string
+		t       time.Time
+		want    bool
+	}{
+		{"no windows", "", at(time.Sunday, "03:00"), true},
+		{"in window", "Mon-Fri 09:00-18:00", at(time.Wednesday, "12:00"), true},
+		{"start is included", "Mon-Fri 09:00-18:00", at(time.Monday, "09:00"), true},
+		{"end is excluded", "Mon-Fri 09:00-18:00", at(time.Monday, "18:00"), false},
+		{"before window", "Mon-Fri 09:00-18:00", at(time.Monday, "08:59"), false},
+		{"other weekday", "Mon-Fri 09:00-18:00", at(time.Saturday, "12:00"), false},
+		{"weekday list", "Sat,Sun 10:00-12:00", at(time.Sunday, "11:00"), true},
+		{"range wraps the week", "Fri-Mon 10:00-12:00", at(time.Sunday, "11:00"), true},
+		{"outside wrapped range", "Fri-Mon 10:00-12:00", at(time.Wednesday, "11:00"), false},
+		{"second window", "Mon-Fri 09:00-18:00; Sat,Sun 10:00-12:00", at(time.Saturday, "10:30"), true},
+		{"every day crossing midnight before midnight", "22:00-02:00", at(time.Tuesday, "23:00"), true},
+		{"every day crossing midnight after midnight", "22:00-02:00", at(time.Tuesday, "01:00"), true},
+		{"every day crossing midnight outside", "22:00-02:00", at(time.Tuesday, "12:00"), false},
+		{"crossing midnight end is excluded", "22:00-02:00", at(time.Tuesday, "02:00"), false},
+		{"crossing midnight on start weekday", "Fri 22:00-02:00", at(time.Friday, "23:30"), true},
+		{"after midnight belongs to start weekday", "Fri 22:00-02:00", at(time.Saturday, "01:00"), true},
+		{"after midnight of start weekday is not in window", "Fri 22:00-02:00", at(time.Friday, "01:00"), false},
+		{"after midnight of sunday belongs to saturday", "Sat 22:00-02:00", at(time.Sunday, "01:00"), true},
+		{"equal start and end is the whole day", "Mon 08:00-08:00", at(time.Tuesday, "07:59"), true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			windows, err := parseDeployWindows(tt.windows)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := inDeployWindows(windows, tt.t); got != tt.want {
+				t.Errorf("inDeployWindows(%s, %s) = %v, want %v", tt.windows, tt.t.Format("Mon 15:04"), got, tt.want)
+			}
+		})
+	}
+}
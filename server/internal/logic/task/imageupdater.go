@@ -0,0 +1,205 @@
+package task
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"runtime/debug"
+	"time"
+
+	"github.com/Masterminds/semver/v3"
+	"github.com/hongyuxuan/lizardcd/common/constant"
+	commontypes "github.com/hongyuxuan/lizardcd/common/types"
+	"github.com/hongyuxuan/lizardcd/server/internal/svc"
+
+	"github.com/zeromicro/go-zero/core/logx"
+)
+
+const imageUpdaterTick = 30 * time.Second
+const imageUpdaterInterval = 300 // seconds
+
+// lastModifiedLayouts are the time formats of artifacts returned by repositories,
+// e.g. Harbor and DockerHub use RFC3339, Artifactory may use a zone without colon and S3 uses local time
+var lastModifiedLayouts = []string{time.RFC3339Nano, "2006-01-02T15:04:05.999999999Z0700", "2006-01-02 15:04:05"}
+
+type ImageUpdaterLogic struct {
+	logx.Logger
+	ctx      context.Context
+	svcCtx   *svc.ServiceContext
+	polledAt map[string]time.Time // by app_name
+}
+
+func NewImageUpdaterLogic(ctx context.Context, svcCtx *svc.ServiceContext) *ImageUpdaterLogic {
+	return &ImageUpdaterLogic{
+		Logger:   logx.WithContext(ctx),
+		ctx:      ctx,
+		svcCtx:   svcCtx,
+		polledAt: make(map[string]time.Time),
+	}
+}
+
+// Start polls the repositories of applications which enable image updater, each at its own interval
+func (l *ImageUpdaterLogic) Start() {
+	l.Logger.Info("Start image updater")
+	ticker := time.NewTicker(imageUpdaterTick)
+	defer ticker.Stop()
+	for range ticker.C {
+		var applications []commontypes.Application
+		if err := l.svcCtx.Sqlite.WithContext(context.WithValue(l.ctx, commontypes.TraceIDKey{}, "sqlite.ListApplication")).
+			Find(&applications).Error; err != nil {
+			l.Logger.Error(err)
+			continue
+		}
+		now := time.Now()
+		for _, application := range applications {
+			if !application.ImageUpdater.Enabled {
+				continue
+			}
+			interval := firstPositive(application.ImageUpdater.Interval, imageUpdaterInterval)
+			if now.Sub(l.polledAt[application.AppName]) < interval {
+				continue
+			}
+			l.polledAt[application.AppName] = now
+			if err := l.poll(application); err != nil {
+				l.Logger.Errorf("Image updater failed to poll application \"%s\": %v", application.AppName, err)
+			}
+		}
+	}
+}
+
+// poll records the newest tag of application if it is different from the last one, and deploys it in deploy mode.
+// The first tag found is only recorded, since it is unknown whether it has been deployed.
+func (l *ImageUpdaterLogic) poll(application commontypes.Application) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			l.Logger.Errorf("%+v\n\n%s", r, debug.Stack())
+			err = fmt.Errorf("panic: %v", r)
+		}
+	}()
+	artifacts, err := svc.NewRepoService(l.ctx, l.svcCtx).ListArtifacts(application, "")
+	if err != nil {
+		return
+	}
+	artifact, err := selectArtifact(artifacts, application.ImageUpdater)
+	if err != nil || artifact == nil {
+		return
+	}
+	var last commontypes.ImageUpdate
+	if err = l.svcCtx.Sqlite.WithContext(context.WithValue(l.ctx, commontypes.TraceIDKey{}, "sqlite.GetImageUpdate")).
+		Where("app_name = ?", application.AppName).Order("id desc").Limit(1).Find(&last).Error; err != nil {
+		return
+	}
+	if last.Tag == artifact.Tag {
+		return
+	}
+	// every replica of lizardcd-server polls the same applications, only the one which claims the new tag records and deploys it
+	update := commontypes.ImageUpdate{
+		AppName:     application.AppName,
+		Tag:         artifact.Tag,
+		PreviousTag: last.Tag,
+		ArtifactUrl: artifact.ArtifactUrl,
+		Mode:        application.ImageUpdater.Mode,
+		Tenant:      application.Tenant,
+		DetectAt:    time.Now(),
+	}
+	if update.Mode == "" {
+		update.Mode = constant.IMAGE_UPDATER_MODE_NOTIFY
+	}
+	var claimed bool
+	if claimed, err = l.claim(&update, last.Id); err != nil || !claimed {
+		return
+	}
+	if last.Tag == "" {
+		l.Logger.Infof("Image updater found the current version of application \"%s\": %s", application.AppName, artifact.Tag)
+		return
+	}
+	l.Logger.Infof("Image updater found new version of application \"%s\": %s -> %s", application.AppName, last.Tag, artifact.Tag)
+	if update.Mode != constant.IMAGE_UPDATER_MODE_DEPLOY {
+		return
+	}
+	// a failed deploy is recorded too, so it is not retried until a newer tag is found
+	if update.TaskId, err = l.deploy(application, artifact.ArtifactUrl); err != nil {
+		update.ErrMessage = err.Error()
+		l.Logger.Errorf("Image updater failed to deploy application \"%s\": %v", application.AppName, err)
+	}
+	return l.svcCtx.Sqlite.WithContext(context.WithValue(l.ctx, commontypes.TraceIDKey{}, "sqlite.UpdateImageUpdate")).
+		Model(&update).Updates(commontypes.ImageUpdate{TaskId: update.TaskId, ErrMessage: update.ErrMessage}).Error
+}
+
+// claim records update only if no update of the application is recorded after the last one whose id is lastId,
+// so a new tag is recorded and deployed once by all replicas. The id of update is set if it is claimed.
+func (l *ImageUpdaterLogic) claim(update *commontypes.ImageUpdate, lastId int) (bool, error) {
+	res := l.svcCtx.Sqlite.WithContext(context.WithValue(l.ctx, commontypes.TraceIDKey{}, "sqlite.CreateImageUpdate")).
+		Exec(`INSERT INTO image_update (app_name, tag, previous_tag, artifact_url, mode, task_id, err_message, tenant, detect_at)
+			SELECT ?, ?, ?, ?, ?, '', '', ?, ? WHERE NOT EXISTS (SELECT 1 FROM image_update WHERE app_name = ? AND id > ?)`,
+			update.AppName, update.Tag, update.PreviousTag, update.ArtifactUrl, update.Mode, update.Tenant, update.DetectAt, update.AppName, lastId)
+	if res.Error != nil || res.RowsAffected == 0 {
+		return false, res.Error
+	}
+	// the first update after lastId is the claimed one, since others are only recorded after it
+	err := l.svcCtx.Sqlite.WithContext(context.WithValue(l.ctx, commontypes.TraceIDKey{}, "sqlite.GetImageUpdate")).
+		Model(&commontypes.ImageUpdate{}).Select("id").Where("app_name = ? AND id > ?", update.AppName, lastId).Order("id").Limit(1).Scan(&update.Id).Error
+	return err == nil, err
+}
+
+func (l *ImageUpdaterLogic) deploy(application commontypes.Application, artifactUrl string) (taskId string, err error) {
+	req, err := NewDeployReq(application, constant.TRIGGER_TYPE_IMAGE_UPDATER, artifactUrl, []string{constant.TRIGGER_TYPE_IMAGE_UPDATER})
+	if err != nil {
+		return
+	}
+	resp, err := NewRunTaskLogic(WithPayloads(context.Background(), constant.TRIGGER_TYPE_IMAGE_UPDATER, application.Tenant), l.svcCtx).RunTask(req)
+	if err != nil {
+		return
+	}
+	return resp.Data.(map[string]string)["id"], nil
+}
+
+// selectArtifact returns the highest version matching the semver constraint, or the last modified tag if no constraint.
+// Tags are filtered by the tag filter first, nil is returned if no tag matches.
+func selectArtifact(artifacts []commontypes.ArtifactListRes, config commontypes.ImageUpdaterConfig) (*commontypes.ArtifactListRes, error) {
+	var filter *regexp.Regexp
+	if config.TagFilter != "" {
+		var err error
+		if filter, err = regexp.Compile(config.TagFilter); err != nil {
+			return nil, fmt.Errorf("invalid tag filter \"%s\": %v", config.TagFilter, err)
+		}
+	}
+	var constraint *semver.Constraints
+	if config.Semver != "" {
+		var err error
+		if constraint, err = semver.NewConstraint(config.Semver); err != nil {
+			return nil, fmt.Errorf("invalid semver constraint \"%s\": %v", config.Semver, err)
+		}
+	}
+	var selected *commontypes.ArtifactListRes
+	var selectedVersion *semver.Version
+	for i, artifact := range artifacts {
+		if filter != nil && !filter.MatchString(artifact.Tag) {
+			continue
+		}
+		if constraint == nil {
+			if selected == nil || parseLastModified(artifact.LastModified).After(parseLastModified(selected.LastModified)) {
+				selected = &artifacts[i]
+			}
+			continue
+		}
+		version, err := semver.NewVersion(artifact.Tag)
+		if err != nil || !constraint.Check(version) {
+			continue
+		}
+		if selectedVersion == nil || version.GreaterThan(selectedVersion) {
+			selected, selectedVersion = &artifacts[i], version
+		}
+	}
+	return selected, nil
+}
+
+// parseLastModified returns the last modified time of an artifact, zero if it is in none of lastModifiedLayouts
+func parseLastModified(s string) time.Time {
+	for _, layout := range lastModifiedLayouts {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t
+		}
+	}
+	return time.Time{}
+}
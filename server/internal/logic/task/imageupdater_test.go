@@ -0,0 +1,108 @@
+package task
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	commontypes "github.com/hongyuxuan/lizardcd/common/types"
+	"github.com/hongyuxuan/lizardcd/common/utils"
+	"github.com/hongyuxuan/lizardcd/server/internal/svc"
+)
+
+func TestSelectArtifact(t *testing.T) {
+	artifacts := []commontypes.ArtifactListRes{
+		{Tag: "v1.2.0", LastModified: "2024-03-01T10:00:00Z"},
+		{Tag: "v1.10.0", LastModified: "2024-02-01T10:00:00.123Z"},
+		{Tag: "v2.0.0-rc.1", LastModified: "2024-04-01T10:00:00Z"},
+		{Tag: "latest", LastModified: "2024-05-01T10:00:00Z"},
+		{Tag: "dev-abc123", LastModified: "2024-04-15T10:00:00+08:00"},
+	}
+	tests := []struct {
+		name      string
+		artifacts []commontypes.ArtifactListRes
+		config    commontypes.ImageUpdaterConfig
+		want      string // empty means no artifact is selected
+		wantErr   bool
+	}{
+		{"last modified without constraint", artifacts, commontypes.ImageUpdaterConfig{}, "latest", false},
+		{"last modified of filtered tags", artifacts, commontypes.ImageUpdaterConfig{TagFilter: `^dev-`}, "dev-abc123", false},
+		{"filter excludes latest", artifacts, commontypes.ImageUpdaterConfig{TagFilter: `^v`}, "v2.0.0-rc.1", false},
+		{"highest semver not by string order", artifacts, commontypes.ImageUpdaterConfig{Semver: ">=1.0.0"}, "v1.10.0", false},
+		{"semver constraint range", artifacts, commontypes.ImageUpdaterConfig{Semver: "~1.2"}, "v1.2.0", false},
+		{"semver prerelease", artifacts, commontypes.ImageUpdaterConfig{Semver: ">=2.0.0-0"}, "v2.0.0-rc.1", false},
+		{"semver with filter", artifacts, commontypes.ImageUpdaterConfig{Semver: ">=1.0.0", TagFilter: `^v1\.2`}, "v1.2.0", false},
+		{"no tag matches", artifacts, commontypes.ImageUpdaterConfig{Semver: ">=3.0.0"}, "", false},
+		{"no artifacts", nil, commontypes.ImageUpdaterConfig{}, "", false},
+		{"time zones are compared as times", []commontypes.ArtifactListRes{
+			{Tag: "a", LastModified: "2024-01-01T09:00:00+08:00"}, // 01:00 UTC
+			{Tag: "b", LastModified: "2024-01-01T02:00:00Z"},
+		}, commontypes.ImageUpdaterConfig{}, "b", false},
+		{"artifactory zone without colon", []commontypes.ArtifactListRes{
+			{Tag: "a", LastModified: "2024-01-01T10:00:00.618+0800"},
+			{Tag: "b", LastModified: "2024-01-01T09:00:00.000+0800"},
+		}, commontypes.ImageUpdaterConfig{}, "a", false},
+		{"s3 local time", []commontypes.ArtifactListRes{
+			{Tag: "a.tar.gz", LastModified: "2024-01-02 08:00:00"},
+			{Tag: "b.tar.gz", LastModified: "2024-01-10 08:00:00"},
+		}, commontypes.ImageUpdaterConfig{}, "b.tar.gz", false},
+		{"unparsable time is the oldest", []commontypes.ArtifactListRes{
+			{Tag: "a", LastModified: "unknown"},
+			{Tag: "b", LastModified: "2024-01-01T00:00:00Z"},
+		}, commontypes.ImageUpdaterConfig{}, "b", false},
+		{"invalid tag filter", artifacts, commontypes.ImageUpdaterConfig{TagFilter: `(`}, "", true},
+		{"invalid semver constraint", artifacts, commontypes.ImageUpdaterConfig{Semver: "not a constraint"}, "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := selectArtifact(tt.artifacts, tt.config)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("selectArtifact() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			var tag string
+			if got != nil {
+				tag = got.Tag
+			}
+			if tag != tt.want {
+				t.Errorf("selectArtifact() = %q, want %q", tag, tt.want)
+			}
+		})
+	}
+}
+
+func TestImageUpdaterClaim(t *testing.T) {
+	db := utils.NewSQLite(filepath.Join(t.TempDir(), "lizardcd.db"), "error")
+	if err := db.AutoMigrate(&commontypes.ImageUpdate{}); err != nil {
+		t.Fatal(err)
+	}
+	svcCtx := &svc.ServiceContext{Sqlite: db}
+	// two replicas find the same new tag after the same last update
+	replicas := []*ImageUpdaterLogic{NewImageUpdaterLogic(context.Background(), svcCtx), NewImageUpdaterLogic(context.Background(), svcCtx)}
+	newUpdate := func(tag, previous string) *commontypes.ImageUpdate {
+		return &commontypes.ImageUpdate{AppName: "app", Tag: tag, PreviousTag: previous, Mode: "deploy", Tenant: "dev", DetectAt: time.Now()}
+	}
+
+	first := newUpdate("v1", "")
+	if claimed, err := replicas[0].claim(first, 0); err != nil || !claimed {
+		t.Fatalf("claim() = %v, %v, want claimed", claimed, err)
+	}
+	if first.Id == 0 {
+		t.Fatal("claim() did not set the id of the claimed update")
+	}
+	if claimed, err := replicas[1].claim(newUpdate("v1", ""), 0); err != nil || claimed {
+		t.Fatalf("claim() by another replica = %v, %v, want not claimed", claimed, err)
+	}
+	second := newUpdate("v2", "v1")
+	if claimed, err := replicas[1].claim(second, first.Id); err != nil || !claimed {
+		t.Fatalf("claim() after the last update = %v, %v, want claimed", claimed, err)
+	}
+	if claimed, err := replicas[0].claim(newUpdate("v2", "v1"), first.Id); err != nil || claimed {
+		t.Fatalf("claim() of a stale last update = %v, %v, want not claimed", claimed, err)
+	}
+	var updates []commontypes.ImageUpdate
+	db.Order("id").Find(&updates)
+	if len(updates) != 2 || updates[0].Tag != "v1" || updates[1].Tag != "v2" || updates[1].Id != second.Id || !updates[1].DetectAt.Equal(second.DetectAt) {
+		t.Errorf("recorded updates = %+v", updates)
+	}
+}
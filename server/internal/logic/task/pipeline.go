@@ -0,0 +1,228 @@
+package task
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/hongyuxuan/lizardcd/common/constant"
+	"github.com/hongyuxuan/lizardcd/common/errorx"
+	commontypes "github.com/hongyuxuan/lizardcd/common/types"
+	"github.com/hongyuxuan/lizardcd/server/internal/types"
+	"github.com/samber/lo"
+)
+
+// submitPipeline saves a pipeline task and a stage task for each stage of the application, then runs the stages in order in background
+func (l *RunTaskLogic) submitPipeline(ctx context.Context, application commontypes.Application, task commontypes.TaskHistory, req *types.RunTaskReq) (err error) {
+	names := make(map[string]bool)
+	for _, stage := range application.Stages {
+		if stage.Name == "" || names[stage.Name] {
+			return errorx.NewError(http.StatusBadRequest, fmt.Sprintf("应用阶段名称不能为空或重复: \"%s\"", stage.Name), nil)
+		}
+		names[stage.Name] = true
+	}
+	// a workload belongs to the first matched stage
+	stageWorkloads := make([][]types.TaskWorkload, len(application.Stages))
+	for _, w := range req.Workloads {
+		_, i, ok := lo.FindIndexOf(application.Stages, func(stage commontypes.Stage) bool {
+			return stage.Match(commontypes.WorkLoad{Cluster: w.Cluster, Namespace: w.Namespace})
+		})
+		if !ok {
+			return errorx.NewError(http.StatusBadRequest, fmt.Sprintf("工作负载不属于任何阶段: cluster=%s namespace=%s workload=%s", w.Cluster, w.Namespace, w.WorkloadName), nil)
+		}
+		stageWorkloads[i] = append(stageWorkloads[i], w)
+	}
+
+	task.Stages = application.Stages
+	if err = l.svcCtx.Sqlite.WithContext(context.WithValue(ctx, commontypes.TraceIDKey{}, "sqlite.SaveTaskHistory")).Save(&task).Error; err != nil {
+		l.Logger.Error(err)
+		return
+	}
+	// delete stage tasks of the pipeline task when it is run again
+	var stageTaskIds []string
+	l.svcCtx.Sqlite.WithContext(context.WithValue(ctx, commontypes.TraceIDKey{}, "sqlite.ListStageTask")).Model(&commontypes.TaskHistory{}).Where("parent_id = ?", task.Id).Pluck("id", &stageTaskIds)
+	if len(stageTaskIds) > 0 {
+		l.svcCtx.Sqlite.WithContext(context.WithValue(ctx, commontypes.TraceIDKey{}, "sqlite.DeleteHistory")).Where("id IN ?", stageTaskIds).Delete(&commontypes.TaskHistory{})
+		l.svcCtx.Sqlite.WithContext(context.WithValue(ctx, commontypes.TraceIDKey{}, "sqlite.DeleteHistoryWorkload")).Where("task_history_id IN ?", stageTaskIds).Delete(&commontypes.TaskHistoryWorkload{})
+	}
+
+	for i, stage := range application.Stages {
+		if len(stageWorkloads[i]) == 0 { // stages without workloads are skipped
+			continue
+		}
+		stageTask := commontypes.TaskHistory{
+			Id:          uuid.New().String(),
+			AppName:     task.AppName,
+			TaskType:    task.TaskType,
+			Status:      constant.TASK_STATUS_INITIALIZE,
+			Tenant:      task.Tenant,
+			TriggerType: task.TriggerType,
+			InitAt:      sql.NullTime{Time: time.Now(), Valid: true},
+			Owner:       serverId,
+			LeaseUntil:  newLease(),
+			Labels:      task.Labels,
+			ParentId:    task.Id,
+			Stage:       stage.Name,
+		}
+		if err = l.svcCtx.Sqlite.WithContext(context.WithValue(ctx, commontypes.TraceIDKey{}, "sqlite.SaveTaskHistory")).Save(&stageTask).Error; err != nil {
+			l.Logger.Error(err)
+			return
+		}
+		stageReq := *req
+		stageReq.Workloads = stageWorkloads[i]
+		if _, err = l.createWorkloads(ctx, application, stageTask, &stageReq); err != nil {
+			l.Logger.Error(err)
+			return
+		}
+	}
+	go l.executePipeline(application, task)
+	return
+}
+
+// executePipeline runs the stage tasks of a pipeline task in order. Stage tasks which have already finished are skipped,
+// so a pipeline task can be resumed after lizardcd-server restarted.
+func (l *RunTaskLogic) executePipeline(application commontypes.Application, task commontypes.TaskHistory) {
+	opts := l.getTaskOptions(application)
+	// waiting for approval may take a long time, so a pipeline task has no timeout and each stage task has its own timeout
+	ctx, rt := newTaskContext(l.svcCtx.Sqlite, task.Id, 0)
+	defer rt.stop(task.Id)
+
+	var stageTasks []commontypes.TaskHistory
+	if err := l.svcCtx.Sqlite.Where("parent_id = ?", task.Id).Find(&stageTasks).Error; err != nil {
+		l.Logger.Error(err)
+		l.updateTask(task, commontypes.TaskHistory{
+			Status:     constant.TASK_STATUS_FINISHED,
+			Success:    sql.NullBool{Bool: false, Valid: true},
+			ErrMessage: err.Error(),
+			FinishAt:   sql.NullTime{Time: time.Now(), Valid: true},
+		})
+		return
+	}
+	stageIndex := func(name string) int {
+		_, i, _ := lo.FindIndexOf(task.Stages, func(stage commontypes.Stage) bool { return stage.Name == name })
+		return i
+	}
+	sort.Slice(stageTasks, func(i, j int) bool {
+		return stageIndex(stageTasks[i].Stage) < stageIndex(stageTasks[j].Stage)
+	})
+	l.updateTask(task, commontypes.TaskHistory{
+		Status:  constant.TASK_STATUS_RUNNING,
+		StartAt: sql.NullTime{Time: lo.Ternary(task.StartAt.Valid, task.StartAt.Time, time.Now()), Valid: true},
+	})
+
+	var failed []string
+	var reason string
+	halted := false
+	for _, stageTask := range stageTasks {
+		stage := task.Stages[stageIndex(stageTask.Stage)]
+		if !isTaskDone(stageTask.Status) {
+			switch {
+			case halted || ctx.Err() != nil:
+				l.updateTask(stageTask, commontypes.TaskHistory{
+					Status:     constant.TASK_STATUS_CANCELLED,
+					Success:    sql.NullBool{Bool: false, Valid: true},
+					ErrMessage: lo.Ternary(halted, "HALTED by failed stage", "CANCELLED"),
+					FinishAt:   sql.NullTime{Time: time.Now(), Valid: true},
+				})
+				continue
+			case stage.RequireApproval && !stageTask.ApproveAt.Valid && !l.waitApproval(ctx, opts, task, &stageTask):
+				// rejected or cancelled when waiting for approval
+			default:
+				l.executeStage(ctx, rt, application, task, &stageTask)
+			}
+		}
+		if !stageTask.Success.Valid || !stageTask.Success.Bool {
+			failed = append(failed, stage.Name)
+			if stageTask.Reason == constant.TASK_REASON_TIMEOUT {
+				reason = constant.TASK_REASON_TIMEOUT
+			}
+			// a stage which is rejected or cancelled before started always halts the pipeline
+			if !stage.ContinueOnFailure || !stageTask.StartAt.Valid || stageTask.Status == constant.TASK_STATUS_CANCELLED {
+				halted = true
+			}
+		}
+	}
+
+	status := constant.TASK_STATUS_FINISHED
+	if ctx.Err() != nil {
+		status = constant.TASK_STATUS_CANCELLED
+		reason = taskReason(ctx)
+	}
+	var errMessage string
+	if len(failed) > 0 {
+		errMessage = "Failed stages: " + strings.Join(failed, ", ")
+	}
+	l.updateTask(task, commontypes.TaskHistory{
+		Status:     status,
+		Reason:     lo.Ternary(len(failed) == 0, "", reason),
+		Success:    sql.NullBool{Bool: len(failed) == 0, Valid: true},
+		ErrMessage: errMessage,
+		FinishAt:   sql.NullTime{Time: time.Now(), Valid: true},
+	})
+	l.Logger.Infof("Pipeline task id=%s finished, failed stages: %v", task.Id, failed)
+}
+
+// waitApproval waits until the stage task is approved or rejected, it returns false if the stage task is rejected or the pipeline is cancelled
+func (l *RunTaskLogic) waitApproval(ctx context.Context, opts taskOptions, task commontypes.TaskHistory, stageTask *commontypes.TaskHistory) bool {
+	l.Logger.Infof("Pipeline task id=%s stage=%s is waiting for approval", task.Id, stageTask.Stage)
+	l.updateTask(*stageTask, commontypes.TaskHistory{Status: constant.TASK_STATUS_WAITING_APPROVAL})
+	l.updateTask(task, commontypes.TaskHistory{Status: constant.TASK_STATUS_WAITING_APPROVAL})
+	for {
+		// approval is saved to database, so it works after lizardcd-server restarted
+		if err := l.svcCtx.Sqlite.Where("id = ?", stageTask.Id).First(stageTask).Error; err != nil {
+			l.Logger.Error(err)
+		} else if stageTask.ApproveAt.Valid {
+			return !isTaskDone(stageTask.Status)
+		}
+		select {
+		case <-ctx.Done():
+			l.updateTask(*stageTask, commontypes.TaskHistory{
+				Status:     constant.TASK_STATUS_CANCELLED,
+				Success:    sql.NullBool{Bool: false, Valid: true},
+				ErrMessage: "CANCELLED",
+				FinishAt:   sql.NullTime{Time: time.Now(), Valid: true},
+			})
+			stageTask.Status = constant.TASK_STATUS_CANCELLED
+			stageTask.Success = sql.NullBool{Bool: false, Valid: true}
+			return false
+		case <-time.After(opts.pollInterval):
+		}
+	}
+}
+
+// executeStage runs a stage task and waits until it is done
+func (l *RunTaskLogic) executeStage(ctx context.Context, rt *runningTask, application commontypes.Application, task commontypes.TaskHistory, stageTask *commontypes.TaskHistory) {
+	l.Logger.Infof("Pipeline task id=%s run stage=%s task id=%s", task.Id, stageTask.Stage, stageTask.Id)
+	l.updateTask(task, commontypes.TaskHistory{Status: constant.TASK_STATUS_RUNNING})
+	var workloads []commontypes.TaskHistoryWorkload
+	if err := l.svcCtx.Sqlite.Where("task_history_id = ?", stageTask.Id).Find(&workloads).Error; err != nil {
+		l.Logger.Error(err)
+	}
+	// cancel the stage task when the pipeline task is cancelled
+	done := make(chan struct{})
+	go func() {
+		select {
+		case <-done:
+			return
+		case <-ctx.Done():
+		}
+		// the stage task may not be registered yet
+		for !cancelRunningTask(stageTask.Id, rt.rollback.Load()) {
+			select {
+			case <-done:
+				return
+			case <-time.After(time.Second):
+			}
+		}
+	}()
+	l.execute(application, *stageTask, workloads)
+	close(done)
+	if err := l.svcCtx.Sqlite.Where("id = ?", stageTask.Id).First(stageTask).Error; err != nil {
+		l.Logger.Error(err)
+	}
+}
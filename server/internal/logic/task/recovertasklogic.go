@@ -0,0 +1,121 @@
+package task
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/hongyuxuan/lizardcd/common/constant"
+	commontypes "github.com/hongyuxuan/lizardcd/common/types"
+	"github.com/hongyuxuan/lizardcd/server/internal/svc"
+
+	"github.com/zeromicro/go-zero/core/logx"
+)
+
+type RecoverTaskLogic struct {
+	logx.Logger
+	ctx    context.Context
+	svcCtx *svc.ServiceContext
+	runner *RunTaskLogic
+}
+
+func NewRecoverTaskLogic(ctx context.Context, svcCtx *svc.ServiceContext) *RecoverTaskLogic {
+	return &RecoverTaskLogic{
+		Logger: logx.WithContext(ctx),
+		ctx:    ctx,
+		svcCtx: svcCtx,
+		runner: NewRunTaskLogic(ctx, svcCtx),
+	}
+}
+
+// RecoverTask resumes unfinished tasks whose lease has expired, i.e. the lizardcd-server running them has stopped.
+// It keeps checking, so the tasks of a lizardcd-server which stops later are resumed by other lizardcd-servers.
+func (l *RecoverTaskLogic) RecoverTask() {
+	// sleep 10s, waiting for agents to be discovered from registry
+	time.Sleep(10 * time.Second)
+	for {
+		l.recover()
+		time.Sleep(taskLeaseDuration)
+	}
+}
+
+func (l *RecoverTaskLogic) recover() {
+	var tasks []commontypes.TaskHistory
+	if err := l.svcCtx.Sqlite.WithContext(context.WithValue(l.ctx, commontypes.TraceIDKey{}, "sqlite.ListUnfinishedTask")).
+		Preload("TaskHistoryWorkloads").
+		Where("status IN ?", unfinishedStatus).
+		Find(&tasks).Error; err != nil {
+		l.Logger.Error(err)
+		return
+	}
+	for _, task := range tasks {
+		if task.ParentId != "" { // stage tasks are resumed by their pipeline task
+			continue
+		}
+		if !l.claim(task, time.Now()) {
+			continue
+		}
+		var application commontypes.Application
+		if err := l.svcCtx.Sqlite.WithContext(context.WithValue(l.ctx, commontypes.TraceIDKey{}, "sqlite.GetApplication")).
+			Where("app_name = ?", task.AppName).First(&application).Error; err != nil {
+			l.Logger.Errorf("Failed to resume task id=%s: %v", task.Id, err)
+			l.setFailed(task, "应用不存在，任务无法恢复")
+			continue
+		}
+		if len(task.Stages) > 0 {
+			l.Logger.Infof("Resume pipeline task id=%s app_name=%s", task.Id, task.AppName)
+			go l.runner.executePipeline(application, task)
+			continue
+		}
+		workloads := task.TaskHistoryWorkloads
+		if len(workloads) == 0 {
+			l.Logger.Errorf("Failed to resume task id=%s: no workloads", task.Id)
+			l.setFailed(task, "任务没有工作负载，任务无法恢复")
+			continue
+		}
+		l.Logger.Infof("Resume task id=%s app_name=%s", task.Id, task.AppName)
+		go l.runner.execute(application, task, workloads)
+	}
+}
+
+// claim takes the task whose lease has expired at now, by updating its status and lease only if they are not changed,
+// so a task is resumed by one lizardcd-server. It returns false if the task is still owned by a lizardcd-server, or has been changed,
+// e.g. cancelled or claimed by others.
+func (l *RecoverTaskLogic) claim(task commontypes.TaskHistory, now time.Time) bool {
+	if task.LeaseUntil.Valid && task.LeaseUntil.Time.After(now) {
+		return false
+	}
+	// associations are not saved again when updating the task
+	task.TaskHistoryWorkloads = nil
+	tx := l.svcCtx.Sqlite.Model(&task).Where("status = ?", task.Status)
+	if task.LeaseUntil.Valid {
+		tx = tx.Where("lease_until = ?", task.LeaseUntil)
+	} else { // tasks created before leases
+		tx = tx.Where("lease_until IS NULL")
+	}
+	res := tx.Updates(commontypes.TaskHistory{
+		Status:     constant.TASK_STATUS_RECOVERED,
+		Owner:      serverId,
+		LeaseUntil: newLease(),
+	})
+	if res.Error != nil {
+		l.Logger.Errorf("Failed to claim task id=%s: %v", task.Id, res.Error)
+		return false
+	}
+	if res.RowsAffected == 0 {
+		return false
+	}
+	l.svcCtx.TaskEvents.PublishTask(task.Id)
+	return true
+}
+
+func (l *RecoverTaskLogic) setFailed(task commontypes.TaskHistory, errMessage string) {
+	task.TaskHistoryWorkloads = nil
+	l.svcCtx.Sqlite.Model(&task).Updates(commontypes.TaskHistory{
+		Status:     constant.TASK_STATUS_FINISHED,
+		Success:    sql.NullBool{Bool: false, Valid: true},
+		ErrMessage: errMessage,
+		FinishAt:   sql.NullTime{Time: time.Now(), Valid: true},
+	})
+	l.svcCtx.TaskEvents.PublishTask(task.Id)
+}
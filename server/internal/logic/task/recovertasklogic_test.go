@@ -0,0 +1,53 @@
+package task
+
+import (
+	"context"
+	"database/sql"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/hongyuxuan/lizardcd/common/constant"
+	commontypes "github.com/hongyuxuan/lizardcd/common/types"
+	"github.com/hongyuxuan/lizardcd/common/utils"
+	"github.com/hongyuxuan/lizardcd/server/internal/svc"
+)
+
+func TestRecoverTaskClaim(t *testing.T) {
+	db := utils.NewSQLite(filepath.Join(t.TempDir(), "lizardcd.db"), "error")
+	if err := db.AutoMigrate(&commontypes.TaskHistory{}, &commontypes.TaskHistoryWorkload{}); err != nil {
+		t.Fatal(err)
+	}
+	now := time.Now()
+	db.Create(&[]commontypes.TaskHistory{
+		{Id: "running", Status: constant.TASK_STATUS_RUNNING, Owner: "other", LeaseUntil: sql.NullTime{Time: now.Add(time.Minute), Valid: true}},
+		{Id: "expired", Status: constant.TASK_STATUS_RUNNING, Owner: "stopped", LeaseUntil: sql.NullTime{Time: now.Add(-time.Minute), Valid: true}},
+		{Id: "legacy", Status: constant.TASK_STATUS_RUNNING},
+	})
+	svcCtx := &svc.ServiceContext{Sqlite: db, TaskEvents: svc.NewTaskEventHub(db)}
+	// two replicas start at the same time and find the same unfinished tasks
+	replicas := []*RecoverTaskLogic{NewRecoverTaskLogic(context.Background(), svcCtx), NewRecoverTaskLogic(context.Background(), svcCtx)}
+	var tasks []commontypes.TaskHistory
+	db.Order("id").Find(&tasks)
+
+	for _, task := range tasks {
+		claimed := 0
+		for _, l := range replicas {
+			if l.claim(task, now) {
+				claimed++
+			}
+		}
+		want := 1
+		if task.Id == "running" {
+			want = 0
+		}
+		if claimed != want {
+			t.Errorf("task id=%s is claimed %d times, want %d", task.Id, claimed, want)
+		}
+	}
+	var expired commontypes.TaskHistory
+	db.First(&expired, "id = ?", "expired")
+	if expired.Status != constant.TASK_STATUS_RECOVERED || expired.Owner != serverId || !expired.LeaseUntil.Time.After(now) {
+		t.Errorf("claimed task = %s owner=%s lease_until=%v", expired.Status, expired.Owner, expired.LeaseUntil)
+	}
+}
@@ -0,0 +1,76 @@
+package task
+
+import (
+	"context"
+	"database/sql"
+	"net/http"
+	"time"
+
+	"github.com/hongyuxuan/lizardcd/common/constant"
+	"github.com/hongyuxuan/lizardcd/common/errorx"
+	commontypes "github.com/hongyuxuan/lizardcd/common/types"
+	"github.com/hongyuxuan/lizardcd/common/utils"
+	"github.com/hongyuxuan/lizardcd/server/internal/svc"
+	"github.com/hongyuxuan/lizardcd/server/internal/types"
+
+	"github.com/zeromicro/go-zero/core/logx"
+)
+
+type RejectTaskLogic struct {
+	logx.Logger
+	ctx    context.Context
+	svcCtx *svc.ServiceContext
+}
+
+func NewRejectTaskLogic(ctx context.Context, svcCtx *svc.ServiceContext) *RejectTaskLogic {
+	return &RejectTaskLogic{
+		Logger: logx.WithContext(ctx),
+		ctx:    ctx,
+		svcCtx: svcCtx,
+	}
+}
+
+func (l *RejectTaskLogic) RejectTask(req *types.ApproveTaskReq) (resp *types.Response, err error) {
+	username, _, _, _ := utils.GetPayload(l.ctx)
+	var stageTask commontypes.TaskHistory
+	if stageTask, err = getWaitingStageTask(l.ctx, l.svcCtx, req.Id); err != nil {
+		l.Logger.Error(err)
+		return
+	}
+	if err = authorizeTask(l.ctx, l.svcCtx, stageTask.Id, "approve"); err != nil {
+		l.Logger.Error(err)
+		return
+	}
+	errMessage := "REJECTED by " + username
+	if req.Reason != "" {
+		errMessage += ": " + req.Reason
+	}
+	// a rejected stage is finished without running, and halts the pipeline task
+	res := l.svcCtx.Sqlite.WithContext(context.WithValue(l.ctx, commontypes.TraceIDKey{}, "sqlite.RejectTaskHistory")).
+		Model(&stageTask).Where("status = ?", constant.TASK_STATUS_WAITING_APPROVAL).Updates(commontypes.TaskHistory{
+		Status:     constant.TASK_STATUS_FINISHED,
+		Success:    sql.NullBool{Bool: false, Valid: true},
+		ErrMessage: errMessage,
+		RejectedBy: username,
+		ApproveAt:  sql.NullTime{Time: time.Now(), Valid: true},
+		FinishAt:   sql.NullTime{Time: time.Now(), Valid: true},
+	})
+	if err = res.Error; err != nil {
+		l.Logger.Error(err)
+		return
+	}
+	if res.RowsAffected == 0 { // approved or rejected by others
+		return nil, errorx.NewError(http.StatusBadRequest, "任务不在等待审批状态", nil)
+	}
+	l.svcCtx.TaskEvents.PublishTask(stageTask.Id)
+	l.Logger.Infof("Stage=%s of pipeline task id=%s is rejected by %s", stageTask.Stage, stageTask.ParentId, username)
+	resp = &types.Response{
+		Code:    http.StatusOK,
+		Message: "已拒绝",
+		Data: map[string]string{
+			"id":    stageTask.Id,
+			"stage": stageTask.Stage,
+		},
+	}
+	return
+}
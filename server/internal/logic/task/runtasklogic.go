@@ -4,11 +4,11 @@ import (
 	"context"
 	"database/sql"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
 	"regexp"
 	"strings"
-	"sync"
 	"time"
 
 	"github.com/hongyuxuan/lizardcd/agent/lizardagent"
@@ -22,6 +22,9 @@ import (
 	"github.com/hongyuxuan/lizardcd/server/internal/svc"
 	"github.com/hongyuxuan/lizardcd/server/internal/types"
 	"github.com/oliveagle/jsonpath"
+	"github.com/samber/lo"
+	v1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
 
 	"github.com/zeromicro/go-zero/core/logx"
 
@@ -51,6 +54,17 @@ func (r ResultChan) ToString() string {
 	return fmt.Sprintf("Cluster=%s Namespace=%s WorkloadType=%s WorkloadName=%s Success=%v Err=%s", r.Cluster, r.Namespace, r.WorkloadType, r.WorkloadName, r.Success, r.Err)
 }
 
+func NewResultChan(taskWorkload commontypes.TaskHistoryWorkload, success bool, errMessage string) ResultChan {
+	return ResultChan{
+		Cluster:      taskWorkload.Workload.Cluster,
+		Namespace:    taskWorkload.Workload.Namespace,
+		WorkloadType: taskWorkload.Workload.WorkloadType,
+		WorkloadName: taskWorkload.Workload.WorkloadName,
+		Success:      success,
+		Err:          errMessage,
+	}
+}
+
 func NewRunTaskLogic(ctx context.Context, svcCtx *svc.ServiceContext) *RunTaskLogic {
 	return &RunTaskLogic{
 		Logger:      logx.WithContext(ctx),
@@ -63,12 +77,35 @@ func NewRunTaskLogic(ctx context.Context, svcCtx *svc.ServiceContext) *RunTaskLo
 	}
 }
 
+// reservedTriggerTypes are the trigger types of tasks triggered by lizardcd itself, which cannot be requested by users
+var reservedTriggerTypes = []string{constant.TRIGGER_TYPE_ROLLBACK, constant.TRIGGER_TYPE_SCHEDULER, constant.TRIGGER_TYPE_WEBHOOK, constant.TRIGGER_TYPE_IMAGE_UPDATER}
+
 func (l *RunTaskLogic) RunTask(req *types.RunTaskReq) (resp *types.Response, err error) {
-	_, _, tenant, _ := utils.GetPayload(l.ctx)
+	_, role, tenant, _ := utils.GetPayload(l.ctx)
 	id := uuid.New().String()
 	if req.Id != "" {
 		id = req.Id
 	}
+	if role != "" && lo.Contains(reservedTriggerTypes, req.TriggerType) {
+		err = errorx.NewError(http.StatusBadRequest, fmt.Sprintf("trigger_type不能是%s", req.TriggerType), nil)
+		return
+	}
+	if err = l.checkDeployWindows(tenant); err != nil {
+		return
+	}
+	// every workload is checked by the tenant namespaces and rbac policies of its cluster and namespace.
+	// Tasks triggered by lizardcd itself, e.g. scheduler and webhook, have an empty role and only deploy the workloads of the application,
+	// which are checked by the namespaces of the tenant of the application below.
+	for _, w := range req.Workloads {
+		if role != "" && w.Cluster != "" && w.Namespace != "" {
+			if err = l.svcCtx.Rbac.CheckNamespace(l.ctx, w.Cluster, w.Namespace); err != nil {
+				return
+			}
+		}
+		if err = l.svcCtx.Rbac.Authorize(l.ctx, w.Cluster, w.Namespace, "task", "run"); err != nil {
+			return
+		}
+	}
 	// get application info
 	var application commontypes.Application
 	if err = l.svcCtx.Sqlite.WithContext(context.WithValue(l.ctx, commontypes.TraceIDKey{}, "sqlite.GetApplication")).
@@ -76,30 +113,66 @@ func (l *RunTaskLogic) RunTask(req *types.RunTaskReq) (resp *types.Response, err
 		l.Logger.Error(err)
 		return
 	}
+	if err = svc.CheckApplication(l.ctx, application, lo.Map(req.Workloads, func(w types.TaskWorkload, _ int) commontypes.WorkLoad {
+		return commontypes.WorkLoad{Cluster: w.Cluster, Namespace: w.Namespace, WorkloadName: w.WorkloadName}
+	})); err != nil {
+		return
+	}
+	if role == "" {
+		if err = checkAppWorkloads(application, req.Workloads); err != nil {
+			return
+		}
+		for _, w := range req.Workloads {
+			if w.Cluster == "" || w.Namespace == "" {
+				continue
+			}
+			if err = l.svcCtx.Rbac.CheckTenantNamespace(l.ctx, application.Tenant, w.Cluster, w.Namespace); err != nil {
+				return
+			}
+		}
+	}
 	// create task
 	task := commontypes.TaskHistory{
 		Id:          id,
 		AppName:     req.AppName,
 		TaskType:    req.TaskType,
-		Status:      "initialize",
+		Status:      constant.TASK_STATUS_INITIALIZE,
 		Tenant:      tenant,
 		TriggerType: req.TriggerType,
 		InitAt:      sql.NullTime{Time: time.Now(), Valid: true},
+		Owner:       serverId,
+		LeaseUntil:  newLease(),
 		Labels:      req.Labels,
+		Strategy:    req.Strategy,
 	}
-	if err = l.svcCtx.Sqlite.WithContext(context.WithValue(l.ctx, commontypes.TraceIDKey{}, "sqlite.SaveTaskHistory")).Save(&task).Error; err != nil {
-		l.Logger.Error(err)
+	if task.Strategy == "" {
+		task.Strategy = application.Strategy
+	}
+	switch task.Strategy {
+	case constant.DEPLOY_STRATEGY_CANARY:
+		err = validateCanary(application, req)
+	case constant.DEPLOY_STRATEGY_BLUEGREEN:
+		err = validateBlueGreen(application, req)
+	}
+	if err != nil {
 		return
 	}
-	res := l.svcCtx.Sqlite.WithContext(context.WithValue(l.ctx, commontypes.TraceIDKey{}, "sqlite.DeleteHistoryWorkload")).Where("task_history_id = ?", id).Delete(&commontypes.TaskHistoryWorkload{})
-	l.Logger.Infof("Delete task_history_workload before task run, affect rows = %d", res.RowsAffected)
-
-	if application.DeployType == "容器" {
-		go l.executeWorkload(task, req.Workloads)
-	} else if application.DeployType == "虚拟机" {
-		go l.executeVm(application, task, req.Workloads)
-	} else if application.DeployType == "HTTP" {
-		go l.executeHttp(application, task, req.ArtifactUrl)
+	// subscribe before the task is submitted, so the result of a fast task is not missed
+	var ch chan svc.TaskEvent
+	if req.Wait {
+		ch = l.svcCtx.TaskEvents.Subscribe(task.Id)
+		defer l.svcCtx.TaskEvents.Unsubscribe(task.Id, ch)
+	}
+	if application.DeployType == constant.DEPLOY_TYPE_CONTAINER && len(application.Stages) > 0 {
+		err = l.submitPipeline(l.ctx, application, task, req)
+	} else {
+		err = l.submit(l.ctx, application, task, req)
+	}
+	if err != nil {
+		return
+	}
+	if req.Wait {
+		return l.wait(task.Id, ch, req.Timeout)
 	}
 
 	resp = &types.Response{
@@ -112,322 +185,586 @@ func (l *RunTaskLogic) RunTask(req *types.RunTaskReq) (resp *types.Response, err
 	return
 }
 
-func (l *RunTaskLogic) executeWorkload(task commontypes.TaskHistory, workloads []types.TaskWorkload) {
-	var ag lizardagent.LizardAgent
-	var err error
-	var wg sync.WaitGroup
-	wg.Add(len(workloads))
-	results := make([]chan ResultChan, len(workloads))
-	firstFail := false
-	for i, w := range workloads {
-		taskWorkload := commontypes.TaskHistoryWorkload{
+// checkDeployWindows rejects tasks outside the deploy windows of tenant
+func (l *RunTaskLogic) checkDeployWindows(tenant string) error {
+	setting, err := l.svcCtx.GetDeployWindows(tenant)
+	if err != nil {
+		l.Logger.Error(err)
+		return err
+	}
+	windows, err := parseDeployWindows(setting)
+	if err != nil {
+		l.Logger.Errorf("Invalid deploy windows of tenant %s: %v", tenant, err)
+		return errorx.NewError(http.StatusBadRequest, "租户发布时间窗口配置不正确: "+err.Error(), nil)
+	}
+	if !inDeployWindows(windows, time.Now()) {
+		return errorx.NewError(http.StatusForbidden, "当前不在租户允许的发布时间窗口内: "+setting, nil)
+	}
+	return nil
+}
+
+// checkAppWorkloads rejects the workloads not belonging to application
+func checkAppWorkloads(application commontypes.Application, workloads []types.TaskWorkload) error {
+	for _, w := range workloads {
+		if !lo.ContainsBy(application.Workload, func(item commontypes.WorkLoad) bool {
+			return item.Cluster == w.Cluster && item.Namespace == w.Namespace && item.WorkloadName == w.WorkloadName
+		}) {
+			return errorx.NewError(http.StatusForbidden, fmt.Sprintf("工作负载\"%s\"不属于应用\"%s\"", w.WorkloadName, application.AppName), nil)
+		}
+	}
+	return nil
+}
+
+// NewDeployReq builds a deploy task of the enabled workloads of application with an artifact, for the tasks not submitted by users
+func NewDeployReq(application commontypes.Application, triggerType, artifactUrl string, labels []string) (*types.RunTaskReq, error) {
+	req := &types.RunTaskReq{
+		AppName:     application.AppName,
+		TaskType:    constant.K8S_TASK_TYPE_DEPLOY,
+		TriggerType: triggerType,
+		Labels:      labels,
+	}
+	if application.DeployType == constant.DEPLOY_TYPE_HTTP {
+		req.ArtifactUrl = artifactUrl
+		return req, nil
+	}
+	for _, w := range application.Workload {
+		if !w.Enable {
+			continue
+		}
+		req.Workloads = append(req.Workloads, types.TaskWorkload{
+			Cluster:       w.Cluster,
+			Namespace:     w.Namespace,
+			WorkloadType:  w.WorkloadType,
+			WorkloadName:  w.WorkloadName,
+			ContainerName: w.ContainerName,
+			ArtifactUrl:   artifactUrl,
+		})
+	}
+	if len(req.Workloads) == 0 {
+		return nil, errorx.NewError(http.StatusBadRequest, fmt.Sprintf("应用 %s 没有启用的工作负载", application.AppName), nil)
+	}
+	return req, nil
+}
+
+// waitTimeout is the longest of waiting for a task, responding before the request timeout of /lizardcd/task/run and /lizardcd/task/events
+func waitTimeout(svcCtx *svc.ServiceContext) time.Duration {
+	return time.Duration(svcCtx.Config.Task.WaitTimeout) * time.Second * 9 / 10
+}
+
+// WithPayloads returns a context carrying the payloads of jwt, which RunTask gets the tenant from
+func WithPayloads(ctx context.Context, username, tenant string) context.Context {
+	return context.WithValue(ctx, "payloads", map[string]interface{}{
+		"username":  username,
+		"role":      "",
+		"tenant":    tenant,
+		"namespace": "",
+	})
+}
+
+// wait blocks until the task is done, or timeout which is limited by the request timeout of /lizardcd/task/run
+func (l *RunTaskLogic) wait(taskId string, ch chan svc.TaskEvent, timeout int) (resp *types.Response, err error) {
+	d := waitTimeout(l.svcCtx)
+	if timeout > 0 && time.Duration(timeout)*time.Second < d {
+		d = time.Duration(timeout) * time.Second
+	}
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	// events are dropped when the channel is full, so check the task status periodically as well
+	ticker := time.NewTicker(5 * time.Second)
+	defer ticker.Stop()
+	for done := false; !done; {
+		select {
+		case <-l.ctx.Done():
+			return nil, l.ctx.Err()
+		case <-timer.C:
+			return nil, errorx.NewError(http.StatusGatewayTimeout, "等待任务结束超时，任务仍在执行", map[string]string{
+				"id": taskId,
+			})
+		case event := <-ch:
+			if t, ok := event.Data.(commontypes.TaskHistory); ok {
+				done = isTaskDone(t.Status)
+			}
+		case <-ticker.C:
+			var t commontypes.TaskHistory
+			if l.svcCtx.Sqlite.Where("id = ?", taskId).First(&t).Error == nil {
+				done = isTaskDone(t.Status)
+			}
+		}
+	}
+
+	var task commontypes.TaskHistory
+	if err = l.svcCtx.Sqlite.WithContext(context.WithValue(l.ctx, commontypes.TraceIDKey{}, "sqlite.GetTaskHistory")).
+		Preload("TaskHistoryWorkloads").Where("id = ?", taskId).First(&task).Error; err != nil {
+		l.Logger.Error(err)
+		return
+	}
+	if task.Success.Valid && task.Success.Bool {
+		resp = &types.Response{
+			Code:    http.StatusOK,
+			Message: "任务执行成功",
+			Data:    task,
+		}
+		return
+	}
+	var failed []commontypes.TaskHistoryWorkload
+	for _, w := range task.TaskHistoryWorkloads {
+		if !w.Success.Valid || !w.Success.Bool {
+			failed = append(failed, w)
+		}
+	}
+	err = errorx.NewError(http.StatusInternalServerError, "任务执行失败", map[string]interface{}{
+		"id":               task.Id,
+		"status":           task.Status,
+		"err_message":      task.ErrMessage,
+		"failed_workloads": failed,
+	})
+	return
+}
+
+// submit saves the task and its workloads, then runs it in background
+func (l *RunTaskLogic) submit(ctx context.Context, application commontypes.Application, task commontypes.TaskHistory, req *types.RunTaskReq) (err error) {
+	if err = l.svcCtx.Sqlite.WithContext(context.WithValue(ctx, commontypes.TraceIDKey{}, "sqlite.SaveTaskHistory")).Save(&task).Error; err != nil {
+		l.Logger.Error(err)
+		return
+	}
+	res := l.svcCtx.Sqlite.WithContext(context.WithValue(ctx, commontypes.TraceIDKey{}, "sqlite.DeleteHistoryWorkload")).Where("task_history_id = ?", task.Id).Delete(&commontypes.TaskHistoryWorkload{})
+	l.Logger.Infof("Delete task_history_workload before task run, affect rows = %d", res.RowsAffected)
+
+	// workloads are saved before running, so the task can be resumed when lizardcd-server restarts
+	var workloads []commontypes.TaskHistoryWorkload
+	if workloads, err = l.createWorkloads(ctx, application, task, req); err != nil {
+		l.Logger.Error(err)
+		return
+	}
+	go l.execute(application, task, workloads)
+	return
+}
+
+func (l *RunTaskLogic) createWorkloads(ctx context.Context, application commontypes.Application, task commontypes.TaskHistory, req *types.RunTaskReq) (workloads []commontypes.TaskHistoryWorkload, err error) {
+	if application.DeployType == constant.DEPLOY_TYPE_HTTP {
+		var httpReq types.HttpDeployReq
+		json.Unmarshal([]byte(application.ExtraVars), &httpReq)
+		workloads = append(workloads, commontypes.TaskHistoryWorkload{
 			Workload: commontypes.WorkLoad{
-				Cluster:       w.Cluster,
-				Namespace:     w.Namespace,
-				WorkloadType:  w.WorkloadType,
-				WorkloadName:  w.WorkloadName,
-				ContainerName: w.ContainerName,
-				ArtifactUrl:   w.ArtifactUrl,
+				WorkloadType: application.DeployType,
+				WorkloadName: httpReq.HttpUrl,
+				ArtifactUrl:  req.ArtifactUrl,
 			},
 			TaskHistoryId: task.Id,
 			UpdateAt:      time.Now(),
-		}
-		l.svcCtx.Sqlite.Create(&taskWorkload)
-		// start to deploy
-		if ag, err = l.svcCtx.GetAgent(w.Cluster, w.Namespace); err != nil {
-			l.Logger.Error(err)
-			return
-		}
-		if task.TaskType == constant.K8S_TASK_TYPE_DEPLOY {
-			_, err = ag.PatchDeployment(context.Background(), &agent.PatchWorkloadRequest{
-				Namespace:    w.Namespace,
-				WorkloadName: w.WorkloadName,
-				Container:    w.ContainerName,
-				Image:        w.ArtifactUrl,
+		})
+	} else {
+		for _, w := range req.Workloads {
+			workloads = append(workloads, commontypes.TaskHistoryWorkload{
+				Workload: commontypes.WorkLoad{
+					Cluster:       w.Cluster,
+					Namespace:     w.Namespace,
+					WorkloadType:  w.WorkloadType,
+					WorkloadName:  w.WorkloadName,
+					ContainerName: w.ContainerName,
+					ArtifactUrl:   w.ArtifactUrl,
+				},
+				TaskHistoryId: task.Id,
+				UpdateAt:      time.Now(),
 			})
 		}
-		if task.TaskType == constant.K8S_TASK_TYPE_ROLLOUT {
-			_, err = ag.RolloutDeployment(context.Background(), &agent.RolloutWorkloadRequest{
-				Namespace:    w.Namespace,
-				WorkloadName: w.WorkloadName,
-			})
+	}
+	if len(workloads) == 0 {
+		return
+	}
+	err = l.svcCtx.Sqlite.WithContext(context.WithValue(ctx, commontypes.TraceIDKey{}, "sqlite.CreateHistoryWorkload")).Create(&workloads).Error
+	return
+}
+
+// taskOptions is the timeout and intervals of running a task
+type taskOptions struct {
+	timeout      time.Duration
+	initialDelay time.Duration
+	pollInterval time.Duration
+}
+
+// getTaskOptions resolves the task options of an application, which override tenant settings and the server defaults
+func (l *RunTaskLogic) getTaskOptions(application commontypes.Application) taskOptions {
+	timeout, initialDelay, pollInterval, err := l.svcCtx.GetTaskSettings(application.Tenant)
+	if err != nil {
+		l.Logger.Error(err)
+	}
+	c := l.svcCtx.Config.Task
+	return taskOptions{
+		timeout:      firstPositive(application.TaskTimeout, timeout, c.Timeout),
+		initialDelay: firstPositive(application.TaskInitialDelay, initialDelay, c.InitialDelay),
+		pollInterval: firstPositive(application.TaskPollInterval, pollInterval, c.PollInterval),
+	}
+}
+
+// firstPositive returns the first positive value of seconds as duration
+func firstPositive(seconds ...int64) time.Duration {
+	for _, s := range seconds {
+		if s > 0 {
+			return time.Duration(s) * time.Second
 		}
-		if err != nil {
-			l.Logger.Error(err)
-			firstFail = true
-			// update task_history
-			l.svcCtx.Sqlite.Model(&task).Updates(commontypes.TaskHistory{
-				Success:    sql.NullBool{Bool: false, Valid: true},
-				ErrMessage: err.Error(),
-				StartAt:    sql.NullTime{Time: time.Now(), Valid: true},
-			})
-			// update task_history_workload
-			l.svcCtx.Sqlite.Model(&taskWorkload).Updates(commontypes.TaskHistoryWorkload{
-				ErrMessage: err.Error(),
-				UpdateAt:   time.Now(),
-			})
+	}
+	return 0
+}
+
+// execute runs a task with its saved workloads. Workloads which have already finished are skipped,
+// and workloads which have already been deployed only continue to check their status.
+func (l *RunTaskLogic) execute(application commontypes.Application, task commontypes.TaskHistory, workloads []commontypes.TaskHistoryWorkload) {
+	if application.DeployType == constant.DEPLOY_TYPE_CONTAINER && task.Strategy == constant.DEPLOY_STRATEGY_CANARY {
+		l.executeCanary(application, task, workloads)
+	} else if application.DeployType == constant.DEPLOY_TYPE_CONTAINER && task.Strategy == constant.DEPLOY_STRATEGY_BLUEGREEN {
+		l.executeBlueGreen(application, task, workloads)
+	} else if application.DeployType == constant.DEPLOY_TYPE_CONTAINER {
+		l.executeWorkload(application, task, workloads)
+	} else if application.DeployType == constant.DEPLOY_TYPE_VM {
+		l.executeVm(application, task, workloads)
+	} else if application.DeployType == constant.DEPLOY_TYPE_HTTP && len(workloads) > 0 {
+		l.executeHttp(application, task, workloads[0])
+	}
+}
+
+func (l *RunTaskLogic) executeWorkload(application commontypes.Application, task commontypes.TaskHistory, workloads []commontypes.TaskHistoryWorkload) {
+	opts := l.getTaskOptions(application)
+	ctx, rt := newTaskContext(l.svcCtx.Sqlite, task.Id, opts.timeout)
+	defer rt.stop(task.Id)
+	results := l.rolloutWorkloads(ctx, opts, task, workloads)
+	success, cancelled := l.setTaskStatus(ctx, task, results, len(workloads))
+	if cancelled && rt.rollback.Load() {
+		l.rollback(application, task)
+	}
+	// a rollback task is not rolled back again
+	if !success && !cancelled && application.AutoRollback && task.RollbackOf == "" {
+		l.rollback(application, task)
+	}
+}
+
+// rolloutWorkloads deploys the workloads and checks their status in background, the result of each workload is sent to the returned channel
+func (l *RunTaskLogic) rolloutWorkloads(ctx context.Context, opts taskOptions, task commontypes.TaskHistory, workloads []commontypes.TaskHistoryWorkload) chan ResultChan {
+	results := make(chan ResultChan, len(workloads))
+	firstFail := false
+	for _, taskWorkload := range workloads {
+		w := taskWorkload.Workload
+		if taskWorkload.Success.Valid { // finished before lizardcd-server restarted
+			results <- NewResultChan(taskWorkload, taskWorkload.Success.Bool, taskWorkload.ErrMessage)
 			continue
-		} else {
+		}
+		if !taskWorkload.StartAt.Valid {
+			if ctx.Err() != nil { // task is cancelled before the workload is deployed
+				l.setStatus(taskWorkload, false, nil, taskError(ctx), results)
+				continue
+			}
+			// start to deploy
+			if err := l.deployWorkload(task.TaskType, taskWorkload); err != nil {
+				l.Logger.Error(err)
+				firstFail = true
+				// update task_history
+				l.updateTask(task, commontypes.TaskHistory{
+					Success:    sql.NullBool{Bool: false, Valid: true},
+					ErrMessage: err.Error(),
+					StartAt:    sql.NullTime{Time: time.Now(), Valid: true},
+				})
+				// update task_history_workload
+				l.setStatus(taskWorkload, false, nil, err, results)
+				continue
+			}
 			l.Logger.Infof("Patch deployments cluster=%s namespace=%s workload=%s container=%s image=%s", w.Cluster, w.Namespace, w.WorkloadName, w.ContainerName, w.ArtifactUrl)
+			l.setStarted(taskWorkload, nil)
 			if !firstFail {
-				l.svcCtx.Sqlite.Model(&task).Updates(commontypes.TaskHistory{
-					Status:  "running",
+				l.updateTask(task, commontypes.TaskHistory{
+					Status:  constant.TASK_STATUS_RUNNING,
 					StartAt: sql.NullTime{Time: time.Now(), Valid: true},
 				})
 			}
 		}
 		// get workload status in background
-		results[i] = make(chan ResultChan)
-		ctx, cancel := context.WithTimeout(context.Background(), time.Second*300)
-		defer cancel()
-		go l.getWorkloadStatus(ctx, taskWorkload, results[i], &wg)
+		go l.getWorkloadStatus(ctx, opts, taskWorkload, results)
 	}
-	var failedWorkload []string
-	for i := range workloads {
-		var res ResultChan
-		ch := results[i]
-		go func(ch_comsume chan ResultChan) {
-			res = <-ch_comsume
-			if !res.Success {
-				failedWorkload = append(failedWorkload, res.ToString())
-			}
-		}(ch)
+	return results
+}
+
+func (l *RunTaskLogic) deployWorkload(taskType string, taskWorkload commontypes.TaskHistoryWorkload) (err error) {
+	w := taskWorkload.Workload
+	var ag lizardagent.LizardAgent
+	if ag, err = l.svcCtx.GetAgent(w.Cluster, w.Namespace); err != nil {
+		return
 	}
-	wg.Wait()
-	if len(failedWorkload) == 0 {
-		l.Logger.Infof("Successfully run task, id=%s", task.Id)
-		l.svcCtx.Sqlite.Model(&task).Updates(commontypes.TaskHistory{
-			Status:   "finished",
-			Success:  sql.NullBool{Bool: true, Valid: true},
-			FinishAt: sql.NullTime{Time: time.Now(), Valid: true},
-			Expire:   time.Since(task.StartAt.Time).Truncate(time.Duration(1) * time.Millisecond).String(),
+	statefulset := w.WorkloadType == constant.K8S_RESOURCE_TYPE_STATEFULSETS
+	if taskType == constant.K8S_TASK_TYPE_DEPLOY {
+		// save the current image, so the workload can be rolled back
+		var image string
+		if image, err = l.getContainerImage(ag, w); err != nil {
+			return
+		}
+		l.updateWorkload(taskWorkload, commontypes.TaskHistoryWorkload{
+			PreviousArtifactUrl: image,
+			UpdateAt:            time.Now(),
 		})
+		patchReq := &agent.PatchWorkloadRequest{
+			Namespace:    w.Namespace,
+			WorkloadName: w.WorkloadName,
+			Container:    w.ContainerName,
+			Image:        w.ArtifactUrl,
+		}
+		if statefulset {
+			_, err = ag.PatchStatefulset(context.Background(), patchReq)
+		} else {
+			_, err = ag.PatchDeployment(context.Background(), patchReq)
+		}
+	}
+	if taskType == constant.K8S_TASK_TYPE_ROLLOUT {
+		rolloutReq := &agent.RolloutWorkloadRequest{
+			Namespace:    w.Namespace,
+			WorkloadName: w.WorkloadName,
+		}
+		if statefulset {
+			_, err = ag.RolloutStatefulset(context.Background(), rolloutReq)
+		} else {
+			_, err = ag.RolloutDeployment(context.Background(), rolloutReq)
+		}
+	}
+	return
+}
+
+// getContainerImage returns the current image of the workload's container
+func (l *RunTaskLogic) getContainerImage(ag lizardagent.LizardAgent, w commontypes.WorkLoad) (image string, err error) {
+	getReq := &agent.GetWorkloadRequest{
+		Namespace:    w.Namespace,
+		WorkloadName: w.WorkloadName,
+	}
+	var rpcResponse *agent.Response
+	var containers []corev1.Container
+	if w.WorkloadType == constant.K8S_RESOURCE_TYPE_STATEFULSETS {
+		if rpcResponse, err = ag.GetStatefulset(context.Background(), getReq); err != nil {
+			return
+		}
+		var statefulset *v1.StatefulSet
+		json.Unmarshal(rpcResponse.Data, &statefulset)
+		containers = statefulset.Spec.Template.Spec.Containers
 	} else {
-		l.Logger.Errorf("Failed run task, id=%s", task.Id)
-		failB, _ := json.Marshal(failedWorkload)
-		l.svcCtx.Sqlite.Model(&task).Updates(commontypes.TaskHistory{
-			Status:     "finished",
-			Success:    sql.NullBool{Bool: false, Valid: true},
-			ErrMessage: string(failB),
-			FinishAt:   sql.NullTime{Time: time.Now(), Valid: true},
-			Expire:     time.Since(task.StartAt.Time).Truncate(time.Duration(1) * time.Millisecond).String(),
-		})
+		if rpcResponse, err = ag.GetDeployment(context.Background(), getReq); err != nil {
+			return
+		}
+		var deployment *v1.Deployment
+		json.Unmarshal(rpcResponse.Data, &deployment)
+		containers = deployment.Spec.Template.Spec.Containers
+	}
+	for _, c := range containers {
+		if c.Name == w.ContainerName {
+			image = c.Image
+		}
 	}
+	return
 }
 
-func (l *RunTaskLogic) executeVm(application commontypes.Application, task commontypes.TaskHistory, workloads []types.TaskWorkload) {
+func (l *RunTaskLogic) executeVm(application commontypes.Application, task commontypes.TaskHistory, workloads []commontypes.TaskHistoryWorkload) {
 	var req types.VmDeployReq
 	json.Unmarshal([]byte(application.ExtraVars), &req)
 
-	var err error
-	var wg sync.WaitGroup
-	wg.Add(len(workloads))
-	results := make([]chan ResultChan, len(workloads))
+	opts := l.getTaskOptions(application)
+	ctx, rt := newTaskContext(l.svcCtx.Sqlite, task.Id, opts.timeout)
+	defer rt.stop(task.Id)
+	results := make(chan ResultChan, len(workloads))
 	firstFail := false
-	for i, w := range workloads {
-		taskWorkload := commontypes.TaskHistoryWorkload{
-			Workload: commontypes.WorkLoad{
-				WorkloadType: w.WorkloadType,
-				WorkloadName: w.WorkloadName,
-				ArtifactUrl:  w.ArtifactUrl,
-			},
-			TaskHistoryId: task.Id,
-			UpdateAt:      time.Now(),
-		}
-		l.svcCtx.Sqlite.Create(&taskWorkload)
-		// start to deploy
-		req.ArtifactUrl = w.ArtifactUrl
-		req.ArtifactHeader = map[string]string{"X-JFrog-Art-Api": application.Repo.RepoPassword}
-		req.Targets = []string{w.WorkloadName}
-		_, err = l.vmdeploy.Deploy(&req)
-		if err != nil {
-			l.Logger.Error(err)
-			firstFail = true
-			// update task_history
-			l.svcCtx.Sqlite.Model(&task).Updates(commontypes.TaskHistory{
-				Success:    sql.NullBool{Bool: false, Valid: true},
-				ErrMessage: err.Error(),
-				StartAt:    sql.NullTime{Time: time.Now(), Valid: true},
-			})
-			// update task_history_workload
-			l.svcCtx.Sqlite.Model(&taskWorkload).Updates(commontypes.TaskHistoryWorkload{
-				ErrMessage: err.Error(),
-				UpdateAt:   time.Now(),
-			})
+	repo, repoErr := svc.NewRepoService(l.ctx, l.svcCtx).Repository(application.Repo)
+	for _, taskWorkload := range workloads {
+		w := taskWorkload.Workload
+		if taskWorkload.Success.Valid { // finished before lizardcd-server restarted
+			results <- NewResultChan(taskWorkload, taskWorkload.Success.Bool, taskWorkload.ErrMessage)
 			continue
-		} else {
+		}
+		healthCheck := req.HealthCheck
+		if !taskWorkload.StartAt.Valid {
+			if ctx.Err() != nil { // task is cancelled before the workload is deployed
+				l.setStatus(taskWorkload, false, nil, taskError(ctx), results)
+				continue
+			}
+			// start to deploy
+			req.ArtifactUrl = w.ArtifactUrl
+			req.ArtifactHeader = map[string]string{"X-JFrog-Art-Api": repo.RepoPassword}
+			req.Targets = []string{w.WorkloadName}
+			err := repoErr
+			if err == nil {
+				_, err = l.vmdeploy.Deploy(&req)
+			}
+			if err != nil {
+				l.Logger.Error(err)
+				firstFail = true
+				// update task_history
+				l.updateTask(task, commontypes.TaskHistory{
+					Success:    sql.NullBool{Bool: false, Valid: true},
+					ErrMessage: err.Error(),
+					StartAt:    sql.NullTime{Time: time.Now(), Valid: true},
+				})
+				// update task_history_workload
+				l.setStatus(taskWorkload, false, nil, err, results)
+				continue
+			}
 			l.Logger.Infof("Execute start comamnd on vm host=%s", w.WorkloadName)
+			l.setStarted(taskWorkload, healthCheck)
 			if !firstFail {
-				l.svcCtx.Sqlite.Model(&task).Updates(commontypes.TaskHistory{
-					Status:  "running",
+				l.updateTask(task, commontypes.TaskHistory{
+					Status:  constant.TASK_STATUS_RUNNING,
 					StartAt: sql.NullTime{Time: time.Now(), Valid: true},
 				})
 			}
+		} else {
+			json.Unmarshal([]byte(taskWorkload.HealthCheck), &healthCheck)
 		}
 		// get workload status in background
-		results[i] = make(chan ResultChan)
-		ctx, cancel := context.WithTimeout(context.Background(), time.Second*300)
-		defer cancel()
-		go l.getVmStatus(ctx, taskWorkload, &req.HealthCheck, results[i], &wg)
-	}
-	var failedWorkload []string
-	for i := range workloads {
-		var res ResultChan
-		ch := results[i]
-		go func(ch_comsume chan ResultChan) {
-			res = <-ch_comsume
-			if !res.Success {
-				failedWorkload = append(failedWorkload, res.ToString())
-			}
-		}(ch)
+		go l.getVmStatus(ctx, opts, taskWorkload, healthCheck, results)
 	}
-	wg.Wait()
-	if len(failedWorkload) == 0 {
-		l.Logger.Infof("Successfully run task, id=%s", task.Id)
-		l.svcCtx.Sqlite.Model(&task).Updates(commontypes.TaskHistory{
-			Status:   "finished",
-			Success:  sql.NullBool{Bool: true, Valid: true},
-			FinishAt: sql.NullTime{Time: time.Now(), Valid: true},
-			Expire:   time.Since(task.StartAt.Time).Truncate(time.Duration(1) * time.Millisecond).String(),
-		})
-	} else {
-		l.Logger.Errorf("Failed run task, id=%s", task.Id)
-		failB, _ := json.Marshal(failedWorkload)
-		l.svcCtx.Sqlite.Model(&task).Updates(commontypes.TaskHistory{
-			Status:     "finished",
-			Success:    sql.NullBool{Bool: false, Valid: true},
-			ErrMessage: string(failB),
-			FinishAt:   sql.NullTime{Time: time.Now(), Valid: true},
-			Expire:     time.Since(task.StartAt.Time).Truncate(time.Duration(1) * time.Millisecond).String(),
-		})
+	if _, cancelled := l.setTaskStatus(ctx, task, results, len(workloads)); cancelled && rt.rollback.Load() {
+		l.Logger.Errorf("Rollback is not supported by vm task, id=%s", task.Id)
 	}
 }
 
-func (l *RunTaskLogic) executeHttp(application commontypes.Application, task commontypes.TaskHistory, artifactUrl string) {
-	var req types.HttpDeployReq
-	json.Unmarshal([]byte(application.ExtraVars), &req)
-	req.ArtifactUrl = artifactUrl
-	// update task_history_workload
-	taskWorkload := commontypes.TaskHistoryWorkload{
-		Workload: commontypes.WorkLoad{
-			WorkloadType: application.DeployType,
-			WorkloadName: req.HttpUrl,
-			ArtifactUrl:  artifactUrl,
-		},
-		TaskHistoryId: task.Id,
-		UpdateAt:      time.Now(),
+func (l *RunTaskLogic) executeHttp(application commontypes.Application, task commontypes.TaskHistory, taskWorkload commontypes.TaskHistoryWorkload) {
+	if taskWorkload.Success.Valid { // finished before lizardcd-server restarted
+		l.setHttpStatus(task, taskWorkload, constant.TASK_STATUS_FINISHED, "", taskWorkload.Success.Bool, taskWorkload.ErrMessage)
+		return
 	}
-	l.svcCtx.Sqlite.Create(&taskWorkload)
+	opts := l.getTaskOptions(application)
+	ctx, rt := newTaskContext(l.svcCtx.Sqlite, task.Id, opts.timeout)
+	defer rt.stop(task.Id)
+	var checkReq types.HttpCheckReq
+	if !taskWorkload.StartAt.Valid {
+		var req types.HttpDeployReq
+		json.Unmarshal([]byte(application.ExtraVars), &req)
+		req.ArtifactUrl = taskWorkload.Workload.ArtifactUrl
+		// the health check is saved with the encrypted headers
+		encryptedHeader := lo.Assign(req.HttpHeader)
 
-	var err error
-	var res *types.Response
-	if res, err = l.httpdeploy.Httpdeploy(&req); err != nil {
+		var err error
+		var res *types.Response
+		if err = utils.DecryptSecrets(req.HttpHeader); err != nil {
+			l.setHttpTerminated(task, taskWorkload, err)
+			return
+		}
+		if res, err = l.httpdeploy.Httpdeploy(&req); err != nil {
+			l.setHttpTerminated(task, taskWorkload, err)
+			return
+		}
+		re, _ := regexp.Compile(`.*(\{\{response(\$.*)\}\}).*`)
+		matches := re.FindStringSubmatch(req.HealthCheck.HttpPath)
+		if len(matches) >= 3 {
+			var lookup interface{}
+			if lookup, err = jsonpath.JsonPathLookup(res.Data, matches[2]); err != nil {
+				l.setHttpTerminated(task, taskWorkload, err)
+				return
+			}
+			lookupstr := utils.AnyToString(lookup)
+			req.HealthCheck.HttpPath = strings.ReplaceAll(req.HealthCheck.HttpPath, matches[1], lookupstr)
+			req.HealthCheck.HttpBody = strings.ReplaceAll(req.HealthCheck.HttpBody, matches[1], lookupstr)
+		}
+		checkReq = types.HttpCheckReq{
+			HttpUrl:    req.HttpUrl,
+			HttpHeader: encryptedHeader,
+			HttpCheck:  req.HealthCheck,
+		}
+		// health check is saved with the resolved response variables, so it can be resumed
+		l.setStarted(taskWorkload, checkReq)
 		// update task_history
-		l.svcCtx.Sqlite.Model(&task).Updates(commontypes.TaskHistory{
-			Status:     "terminated",
-			Success:    sql.NullBool{Bool: false, Valid: true},
-			ErrMessage: err.Error(),
-			StartAt:    sql.NullTime{Time: time.Now(), Valid: true},
-		})
-		// update task_history_workload
-		l.svcCtx.Sqlite.Model(&taskWorkload).Updates(commontypes.TaskHistoryWorkload{
-			ErrMessage: err.Error(),
-			UpdateAt:   time.Now(),
+		l.updateTask(task, commontypes.TaskHistory{
+			Status:  constant.TASK_STATUS_RUNNING,
+			StartAt: sql.NullTime{Time: time.Now(), Valid: true},
 		})
-		return
+		l.Logger.Infof("Successfully start http task, response: %s", res.Data)
+	} else {
+		json.Unmarshal([]byte(taskWorkload.HealthCheck), &checkReq)
 	}
-	re, _ := regexp.Compile(`.*(\{\{response(\$.*)\}\}).*`)
-	matches := re.FindStringSubmatch(req.HealthCheck.HttpPath)
-	if len(matches) >= 3 {
-		var lookup interface{}
-		if lookup, err = jsonpath.JsonPathLookup(res.Data, matches[2]); err != nil {
-			l.svcCtx.Sqlite.Model(&task).Updates(commontypes.TaskHistory{
-				Status:     "terminated",
-				ErrMessage: err.Error(),
-				StartAt:    sql.NullTime{Time: time.Now(), Valid: true},
-			})
-		}
-		lookupstr := utils.AnyToString(lookup)
-		req.HealthCheck.HttpPath = strings.ReplaceAll(req.HealthCheck.HttpPath, matches[1], lookupstr)
-		req.HealthCheck.HttpBody = strings.ReplaceAll(req.HealthCheck.HttpBody, matches[1], lookupstr)
+	if err := utils.DecryptSecrets(checkReq.HttpHeader); err != nil {
+		l.setHttpTerminated(task, taskWorkload, err)
+		return
 	}
-	// update task_workload
-	l.svcCtx.Sqlite.Model(&task).Updates(commontypes.TaskHistory{
-		Status:  "running",
-		StartAt: sql.NullTime{Time: time.Now(), Valid: true},
-	})
-	l.Logger.Infof("Successfully start http task, response: %s", res.Data)
 
-	// httpcheck in background
-	ctx, _ := context.WithTimeout(context.Background(), time.Second*300)
-	// defer cancel()
-	go l.getHttpStatus(ctx, taskWorkload, &types.HttpCheckReq{
-		HttpUrl:    req.HttpUrl,
-		HttpHeader: req.HttpHeader,
-		HttpCheck:  req.HealthCheck,
-	}, task)
+	// httpcheck
+	l.getHttpStatus(ctx, opts, taskWorkload, &checkReq, task)
 }
 
-func (l *RunTaskLogic) getWorkloadStatus(ctx context.Context, taskWorkload commontypes.TaskHistoryWorkload, result chan ResultChan, wg *sync.WaitGroup) {
+func (l *RunTaskLogic) getWorkloadStatus(ctx context.Context, opts taskOptions, taskWorkload commontypes.TaskHistoryWorkload, result chan ResultChan) {
 	var ag lizardagent.LizardAgent
 	var err error
-	// sleep 10s, waiting for kubernetes
-	time.Sleep(10 * time.Second)
+	var reasons string
+	// waiting for kubernetes
+	sleep(ctx, opts.initialDelay)
 	for {
 		select {
 		case <-ctx.Done():
-			l.Logger.Errorf("Cluster=%s namespace=%s workload_type=%s workload_name=%s running TIMEOUT and TERMINATED", taskWorkload.Workload.Cluster, taskWorkload.Workload.Namespace, taskWorkload.Workload.WorkloadType, taskWorkload.Workload.WorkloadName)
-			l.setStatus(taskWorkload, false, nil, errorx.NewDefaultError("TIMEOUT and TERMINATED"), result)
-			time.Sleep(1 * time.Second)
-			wg.Done()
+			l.Logger.Errorf("Cluster=%s namespace=%s workload_type=%s workload_name=%s running %v", taskWorkload.Workload.Cluster, taskWorkload.Workload.Namespace, taskWorkload.Workload.WorkloadType, taskWorkload.Workload.WorkloadName, taskError(ctx))
+			err = taskError(ctx)
+			if reasons != "" {
+				err = errorx.NewDefaultError(err.Error() + ": " + reasons)
+			}
+			l.setStatus(taskWorkload, false, nil, err, result)
 			return
 		default:
-			podStatus := true
+			// agent may not be discovered yet when the task is resumed after lizardcd-server restarted
 			if ag, err = l.svcCtx.GetAgent(taskWorkload.Workload.Cluster, taskWorkload.Workload.Namespace); err != nil {
 				l.Logger.Error(err)
-				return
+				sleep(ctx, opts.pollInterval)
+				continue
 			}
-			var rpcResponse *agent.Response
-			if rpcResponse, err = ag.GetPodStatus(context.Background(), &agent.GetWorkloadRequest{
-				Namespace:    taskWorkload.Workload.Namespace,
-				WorkloadName: taskWorkload.Workload.WorkloadName,
-			}); err != nil {
-				l.setStatus(taskWorkload, false, nil, err, result)
+			var status *commontypes.RolloutStatus
+			if status, err = l.getRolloutStatus(ag, taskWorkload.Workload); err != nil {
+				l.Logger.Error(err)
+				sleep(ctx, opts.pollInterval)
 				continue
 			}
-			var status *commontypes.WorkloadStatus
-			json.Unmarshal(rpcResponse.Data, &status)
-			l.Logger.Infof("Cluster=%s namespace=%s workload_type=%s workload_name=%s pod status=%v", taskWorkload.Workload.Cluster, taskWorkload.Workload.Namespace, taskWorkload.Workload.WorkloadType, taskWorkload.Workload.WorkloadName, status.Pods)
+			l.Logger.Infof("Cluster=%s namespace=%s workload_type=%s workload_name=%s rollout status: updated=%d/%d available=%d pod status=%v", taskWorkload.Workload.Cluster, taskWorkload.Workload.Namespace, taskWorkload.Workload.WorkloadType, taskWorkload.Workload.WorkloadName, status.UpdatedReplicas, status.Replicas, status.AvailableReplicas, status.Pods)
+			// failure reasons of pods, e.g. ImagePullBackOff, CrashLoopBackOff
+			var podReasons []string
 			for _, pod := range status.Pods {
-				if pod.Ready == "False" {
-					podStatus = false
+				if pod.Reason != "" {
+					podReasons = append(podReasons, fmt.Sprintf("%s: %s %s", pod.PodName, pod.Reason, pod.Message))
 				}
 			}
-			// status write to database
-			b, _ := json.Marshal(status.Pods)
-			l.svcCtx.Sqlite.Model(&taskWorkload).Updates(commontypes.TaskHistoryWorkload{
-				Status:   string(b),
-				UpdateAt: time.Now(),
-			})
-			if podStatus { // 任务结束
+			reasons = strings.Join(podReasons, "; ")
+			if status.Failed {
+				l.setStatus(taskWorkload, false, status.Pods, errorx.NewDefaultError(strings.TrimSuffix(status.Message+"; "+reasons, "; ")), result)
+				return
+			}
+			if status.Finished { // 任务结束
 				l.setStatus(taskWorkload, true, status.Pods, nil, result)
-				wg.Done()
-				break
+				return
 			}
-			time.Sleep(3 * time.Second)
+			// status write to database
+			b, _ := json.Marshal(status.Pods)
+			l.updateWorkload(taskWorkload, commontypes.TaskHistoryWorkload{
+				Status:     string(b),
+				ErrMessage: reasons,
+				UpdateAt:   time.Now(),
+			}, "Status", "ErrMessage", "UpdateAt")
+			sleep(ctx, opts.pollInterval)
 		}
 	}
 }
 
-func (l *RunTaskLogic) getVmStatus(ctx context.Context, taskWorkload commontypes.TaskHistoryWorkload, healthCheck *types.HealthCheck, result chan ResultChan, wg *sync.WaitGroup) {
-	time.Sleep(10 * time.Second)
+// getRolloutStatus gets the rollout progress of a deployment or statefulset from agent
+func (l *RunTaskLogic) getRolloutStatus(ag lizardagent.LizardAgent, w commontypes.WorkLoad) (status *commontypes.RolloutStatus, err error) {
+	getReq := &agent.GetWorkloadRequest{
+		Namespace:    w.Namespace,
+		WorkloadName: w.WorkloadName,
+	}
+	var rpcResponse *agent.Response
+	if w.WorkloadType == constant.K8S_RESOURCE_TYPE_STATEFULSETS {
+		rpcResponse, err = ag.GetStatefulsetRolloutStatus(context.Background(), getReq)
+	} else {
+		rpcResponse, err = ag.GetDeploymentRolloutStatus(context.Background(), getReq)
+	}
+	if err != nil {
+		return
+	}
+	json.Unmarshal(rpcResponse.Data, &status)
+	return
+}
+
+func (l *RunTaskLogic) getVmStatus(ctx context.Context, opts taskOptions, taskWorkload commontypes.TaskHistoryWorkload, healthCheck types.HealthCheck, result chan ResultChan) {
+	sleep(ctx, opts.initialDelay)
 	for {
 		select {
 		case <-ctx.Done():
-			l.Logger.Errorf("Vm host=%s running TIMEOUT and TERMINATED", taskWorkload.Workload.WorkloadName)
-			l.setStatus(taskWorkload, false, nil, errorx.NewDefaultError("TIMEOUT and TERMINATED"), result)
-			time.Sleep(1 * time.Second)
-			wg.Done()
+			l.Logger.Errorf("Vm host=%s running %v", taskWorkload.Workload.WorkloadName, taskError(ctx))
+			l.setStatus(taskWorkload, false, nil, taskError(ctx), result)
 			return
 		default:
 			if res, err := l.healthcheck.Healthcheck(&types.HealthCheckReq{
@@ -445,36 +782,38 @@ func (l *RunTaskLogic) getVmStatus(ctx context.Context, taskWorkload commontypes
 				} else {
 					l.Logger.Infof("Vm host healthcheck failed: %v", err)
 				}
-				l.svcCtx.Sqlite.Model(&taskWorkload).Updates(commontypes.TaskHistoryWorkload{
+				l.updateWorkload(taskWorkload, commontypes.TaskHistoryWorkload{
 					Status:   taskWorkload.Workload.WorkloadName + ": " + err.Error(),
 					UpdateAt: time.Now(),
 				})
 			} else {
 				l.Logger.Info(res.Message)
 				l.setStatus(taskWorkload, true, nil, nil, result)
-				time.Sleep(1 * time.Second)
-				wg.Done()
-				break
+				return
 			}
-			time.Sleep(3 * time.Second)
+			sleep(ctx, opts.pollInterval)
 		}
 	}
 }
 
-func (l *RunTaskLogic) getHttpStatus(ctx context.Context, taskWorkload commontypes.TaskHistoryWorkload, httpCheckReq *types.HttpCheckReq, task commontypes.TaskHistory) {
-	time.Sleep(10 * time.Second)
+func (l *RunTaskLogic) getHttpStatus(ctx context.Context, opts taskOptions, taskWorkload commontypes.TaskHistoryWorkload, httpCheckReq *types.HttpCheckReq, task commontypes.TaskHistory) {
+	sleep(ctx, opts.initialDelay)
 	for {
 		select {
 		case <-ctx.Done():
-			e := errorx.NewDefaultError("Http task running TIMEOUT and TERMINATED")
+			e := errorx.NewDefaultError("Http task running " + taskError(ctx).Error())
 			l.Logger.Error(e)
-			l.setHttpStatus(task, taskWorkload, false, e.Error())
+			status := constant.TASK_STATUS_FINISHED
+			if errors.Is(ctx.Err(), context.Canceled) {
+				status = constant.TASK_STATUS_CANCELLED
+			}
+			l.setHttpStatus(task, taskWorkload, status, taskReason(ctx), false, e.Error())
 			return
 		default:
 			res, err := l.httpcheck.Httpcheck(httpCheckReq)
 			if err != nil {
 				l.Logger.Infof("Failed run http task: id = %v", err)
-				l.setHttpStatus(task, taskWorkload, false, err.Error())
+				l.setHttpStatus(task, taskWorkload, constant.TASK_STATUS_FINISHED, "", false, err.Error())
 				return
 			} else {
 				data := res.Data.(types.HttpcheckResponse)
@@ -482,49 +821,176 @@ func (l *RunTaskLogic) getHttpStatus(ctx context.Context, taskWorkload commontyp
 					l.Logger.Infof("Http task is running: %+v", data)
 				} else {
 					l.Logger.Infof("Http task finished: %+v", data)
-					l.setHttpStatus(task, taskWorkload, data.Success, data.Message.(string))
+					l.setHttpStatus(task, taskWorkload, constant.TASK_STATUS_FINISHED, "", data.Success, data.Message.(string))
 					return
 				}
 			}
-			time.Sleep(3 * time.Second)
+			sleep(ctx, opts.pollInterval)
 		}
 	}
 }
 
-func (l *RunTaskLogic) setStatus(taskWorkload commontypes.TaskHistoryWorkload, success bool, pods []commontypes.PodStatus, err error, ch chan ResultChan) {
+// updateTask updates task_history and sends the task event
+func (l *RunTaskLogic) updateTask(task commontypes.TaskHistory, values commontypes.TaskHistory) {
+	l.svcCtx.Sqlite.Model(&task).Updates(values)
+	l.svcCtx.TaskEvents.PublishTask(task.Id)
+}
+
+// updateWorkload updates task_history_workload and sends the workload event, columns are selected to update zero values
+func (l *RunTaskLogic) updateWorkload(taskWorkload commontypes.TaskHistoryWorkload, values commontypes.TaskHistoryWorkload, columns ...string) {
+	db := l.svcCtx.Sqlite.Model(&taskWorkload)
+	if len(columns) > 0 {
+		db = db.Select(columns)
+	}
+	db.Updates(values)
+	l.svcCtx.TaskEvents.PublishWorkload(taskWorkload.TaskHistoryId, taskWorkload.Id)
+}
+
+func (l *RunTaskLogic) setStarted(taskWorkload commontypes.TaskHistoryWorkload, healthCheck interface{}) {
 	thw := commontypes.TaskHistoryWorkload{
+		StartAt:  sql.NullTime{Time: time.Now(), Valid: true},
 		UpdateAt: time.Now(),
 	}
-	r := ResultChan{
-		Cluster:      taskWorkload.Workload.Cluster,
-		Namespace:    taskWorkload.Workload.Namespace,
-		WorkloadType: taskWorkload.Workload.WorkloadType,
-		WorkloadName: taskWorkload.Workload.WorkloadName,
-		Success:      success,
+	if healthCheck != nil {
+		b, _ := json.Marshal(healthCheck)
+		thw.HealthCheck = string(b)
+	}
+	l.updateWorkload(taskWorkload, thw)
+}
+
+func (l *RunTaskLogic) setStatus(taskWorkload commontypes.TaskHistoryWorkload, success bool, pods []commontypes.PodStatus, err error, ch chan ResultChan) {
+	thw := commontypes.TaskHistoryWorkload{
+		Success:  sql.NullBool{Bool: success, Valid: true},
+		UpdateAt: time.Now(),
 	}
+	var errMessage string
 	if pods != nil {
 		status, _ := json.Marshal(pods)
 		thw.Status = string(status)
 	}
 	if err != nil {
-		thw.ErrMessage = err.Error()
-		r.Err = err.Error()
+		errMessage = err.Error()
+		thw.ErrMessage = errMessage
+	}
+	l.updateWorkload(taskWorkload, thw)
+	ch <- NewResultChan(taskWorkload, success, errMessage)
+}
+
+// setTaskStatus waits for all workloads to finish and saves the result of task
+func (l *RunTaskLogic) setTaskStatus(ctx context.Context, task commontypes.TaskHistory, results chan ResultChan, count int) (success, cancelled bool) {
+	var failedWorkload []string
+	for i := 0; i < count; i++ {
+		if res := <-results; !res.Success {
+			failedWorkload = append(failedWorkload, res.ToString())
+		}
 	}
-	l.svcCtx.Sqlite.Model(&taskWorkload).Updates(thw)
-	ch <- r
+	cancelled = len(failedWorkload) > 0 && errors.Is(ctx.Err(), context.Canceled)
+	status := constant.TASK_STATUS_FINISHED
+	if cancelled {
+		status = constant.TASK_STATUS_CANCELLED
+	}
+	success = len(failedWorkload) == 0
+	if success {
+		l.Logger.Infof("Successfully run task, id=%s", task.Id)
+		l.updateTask(task, commontypes.TaskHistory{
+			Status:   constant.TASK_STATUS_FINISHED,
+			Success:  sql.NullBool{Bool: true, Valid: true},
+			FinishAt: sql.NullTime{Time: time.Now(), Valid: true},
+			Expire:   time.Since(task.StartAt.Time).Truncate(time.Duration(1) * time.Millisecond).String(),
+		})
+	} else {
+		l.Logger.Errorf("Failed run task, id=%s", task.Id)
+		failB, _ := json.Marshal(failedWorkload)
+		l.updateTask(task, commontypes.TaskHistory{
+			Status:     status,
+			Reason:     taskReason(ctx),
+			Success:    sql.NullBool{Bool: false, Valid: true},
+			ErrMessage: string(failB),
+			FinishAt:   sql.NullTime{Time: time.Now(), Valid: true},
+			Expire:     time.Since(task.StartAt.Time).Truncate(time.Duration(1) * time.Millisecond).String(),
+		})
+	}
+	return
 }
 
-func (l *RunTaskLogic) setHttpStatus(task commontypes.TaskHistory, taskWorkload commontypes.TaskHistoryWorkload, success bool, errMessage string) {
+// rollback runs a new task which patches the deployed workloads of a task back to their previous images
+func (l *RunTaskLogic) rollback(application commontypes.Application, task commontypes.TaskHistory) {
+	var workloads []commontypes.TaskHistoryWorkload
+	if err := l.svcCtx.Sqlite.Where("task_history_id = ? AND start_at IS NOT NULL AND previous_artifact_url != ''", task.Id).Find(&workloads).Error; err != nil {
+		l.Logger.Error(err)
+		return
+	}
+	if len(workloads) == 0 {
+		l.Logger.Infof("No workloads need to rollback, id=%s", task.Id)
+		return
+	}
+	req := &types.RunTaskReq{
+		AppName:     task.AppName,
+		TaskType:    constant.K8S_TASK_TYPE_DEPLOY,
+		TriggerType: constant.TRIGGER_TYPE_ROLLBACK,
+		Labels:      task.Labels,
+	}
+	for _, taskWorkload := range workloads {
+		w := taskWorkload.Workload
+		req.Workloads = append(req.Workloads, types.TaskWorkload{
+			Cluster:       w.Cluster,
+			Namespace:     w.Namespace,
+			WorkloadType:  w.WorkloadType,
+			WorkloadName:  w.WorkloadName,
+			ContainerName: w.ContainerName,
+			ArtifactUrl:   taskWorkload.PreviousArtifactUrl,
+		})
+	}
+	rollbackTask := commontypes.TaskHistory{
+		Id:          uuid.New().String(),
+		AppName:     req.AppName,
+		TaskType:    req.TaskType,
+		Status:      constant.TASK_STATUS_INITIALIZE,
+		Tenant:      task.Tenant,
+		TriggerType: req.TriggerType,
+		InitAt:      sql.NullTime{Time: time.Now(), Valid: true},
+		Owner:       serverId,
+		LeaseUntil:  newLease(),
+		Labels:      req.Labels,
+		RollbackOf:  task.Id,
+	}
+	// request context of the original task may be done, so use a new one
+	if err := l.submit(context.Background(), application, rollbackTask, req); err != nil {
+		return
+	}
+	l.Logger.Infof("Rollback task id=%s by task id=%s", task.Id, rollbackTask.Id)
+}
+
+func (l *RunTaskLogic) setHttpTerminated(task commontypes.TaskHistory, taskWorkload commontypes.TaskHistoryWorkload, err error) {
+	l.Logger.Error(err)
+	// update task_history
+	l.updateTask(task, commontypes.TaskHistory{
+		Status:     constant.TASK_STATUS_TERMINATED,
+		Success:    sql.NullBool{Bool: false, Valid: true},
+		ErrMessage: err.Error(),
+		StartAt:    sql.NullTime{Time: time.Now(), Valid: true},
+	})
+	// update task_history_workload
+	l.updateWorkload(taskWorkload, commontypes.TaskHistoryWorkload{
+		Success:    sql.NullBool{Bool: false, Valid: true},
+		ErrMessage: err.Error(),
+		UpdateAt:   time.Now(),
+	})
+}
+
+func (l *RunTaskLogic) setHttpStatus(task commontypes.TaskHistory, taskWorkload commontypes.TaskHistoryWorkload, status, reason string, success bool, errMessage string) {
 	// update task_workload
-	l.svcCtx.Sqlite.Model(&task).Updates(commontypes.TaskHistory{
-		Status:     "finished",
+	l.updateTask(task, commontypes.TaskHistory{
+		Status:     status,
+		Reason:     reason,
 		Success:    sql.NullBool{Bool: success, Valid: true},
 		ErrMessage: errMessage,
 		FinishAt:   sql.NullTime{Time: time.Now(), Valid: true},
 		Expire:     time.Since(task.StartAt.Time).Truncate(time.Duration(1) * time.Millisecond).String(),
 	})
 	// update task_history_workload
-	l.svcCtx.Sqlite.Model(&taskWorkload).Updates(commontypes.TaskHistoryWorkload{
+	l.updateWorkload(taskWorkload, commontypes.TaskHistoryWorkload{
+		Success:    sql.NullBool{Bool: success, Valid: true},
 		ErrMessage: errMessage,
 		UpdateAt:   time.Now(),
 	})
@@ -0,0 +1,23 @@
+package task
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/hongyuxuan/lizardcd/common/constant"
+	"github.com/hongyuxuan/lizardcd/common/errorx"
+	"github.com/hongyuxuan/lizardcd/server/internal/svc"
+	"github.com/hongyuxuan/lizardcd/server/internal/types"
+)
+
+func TestRunTaskReservedTriggerType(t *testing.T) {
+	ctx := withPayloads("alice", constant.ROLE_READWRITE, "dev")
+	for _, triggerType := range reservedTriggerTypes {
+		t.Run(triggerType, func(t *testing.T) {
+			_, err := NewRunTaskLogic(ctx, &svc.ServiceContext{}).RunTask(&types.RunTaskReq{AppName: "web", TriggerType: triggerType})
+			if e, ok := err.(*errorx.LizardcdError); !ok || e.Code != http.StatusBadRequest {
+				t.Errorf("RunTask() error = %v, want 400", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,227 @@
+package task
+
+import (
+	"context"
+	"database/sql"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/hongyuxuan/lizardcd/common/constant"
+	"github.com/hongyuxuan/lizardcd/common/errorx"
+	commontypes "github.com/hongyuxuan/lizardcd/common/types"
+	"github.com/hongyuxuan/lizardcd/server/internal/svc"
+	"github.com/hongyuxuan/lizardcd/server/internal/types"
+	"github.com/robfig/cron/v3"
+
+	"github.com/zeromicro/go-zero/core/logx"
+)
+
+// scheduler runs the scheduled tasks of this lizardcd-server, entries are stored by scheduled task id
+var scheduler = struct {
+	sync.Mutex
+	cron    *cron.Cron
+	entries map[string]cron.EntryID
+}{
+	cron:    cron.New(),
+	entries: make(map[string]cron.EntryID),
+}
+
+const runAtLayout = "2006-01-02 15:04:05"
+
+// scheduleGracePeriod is how long a saved next run time is kept at startup after it has passed, it may be claimed by another replica
+const scheduleGracePeriod = time.Minute
+
+// onceSchedule runs only once at a time
+type onceSchedule struct {
+	at time.Time
+}
+
+// Next returns zero time after the time has passed, so cron never runs it again
+func (s onceSchedule) Next(t time.Time) time.Time {
+	if t.Before(s.at) {
+		return s.at
+	}
+	return time.Time{}
+}
+
+// parseSchedule returns the schedule of a cron expression, or a one-shot schedule if cron is empty
+func parseSchedule(spec string, runAt sql.NullTime) (cron.Schedule, error) {
+	if spec != "" {
+		schedule, err := cron.ParseStandard(spec)
+		if err != nil {
+			return nil, errorx.NewError(http.StatusBadRequest, "cron表达式不正确: "+err.Error(), nil)
+		}
+		return schedule, nil
+	}
+	if !runAt.Valid {
+		return nil, errorx.NewError(http.StatusBadRequest, "cron和run_at不能同时为空", nil)
+	}
+	return onceSchedule{at: runAt.Time}, nil
+}
+
+// parseRunAt parses the one-shot time in local timezone
+func parseRunAt(runAt string) (sql.NullTime, error) {
+	if runAt == "" {
+		return sql.NullTime{}, nil
+	}
+	t, err := time.ParseInLocation(runAtLayout, runAt, time.Local)
+	if err != nil {
+		return sql.NullTime{}, errorx.NewError(http.StatusBadRequest, "run_at格式必须为 "+runAtLayout, nil)
+	}
+	return sql.NullTime{Time: t, Valid: true}, nil
+}
+
+type SchedulerLogic struct {
+	logx.Logger
+	ctx    context.Context
+	svcCtx *svc.ServiceContext
+}
+
+func NewSchedulerLogic(ctx context.Context, svcCtx *svc.ServiceContext) *SchedulerLogic {
+	return &SchedulerLogic{
+		Logger: logx.WithContext(ctx),
+		ctx:    ctx,
+		svcCtx: svcCtx,
+	}
+}
+
+// Start loads the enabled scheduled tasks and starts the scheduler. One-shot tasks missed when lizardcd-server was down run at once.
+func (l *SchedulerLogic) Start() {
+	var scheduledTasks []commontypes.ScheduledTask
+	if err := l.svcCtx.Sqlite.WithContext(context.WithValue(l.ctx, commontypes.TraceIDKey{}, "sqlite.ListScheduledTask")).
+		Where("enabled = ?", true).Find(&scheduledTasks).Error; err != nil {
+		l.Logger.Error(err)
+	}
+	now := time.Now()
+	for _, st := range scheduledTasks {
+		if st.Cron == "" && st.NextRunAt.Valid && !st.NextRunAt.Time.After(now) {
+			l.Logger.Infof("Run overdue scheduled task id=%s name=%s run_at=%s", st.Id, st.Name, st.NextRunAt.Time.Format(runAtLayout))
+			go l.run(st.Id)
+			continue
+		}
+		next := l.register(st)
+		// the next run time saved by other replicas is kept, unless it has been missed
+		if !st.NextRunAt.Valid || st.NextRunAt.Time.Before(now.Add(-scheduleGracePeriod)) {
+			l.svcCtx.Sqlite.Model(&st).Select("NextRunAt").Updates(commontypes.ScheduledTask{NextRunAt: next})
+		}
+	}
+	l.Logger.Infof("Start scheduler with %d scheduled tasks", len(scheduledTasks))
+	scheduler.cron.Start()
+}
+
+// Schedule adds or replaces the scheduler entry of a scheduled task, and saves its next run time
+func (l *SchedulerLogic) Schedule(st commontypes.ScheduledTask) {
+	next := l.register(st)
+	l.svcCtx.Sqlite.Model(&st).Select("NextRunAt").Updates(commontypes.ScheduledTask{NextRunAt: next})
+}
+
+// register adds or replaces the scheduler entry of a scheduled task, and returns its next run time
+func (l *SchedulerLogic) register(st commontypes.ScheduledTask) (next sql.NullTime) {
+	l.Unschedule(st.Id)
+	if !st.Enabled {
+		return
+	}
+	schedule, err := parseSchedule(st.Cron, st.RunAt)
+	if err != nil {
+		l.Logger.Errorf("Failed to schedule task id=%s: %v", st.Id, err)
+		return
+	}
+	id := st.Id
+	scheduler.Lock()
+	scheduler.entries[id] = scheduler.cron.Schedule(schedule, cron.FuncJob(func() { l.run(id) }))
+	scheduler.Unlock()
+	if t := schedule.Next(time.Now()); !t.IsZero() {
+		next = sql.NullTime{Time: t, Valid: true}
+	}
+	return
+}
+
+// claim takes the run of a scheduled task due at its next_run_at, by moving next_run_at forward only if it is not changed.
+// Every replica of lizardcd-server fires the same schedules, only the one which claims the run submits the task.
+func (l *SchedulerLogic) claim(st commontypes.ScheduledTask, now time.Time) bool {
+	if !st.Enabled || !st.NextRunAt.Valid || st.NextRunAt.Time.After(now) {
+		return false // run by another replica, or rescheduled
+	}
+	var next sql.NullTime
+	if st.Cron != "" {
+		if schedule, err := parseSchedule(st.Cron, st.RunAt); err == nil {
+			if t := schedule.Next(now); !t.IsZero() {
+				next = sql.NullTime{Time: t, Valid: true}
+			}
+		}
+	}
+	res := l.svcCtx.Sqlite.Model(&commontypes.ScheduledTask{}).
+		Where("id = ? AND next_run_at = ?", st.Id, st.NextRunAt).
+		Updates(map[string]interface{}{
+			"next_run_at": next,
+			"last_run_at": sql.NullTime{Time: now, Valid: true},
+		})
+	if res.Error != nil {
+		l.Logger.Errorf("Failed to claim scheduled task id=%s: %v", st.Id, res.Error)
+		return false
+	}
+	return res.RowsAffected == 1
+}
+
+// Unschedule removes the scheduler entry of a scheduled task
+func (l *SchedulerLogic) Unschedule(id string) {
+	scheduler.Lock()
+	defer scheduler.Unlock()
+	if entryId, ok := scheduler.entries[id]; ok {
+		scheduler.cron.Remove(entryId)
+		delete(scheduler.entries, id)
+	}
+}
+
+// run submits a task of the scheduled task as the user who created it, if this lizardcd-server claims the run
+func (l *SchedulerLogic) run(id string) {
+	var st commontypes.ScheduledTask
+	if err := l.svcCtx.Sqlite.Where("id = ?", id).First(&st).Error; err != nil {
+		l.Logger.Errorf("Failed to get scheduled task id=%s: %v", id, err)
+		return
+	}
+	if !l.claim(st, time.Now()) {
+		l.Logger.Infof("Skip scheduled task id=%s name=%s, which is run by another lizardcd-server or rescheduled", st.Id, st.Name)
+		if st.Cron == "" {
+			l.Unschedule(st.Id)
+		}
+		return
+	}
+	req := &types.RunTaskReq{
+		AppName:     st.AppName,
+		TaskType:    st.TaskType,
+		TriggerType: constant.TRIGGER_TYPE_SCHEDULER,
+		Labels:      st.Labels,
+		ArtifactUrl: st.ArtifactUrl,
+		Strategy:    st.Strategy,
+	}
+	for _, w := range st.Workloads {
+		req.Workloads = append(req.Workloads, types.TaskWorkload{
+			Cluster:       w.Cluster,
+			Namespace:     w.Namespace,
+			WorkloadType:  w.WorkloadType,
+			WorkloadName:  w.WorkloadName,
+			ContainerName: w.ContainerName,
+			ArtifactUrl:   w.ArtifactUrl,
+		})
+	}
+	ctx := WithPayloads(context.Background(), st.CreatedBy, st.Tenant)
+	values := commontypes.ScheduledTask{
+		LastTaskId: st.LastTaskId,
+		Enabled:    st.Enabled && st.Cron != "", // a one-shot task is disabled after it runs
+	}
+	resp, err := NewRunTaskLogic(ctx, l.svcCtx).RunTask(req)
+	if err != nil {
+		l.Logger.Errorf("Failed to run scheduled task id=%s name=%s: %v", st.Id, st.Name, err)
+		values.LastError = err.Error()
+	} else {
+		values.LastTaskId = resp.Data.(map[string]string)["id"]
+		l.Logger.Infof("Run scheduled task id=%s name=%s, task id=%s", st.Id, st.Name, values.LastTaskId)
+	}
+	if !values.Enabled {
+		l.Unschedule(st.Id)
+	}
+	// next_run_at and last_run_at are saved by claim
+	l.svcCtx.Sqlite.Model(&st).Select("LastTaskId", "LastError", "Enabled").Updates(values)
+}
@@ -0,0 +1,166 @@
+package task
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"reflect"
+	"time"
+
+	"github.com/hongyuxuan/lizardcd/common/constant"
+	"github.com/hongyuxuan/lizardcd/common/errorx"
+	commontypes "github.com/hongyuxuan/lizardcd/common/types"
+	"github.com/hongyuxuan/lizardcd/common/utils"
+	"github.com/hongyuxuan/lizardcd/server/internal/svc"
+	"github.com/hongyuxuan/lizardcd/server/internal/types"
+
+	"github.com/samber/lo"
+	"github.com/zeromicro/go-zero/core/logx"
+	"gorm.io/gorm"
+)
+
+// taskEventsPollInterval is the interval of checking the changes of a streamed task in db
+const taskEventsPollInterval = 5 * time.Second
+
+type TaskEventsLogic struct {
+	logx.Logger
+	ctx    context.Context
+	svcCtx *svc.ServiceContext
+}
+
+func NewTaskEventsLogic(ctx context.Context, svcCtx *svc.ServiceContext) *TaskEventsLogic {
+	return &TaskEventsLogic{
+		Logger: logx.WithContext(ctx),
+		ctx:    ctx,
+		svcCtx: svcCtx,
+	}
+}
+
+// TaskEvents streams the progress of a task as server-sent events. The first event is the whole task with workloads,
+// then the task or workload is sent when its state changes. The stream is closed when the task is done, or before
+// the write timeout of http server, then clients should reconnect.
+func (l *TaskEventsLogic) TaskEvents(req *types.TaskIdReq, r *http.Request, w http.ResponseWriter) (err error) {
+	// requests without this header are cancelled by the timeout middleware of go-zero
+	if r.Header.Get("Accept") != "text/event-stream" {
+		return errorx.NewError(http.StatusBadRequest, "请设置请求头 Accept: text/event-stream", nil)
+	}
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		return errorx.NewDefaultError("streaming is not supported")
+	}
+	// subscribe before loading the task, so no state transition is missed
+	ch := l.svcCtx.TaskEvents.Subscribe(req.Id)
+	defer l.svcCtx.TaskEvents.Unsubscribe(req.Id, ch)
+
+	// users other than admin can only stream the tasks of their tenant
+	_, role, tenant, _ := utils.GetPayload(l.ctx)
+	var task commontypes.TaskHistory
+	tx := l.svcCtx.Sqlite.WithContext(context.WithValue(l.ctx, commontypes.TraceIDKey{}, "sqlite.GetTaskHistory")).
+		Preload("TaskHistoryWorkloads").Where("id = ?", req.Id)
+	if role != constant.ROLE_ADMIN {
+		tx = tx.Where("tenant = ?", tenant)
+	}
+	if err = tx.First(&task).Error; err != nil {
+		l.Logger.Error(err)
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			err = errorx.NewError(http.StatusNotFound, "任务不存在", nil)
+		}
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.WriteHeader(http.StatusOK)
+	writeEvent(w, svc.TASK_EVENT_TASK, task)
+	flusher.Flush()
+	if isTaskDone(task.Status) {
+		return nil
+	}
+
+	keepalive := time.NewTicker(15 * time.Second)
+	defer keepalive.Stop()
+	deadline := time.NewTimer(waitTimeout(l.svcCtx))
+	defer deadline.Stop()
+	// events are only published by the lizardcd-server running the task, so poll the task as well,
+	// which is run by other lizardcd-servers, or whose events are dropped when the channel is full
+	poll := time.NewTicker(taskEventsPollInterval)
+	defer poll.Stop()
+	workloads := task.TaskHistoryWorkloads
+	task.TaskHistoryWorkloads = nil
+	for {
+		select {
+		case <-l.ctx.Done():
+			return nil
+		case <-deadline.C:
+			return nil
+		case <-keepalive.C:
+			fmt.Fprint(w, ": keepalive\n\n")
+			flusher.Flush()
+		case event := <-ch:
+			writeEvent(w, event.Event, event.Data)
+			flusher.Flush()
+			switch data := event.Data.(type) {
+			case commontypes.TaskHistory:
+				if task = data; isTaskDone(task.Status) {
+					return nil
+				}
+			case commontypes.TaskHistoryWorkload:
+				workloads = setWorkload(workloads, data)
+			}
+		case <-poll.C:
+			if done := l.pollTask(w, &task, &workloads); done {
+				return nil
+			}
+			flusher.Flush()
+		}
+	}
+}
+
+// pollTask sends the workloads and the task which are changed since they are sent, it returns true if the task is done
+func (l *TaskEventsLogic) pollTask(w http.ResponseWriter, task *commontypes.TaskHistory, workloads *[]commontypes.TaskHistoryWorkload) bool {
+	var latest []commontypes.TaskHistoryWorkload
+	if err := l.svcCtx.Sqlite.Where("task_history_id = ?", task.Id).Find(&latest).Error; err != nil {
+		l.Logger.Error(err)
+		return false
+	}
+	for _, tw := range latest {
+		if old, ok := lo.Find(*workloads, func(item commontypes.TaskHistoryWorkload) bool { return item.Id == tw.Id }); !ok ||
+			old.Status != tw.Status || old.Success != tw.Success || old.ErrMessage != tw.ErrMessage {
+			writeEvent(w, svc.TASK_EVENT_WORKLOAD, tw)
+			*workloads = setWorkload(*workloads, tw)
+		}
+	}
+	var t commontypes.TaskHistory
+	if err := l.svcCtx.Sqlite.Where("id = ?", task.Id).First(&t).Error; err != nil {
+		l.Logger.Error(err)
+		return false
+	}
+	if t.Status != task.Status || !reflect.DeepEqual(t.Steps, task.Steps) {
+		writeEvent(w, svc.TASK_EVENT_TASK, t)
+		*task = t
+	}
+	return isTaskDone(task.Status)
+}
+
+// setWorkload replaces the workload of the same id in workloads, or appends it
+func setWorkload(workloads []commontypes.TaskHistoryWorkload, tw commontypes.TaskHistoryWorkload) []commontypes.TaskHistoryWorkload {
+	for i := range workloads {
+		if workloads[i].Id == tw.Id {
+			workloads[i] = tw
+			return workloads
+		}
+	}
+	return append(workloads, tw)
+}
+
+func writeEvent(w http.ResponseWriter, event string, data interface{}) {
+	b, _ := json.Marshal(data)
+	fmt.Fprintf(w, "event: %s\ndata: %s\n\n", event, b)
+}
+
+func isTaskDone(status string) bool {
+	return status == constant.TASK_STATUS_FINISHED || status == constant.TASK_STATUS_TERMINATED || status == constant.TASK_STATUS_CANCELLED
+}
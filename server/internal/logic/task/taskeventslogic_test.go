@@ -0,0 +1,52 @@
+package task
+
+import (
+	"context"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/hongyuxuan/lizardcd/common/constant"
+	commontypes "github.com/hongyuxuan/lizardcd/common/types"
+	"github.com/hongyuxuan/lizardcd/common/utils"
+	"github.com/hongyuxuan/lizardcd/server/internal/svc"
+)
+
+func TestPollTask(t *testing.T) {
+	db := utils.NewSQLite(filepath.Join(t.TempDir(), "lizardcd.db"), "error")
+	if err := db.AutoMigrate(&commontypes.TaskHistory{}, &commontypes.TaskHistoryWorkload{}); err != nil {
+		t.Fatal(err)
+	}
+	db.Create(&commontypes.TaskHistory{Id: "task", Status: constant.TASK_STATUS_RUNNING})
+	db.Create(&commontypes.TaskHistoryWorkload{Id: 1, TaskHistoryId: "task", Status: `"running"`})
+	l := NewTaskEventsLogic(context.Background(), &svc.ServiceContext{Sqlite: db})
+
+	// the task and workloads sent when the stream starts
+	var task commontypes.TaskHistory
+	db.Preload("TaskHistoryWorkloads").First(&task, "id = ?", "task")
+	workloads := task.TaskHistoryWorkloads
+	task.TaskHistoryWorkloads = nil
+
+	w := httptest.NewRecorder()
+	if l.pollTask(w, &task, &workloads) || w.Body.Len() > 0 {
+		t.Fatalf("pollTask() of unchanged task sent %q", w.Body.String())
+	}
+
+	// the task is run by another lizardcd-server, which publishes no event to this one
+	db.Model(&commontypes.TaskHistoryWorkload{Id: 1}).Update("status", `"success"`)
+	db.Model(&commontypes.TaskHistory{Id: "task"}).Updates(commontypes.TaskHistory{Steps: commontypes.TaskStepList{{Weight: 50}}})
+	w = httptest.NewRecorder()
+	if l.pollTask(w, &task, &workloads) {
+		t.Fatal("pollTask() of running task = done")
+	}
+	if body := w.Body.String(); !strings.Contains(body, "event: workload\n") || !strings.Contains(body, "event: task\n") {
+		t.Errorf("pollTask() of changed task sent %q", body)
+	}
+
+	db.Model(&commontypes.TaskHistory{Id: "task"}).Update("status", constant.TASK_STATUS_FINISHED)
+	w = httptest.NewRecorder()
+	if !l.pollTask(w, &task, &workloads) || !strings.Contains(w.Body.String(), constant.TASK_STATUS_FINISHED) {
+		t.Errorf("pollTask() of finished task sent %q", w.Body.String())
+	}
+}
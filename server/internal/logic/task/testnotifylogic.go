@@ -0,0 +1,56 @@
+package task
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"github.com/hongyuxuan/lizardcd/common/constant"
+	"github.com/hongyuxuan/lizardcd/common/errorx"
+	commontypes "github.com/hongyuxuan/lizardcd/common/types"
+	"github.com/hongyuxuan/lizardcd/common/utils"
+	"github.com/hongyuxuan/lizardcd/server/internal/svc"
+	"github.com/hongyuxuan/lizardcd/server/internal/types"
+	"gorm.io/gorm"
+
+	"github.com/zeromicro/go-zero/core/logx"
+)
+
+type TestNotifyLogic struct {
+	logx.Logger
+	ctx    context.Context
+	svcCtx *svc.ServiceContext
+}
+
+func NewTestNotifyLogic(ctx context.Context, svcCtx *svc.ServiceContext) *TestNotifyLogic {
+	return &TestNotifyLogic{
+		Logger: logx.WithContext(ctx),
+		ctx:    ctx,
+		svcCtx: svcCtx,
+	}
+}
+
+func (l *TestNotifyLogic) TestNotify(req *types.TaskIdReq) (resp *types.Response, err error) {
+	_, role, tenant, _ := utils.GetPayload(l.ctx)
+	tx := l.svcCtx.Sqlite.WithContext(context.WithValue(l.ctx, commontypes.TraceIDKey{}, "sqlite.GetNotifyChannel")).Where("id = ?", req.Id)
+	if role != constant.ROLE_ADMIN {
+		tx = tx.Where("tenant = ?", tenant)
+	}
+	var channel commontypes.NotifyChannel
+	if err = tx.First(&channel).Error; err != nil {
+		l.Logger.Error(err)
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			err = errorx.NewError(http.StatusNotFound, "通知渠道不存在", nil)
+		}
+		return
+	}
+	if err = l.svcCtx.Notifier.Send(channel, svc.NewTestMessage(channel.Tenant)); err != nil {
+		l.Logger.Errorf("Failed to send test notification to channel \"%s\": %v", channel.Name, err)
+		return nil, errorx.NewError(http.StatusBadRequest, "测试通知发送失败: "+err.Error(), nil)
+	}
+	resp = &types.Response{
+		Code:    http.StatusOK,
+		Message: "测试通知发送成功",
+	}
+	return
+}
@@ -0,0 +1,61 @@
+package task
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"github.com/hongyuxuan/lizardcd/common/constant"
+	"github.com/hongyuxuan/lizardcd/common/errorx"
+	commontypes "github.com/hongyuxuan/lizardcd/common/types"
+	"github.com/hongyuxuan/lizardcd/common/utils"
+	"github.com/hongyuxuan/lizardcd/server/internal/svc"
+	"github.com/hongyuxuan/lizardcd/server/internal/types"
+	"gorm.io/gorm"
+
+	"github.com/zeromicro/go-zero/core/logx"
+)
+
+type UpdateScheduleLogic struct {
+	logx.Logger
+	ctx    context.Context
+	svcCtx *svc.ServiceContext
+}
+
+func NewUpdateScheduleLogic(ctx context.Context, svcCtx *svc.ServiceContext) *UpdateScheduleLogic {
+	return &UpdateScheduleLogic{
+		Logger: logx.WithContext(ctx),
+		ctx:    ctx,
+		svcCtx: svcCtx,
+	}
+}
+
+func (l *UpdateScheduleLogic) UpdateSchedule(req *types.ScheduleTaskReq) (resp *types.Response, err error) {
+	_, role, tenant, _ := utils.GetPayload(l.ctx)
+	var st commontypes.ScheduledTask
+	tx := l.svcCtx.Sqlite.WithContext(context.WithValue(l.ctx, commontypes.TraceIDKey{}, "sqlite.GetScheduledTask")).Where("id = ?", req.Id)
+	if role != constant.ROLE_ADMIN {
+		tx = tx.Where("tenant = ?", tenant)
+	}
+	if err = tx.First(&st).Error; err != nil {
+		l.Logger.Error(err)
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			err = errorx.NewError(http.StatusNotFound, "定时任务不存在", nil)
+		}
+		return
+	}
+	if err = setScheduledTask(l.ctx, l.svcCtx, &st, req); err != nil {
+		return
+	}
+	if err = l.svcCtx.Sqlite.WithContext(context.WithValue(l.ctx, commontypes.TraceIDKey{}, "sqlite.SaveScheduledTask")).Save(&st).Error; err != nil {
+		l.Logger.Error(err)
+		return
+	}
+	NewSchedulerLogic(context.Background(), l.svcCtx).Schedule(st)
+	l.Logger.Infof("Update scheduled task id=%s name=%s cron=\"%s\" run_at=%s enabled=%v", st.Id, st.Name, st.Cron, req.RunAt, st.Enabled)
+	resp = &types.Response{
+		Code:    http.StatusOK,
+		Message: "定时任务更新成功",
+	}
+	return
+}
@@ -0,0 +1,117 @@
+package token
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/hongyuxuan/lizardcd/common/constant"
+	"github.com/hongyuxuan/lizardcd/common/errorx"
+	commontypes "github.com/hongyuxuan/lizardcd/common/types"
+	"github.com/hongyuxuan/lizardcd/common/utils"
+	"github.com/hongyuxuan/lizardcd/server/internal/svc"
+	"github.com/hongyuxuan/lizardcd/server/internal/types"
+	"github.com/samber/lo"
+	"gorm.io/gorm"
+
+	"github.com/zeromicro/go-zero/core/logx"
+)
+
+type CreatetokenLogic struct {
+	logx.Logger
+	ctx    context.Context
+	svcCtx *svc.ServiceContext
+}
+
+func NewCreatetokenLogic(ctx context.Context, svcCtx *svc.ServiceContext) *CreatetokenLogic {
+	return &CreatetokenLogic{
+		Logger: logx.WithContext(ctx),
+		ctx:    ctx,
+		svcCtx: svcCtx,
+	}
+}
+
+func (l *CreatetokenLogic) Createtoken(req *types.ApiTokenReq) (resp *types.Response, err error) {
+	if err = requireUser(l.ctx); err != nil {
+		return
+	}
+	username, role, userTenant, _ := utils.GetPayload(l.ctx)
+	token := commontypes.ApiToken{
+		Id:           uuid.New().String(),
+		Name:         req.Name,
+		Role:         req.Role,
+		Tenant:       req.Tenant,
+		Applications: lo.Uniq(req.Applications),
+		CreateBy:     username,
+		CreateAt:     time.Now(),
+	}
+	if token.Tenant == "" {
+		token.Tenant = userTenant
+	}
+	// users except admin can only create tokens of their tenant, and not higher than their role
+	if role != constant.ROLE_ADMIN && token.Tenant != userTenant {
+		return nil, errorx.NewError(http.StatusForbidden, fmt.Sprintf("不能创建租户\"%s\"的API令牌", token.Tenant), nil)
+	}
+	if roleLevels[token.Role] > roleLevels[role] {
+		return nil, errorx.NewError(http.StatusForbidden, "不能创建权限高于自己的API令牌", nil)
+	}
+	if req.ExpireAt != "" {
+		var expireAt time.Time
+		if expireAt, err = time.ParseInLocation(expireAtLayout, req.ExpireAt, time.Local); err != nil {
+			return nil, errorx.NewError(http.StatusBadRequest, fmt.Sprintf("过期时间格式错误，应为%s", expireAtLayout), nil)
+		}
+		if expireAt.Before(token.CreateAt) {
+			return nil, errorx.NewError(http.StatusBadRequest, "过期时间不能早于当前时间", nil)
+		}
+		token.ExpireAt = sql.NullTime{Time: expireAt, Valid: true}
+	}
+
+	var tenant commontypes.Tenant
+	if err = l.svcCtx.Sqlite.WithContext(context.WithValue(l.ctx, commontypes.TraceIDKey{}, "sqlite.GetTenant")).
+		Where("tenant_name = ?", token.Tenant).First(&tenant).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			err = errorx.NewError(http.StatusBadRequest, fmt.Sprintf("租户\"%s\"不存在", token.Tenant), nil)
+		}
+		return
+	}
+	if len(token.Applications) > 0 {
+		var apps []string
+		if err = l.svcCtx.Sqlite.WithContext(context.WithValue(l.ctx, commontypes.TraceIDKey{}, "sqlite.ListApplication")).
+			Model(&commontypes.Application{}).Where("tenant = ? AND app_name IN ?", token.Tenant, []string(token.Applications)).Pluck("app_name", &apps).Error; err != nil {
+			l.Logger.Error(err)
+			return
+		}
+		if missing, _ := lo.Difference(token.Applications, apps); len(missing) > 0 {
+			return nil, errorx.NewError(http.StatusBadRequest, fmt.Sprintf("租户\"%s\"中不存在应用%v", token.Tenant, missing), nil)
+		}
+	}
+	namespaces, err := tenantNamespaces(tenant)
+	if err != nil {
+		l.Logger.Error(err)
+		return
+	}
+
+	if err = l.svcCtx.Sqlite.WithContext(context.WithValue(l.ctx, commontypes.TraceIDKey{}, "sqlite.CreateApiToken")).Create(&token).Error; err != nil {
+		l.Logger.Error(err)
+		return nil, errorx.NewDefaultError("Failed to create api token \"%s\": %v", token.Name, err)
+	}
+	var accessToken string
+	if accessToken, err = l.svcCtx.ApiTokens.Sign(token, namespaces); err != nil {
+		l.Logger.Error(err)
+		return
+	}
+	l.Logger.Infof("Create api token \"%s\" of tenant=%s role=%s applications=%v", token.Name, token.Tenant, token.Role, token.Applications)
+	resp = &types.Response{
+		Code: http.StatusOK,
+		Data: map[string]interface{}{
+			"token":        token,
+			"access_token": accessToken,
+		},
+		Message: "API令牌创建成功，令牌只显示一次，请妥善保存",
+	}
+	return
+}
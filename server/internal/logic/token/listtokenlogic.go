@@ -0,0 +1,49 @@
+package token
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/hongyuxuan/lizardcd/common/constant"
+	commontypes "github.com/hongyuxuan/lizardcd/common/types"
+	"github.com/hongyuxuan/lizardcd/common/utils"
+	"github.com/hongyuxuan/lizardcd/server/internal/svc"
+	"github.com/hongyuxuan/lizardcd/server/internal/types"
+
+	"github.com/zeromicro/go-zero/core/logx"
+)
+
+type ListtokenLogic struct {
+	logx.Logger
+	ctx    context.Context
+	svcCtx *svc.ServiceContext
+}
+
+func NewListtokenLogic(ctx context.Context, svcCtx *svc.ServiceContext) *ListtokenLogic {
+	return &ListtokenLogic{
+		Logger: logx.WithContext(ctx),
+		ctx:    ctx,
+		svcCtx: svcCtx,
+	}
+}
+
+func (l *ListtokenLogic) Listtoken(req *types.ListApiTokenReq) (resp *types.Response, err error) {
+	_, role, tenant, _ := utils.GetPayload(l.ctx)
+	tx := l.svcCtx.Sqlite.WithContext(context.WithValue(l.ctx, commontypes.TraceIDKey{}, "sqlite.ListApiToken")).Model(&commontypes.ApiToken{})
+	// users except admin can only see the tokens of their tenant
+	if role != constant.ROLE_ADMIN {
+		tx = tx.Where("tenant = ?", tenant)
+	} else if req.Tenant != "" {
+		tx = tx.Where("tenant = ?", req.Tenant)
+	}
+	tokens := []commontypes.ApiToken{}
+	if err = tx.Order("create_at desc").Find(&tokens).Error; err != nil {
+		l.Logger.Error(err)
+		return
+	}
+	resp = &types.Response{
+		Code: http.StatusOK,
+		Data: tokens,
+	}
+	return
+}
@@ -0,0 +1,59 @@
+package token
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"github.com/hongyuxuan/lizardcd/common/constant"
+	"github.com/hongyuxuan/lizardcd/common/errorx"
+	commontypes "github.com/hongyuxuan/lizardcd/common/types"
+	"github.com/hongyuxuan/lizardcd/common/utils"
+	"github.com/hongyuxuan/lizardcd/server/internal/svc"
+	"github.com/hongyuxuan/lizardcd/server/internal/types"
+	"gorm.io/gorm"
+
+	"github.com/zeromicro/go-zero/core/logx"
+)
+
+type RevoketokenLogic struct {
+	logx.Logger
+	ctx    context.Context
+	svcCtx *svc.ServiceContext
+}
+
+func NewRevoketokenLogic(ctx context.Context, svcCtx *svc.ServiceContext) *RevoketokenLogic {
+	return &RevoketokenLogic{
+		Logger: logx.WithContext(ctx),
+		ctx:    ctx,
+		svcCtx: svcCtx,
+	}
+}
+
+func (l *RevoketokenLogic) Revoketoken(req *types.ApiTokenIdReq) (resp *types.Response, err error) {
+	if err = requireUser(l.ctx); err != nil {
+		return
+	}
+	_, role, tenant, _ := utils.GetPayload(l.ctx)
+	var token commontypes.ApiToken
+	tx := l.svcCtx.Sqlite.WithContext(context.WithValue(l.ctx, commontypes.TraceIDKey{}, "sqlite.GetApiToken")).Where("id = ?", req.Id)
+	if role != constant.ROLE_ADMIN {
+		tx = tx.Where("tenant = ?", tenant)
+	}
+	if err = tx.First(&token).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			err = errorx.NewError(http.StatusNotFound, "API令牌不存在", nil)
+		}
+		return
+	}
+	if err = l.svcCtx.Sqlite.WithContext(context.WithValue(l.ctx, commontypes.TraceIDKey{}, "sqlite.DeleteApiToken")).Delete(&token).Error; err != nil {
+		l.Logger.Error(err)
+		return
+	}
+	l.Logger.Infof("Revoke api token \"%s\" of tenant=%s", token.Name, token.Tenant)
+	resp = &types.Response{
+		Code:    http.StatusOK,
+		Message: "API令牌已吊销",
+	}
+	return
+}
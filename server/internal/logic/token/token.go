@@ -0,0 +1,43 @@
+package token
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/hongyuxuan/lizardcd/common/constant"
+	"github.com/hongyuxuan/lizardcd/common/errorx"
+	commontypes "github.com/hongyuxuan/lizardcd/common/types"
+	"github.com/samber/lo"
+)
+
+const expireAtLayout = "2006-01-02 15:04:05"
+
+// roleLevels orders the roles, a user cannot create tokens of a higher role
+var roleLevels = map[string]int{
+	constant.ROLE_READONLY:  1,
+	constant.ROLE_READWRITE: 2,
+	constant.ROLE_ADMIN:     3,
+}
+
+// requireUser returns 403 if the request is authenticated by an api token, tokens cannot be managed by tokens
+func requireUser(ctx context.Context) error {
+	if ctx.Value(commontypes.ApiTokenKey{}) != nil {
+		return errorx.NewError(http.StatusForbidden, "API令牌不能管理API令牌", nil)
+	}
+	return nil
+}
+
+// tenantNamespaces returns the namespaces of the tenant joined by ",", which is the namespace of jwt payloads
+func tenantNamespaces(tenant commontypes.Tenant) (string, error) {
+	var nscluster []commontypes.NsCluster
+	if tenant.Namespaces != "" {
+		if err := json.Unmarshal([]byte(tenant.Namespaces), &nscluster); err != nil {
+			return "", err
+		}
+	}
+	return strings.Join(lo.Map(nscluster, func(item commontypes.NsCluster, _ int) string {
+		return item.Namespace
+	}), ","), nil
+}
@@ -0,0 +1,97 @@
+package webhook
+
+import (
+	"context"
+	"crypto/subtle"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strings"
+
+	"github.com/hongyuxuan/lizardcd/common/constant"
+	"github.com/hongyuxuan/lizardcd/common/errorx"
+	commontypes "github.com/hongyuxuan/lizardcd/common/types"
+	"github.com/hongyuxuan/lizardcd/common/utils"
+	"github.com/hongyuxuan/lizardcd/server/internal/logic/task"
+	"github.com/hongyuxuan/lizardcd/server/internal/svc"
+	"github.com/hongyuxuan/lizardcd/server/internal/types"
+
+	"github.com/zeromicro/go-zero/core/logx"
+)
+
+// tagPattern is the grammar of docker image tags, so a tag cannot change the artifact url generated by it
+var tagPattern = regexp.MustCompile(`^[\w][\w.-]{0,127}$`)
+
+// pushEvent is an image pushed to a registry, parsed from the payload of a webhook
+type pushEvent struct {
+	source      string // harbor, dockerhub or generic
+	secret      string
+	repository  string // e.g. library/nginx, not checked if empty
+	tag         string
+	artifactUrl string // must be the artifact url generated by the repository of application and tag if not empty
+	labels      []string
+}
+
+// webhookSecret returns the secret from query, or from header "Authorization" with an optional "Bearer " prefix
+func webhookSecret(secret, authorization string) string {
+	if secret != "" {
+		return secret
+	}
+	return strings.TrimPrefix(authorization, "Bearer ")
+}
+
+// deploy authenticates the push event by the webhook secret of application, and runs a deploy task if the tag matches the tag filter
+func deploy(ctx context.Context, svcCtx *svc.ServiceContext, appName string, event pushEvent) (resp *types.Response, err error) {
+	logger := logx.WithContext(ctx)
+	var application commontypes.Application
+	if err = svcCtx.Sqlite.WithContext(context.WithValue(ctx, commontypes.TraceIDKey{}, "sqlite.GetApplication")).
+		Where("app_name = ?", appName).First(&application).Error; err != nil {
+		logger.Errorf("Webhook %s failed to get application \"%s\": %v", event.source, appName, err)
+		// do not tell whether the application exists before authenticated
+		return nil, errorx.NewError(http.StatusUnauthorized, "webhook认证失败", nil)
+	}
+	secret, err := utils.DecryptSecret(application.Webhook.Secret)
+	if err != nil {
+		logger.Errorf("Webhook %s failed to decrypt secret of application \"%s\": %v", event.source, appName, err)
+		return nil, errorx.NewError(http.StatusUnauthorized, "webhook认证失败", nil)
+	}
+	if !application.Webhook.Enabled || secret == "" ||
+		subtle.ConstantTimeCompare([]byte(event.secret), []byte(secret)) != 1 {
+		logger.Errorf("Webhook %s failed to authenticate application \"%s\"", event.source, appName)
+		return nil, errorx.NewError(http.StatusUnauthorized, "webhook认证失败", nil)
+	}
+	if event.tag == "" {
+		return nil, errorx.NewError(http.StatusBadRequest, "镜像tag不能为空", nil)
+	}
+	if !tagPattern.MatchString(event.tag) {
+		return nil, errorx.NewError(http.StatusBadRequest, fmt.Sprintf("镜像tag %s 不正确", event.tag), nil)
+	}
+	if expected := application.RepoName + "/" + application.ImageName; event.repository != "" && application.ImageName != "" && event.repository != expected {
+		return nil, errorx.NewError(http.StatusBadRequest, fmt.Sprintf("推送的镜像仓库 %s 与应用的镜像仓库 %s 不一致", event.repository, expected), nil)
+	}
+	if filter := application.Webhook.TagFilter; filter != "" {
+		var reg *regexp.Regexp
+		if reg, err = regexp.Compile(filter); err != nil {
+			return nil, errorx.NewError(http.StatusBadRequest, "应用的tag过滤规则不正确: "+err.Error(), nil)
+		}
+		if !reg.MatchString(event.tag) {
+			logger.Infof("Webhook %s ignored tag %s of application \"%s\" by tag filter \"%s\"", event.source, event.tag, appName, filter)
+			return &types.Response{
+				Code:    http.StatusOK,
+				Message: fmt.Sprintf("镜像tag %s 不匹配过滤规则 %s，已忽略", event.tag, filter),
+			}, nil
+		}
+	}
+	// the artifact is always in the repository of application, so the payload cannot deploy an image from other registries
+	artifactUrl := svc.NewRepoService(ctx, svcCtx).GetArtifactUrl(application, event.tag)
+	if event.artifactUrl != "" && event.artifactUrl != artifactUrl {
+		return nil, errorx.NewError(http.StatusBadRequest, fmt.Sprintf("推送的镜像 %s 与应用的镜像 %s 不一致", event.artifactUrl, artifactUrl), nil)
+	}
+
+	var req *types.RunTaskReq
+	if req, err = task.NewDeployReq(application, constant.TRIGGER_TYPE_WEBHOOK, artifactUrl, append([]string{event.source}, event.labels...)); err != nil {
+		return
+	}
+	logger.Infof("Webhook %s deploy application \"%s\" with %s", event.source, appName, artifactUrl)
+	return task.NewRunTaskLogic(task.WithPayloads(ctx, constant.TRIGGER_TYPE_WEBHOOK, application.Tenant), svcCtx).RunTask(req)
+}
@@ -0,0 +1,33 @@
+package webhook
+
+import (
+	"context"
+
+	"github.com/hongyuxuan/lizardcd/server/internal/svc"
+	"github.com/hongyuxuan/lizardcd/server/internal/types"
+
+	"github.com/zeromicro/go-zero/core/logx"
+)
+
+type DockerHubWebhookLogic struct {
+	logx.Logger
+	ctx    context.Context
+	svcCtx *svc.ServiceContext
+}
+
+func NewDockerHubWebhookLogic(ctx context.Context, svcCtx *svc.ServiceContext) *DockerHubWebhookLogic {
+	return &DockerHubWebhookLogic{
+		Logger: logx.WithContext(ctx),
+		ctx:    ctx,
+		svcCtx: svcCtx,
+	}
+}
+
+func (l *DockerHubWebhookLogic) DockerHubWebhook(req *types.DockerHubWebhookReq) (resp *types.Response, err error) {
+	return deploy(l.ctx, l.svcCtx, req.AppName, pushEvent{
+		source:     "dockerhub",
+		secret:     req.Secret,
+		repository: req.Repository.RepoName,
+		tag:        req.PushData.Tag,
+	})
+}
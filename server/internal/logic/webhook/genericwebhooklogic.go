@@ -0,0 +1,34 @@
+package webhook
+
+import (
+	"context"
+
+	"github.com/hongyuxuan/lizardcd/server/internal/svc"
+	"github.com/hongyuxuan/lizardcd/server/internal/types"
+
+	"github.com/zeromicro/go-zero/core/logx"
+)
+
+type GenericWebhookLogic struct {
+	logx.Logger
+	ctx    context.Context
+	svcCtx *svc.ServiceContext
+}
+
+func NewGenericWebhookLogic(ctx context.Context, svcCtx *svc.ServiceContext) *GenericWebhookLogic {
+	return &GenericWebhookLogic{
+		Logger: logx.WithContext(ctx),
+		ctx:    ctx,
+		svcCtx: svcCtx,
+	}
+}
+
+func (l *GenericWebhookLogic) GenericWebhook(req *types.GenericWebhookReq) (resp *types.Response, err error) {
+	return deploy(l.ctx, l.svcCtx, req.AppName, pushEvent{
+		source:      "generic",
+		secret:      webhookSecret(req.Secret, req.Authorization),
+		tag:         req.Tag,
+		artifactUrl: req.ArtifactUrl,
+		labels:      req.Labels,
+	})
+}
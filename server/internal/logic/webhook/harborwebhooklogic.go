@@ -0,0 +1,49 @@
+package webhook
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"github.com/hongyuxuan/lizardcd/common/errorx"
+	"github.com/hongyuxuan/lizardcd/server/internal/svc"
+	"github.com/hongyuxuan/lizardcd/server/internal/types"
+
+	"github.com/zeromicro/go-zero/core/logx"
+)
+
+type HarborWebhookLogic struct {
+	logx.Logger
+	ctx    context.Context
+	svcCtx *svc.ServiceContext
+}
+
+func NewHarborWebhookLogic(ctx context.Context, svcCtx *svc.ServiceContext) *HarborWebhookLogic {
+	return &HarborWebhookLogic{
+		Logger: logx.WithContext(ctx),
+		ctx:    ctx,
+		svcCtx: svcCtx,
+	}
+}
+
+func (l *HarborWebhookLogic) HarborWebhook(req *types.HarborWebhookReq) (resp *types.Response, err error) {
+	if req.Type != "PUSH_ARTIFACT" {
+		return &types.Response{
+			Code:    http.StatusOK,
+			Message: fmt.Sprintf("不处理 %s 事件，已忽略", req.Type),
+		}, nil
+	}
+	// deploy the first tagged artifact, a push event contains only one artifact normally
+	for _, r := range req.EventData.Resources {
+		if r.Tag == "" {
+			continue
+		}
+		return deploy(l.ctx, l.svcCtx, req.AppName, pushEvent{
+			source:     "harbor",
+			secret:     webhookSecret(req.Secret, req.Authorization),
+			repository: req.EventData.Repository.RepoFullName,
+			tag:        r.Tag,
+		})
+	}
+	return nil, errorx.NewError(http.StatusBadRequest, "Harbor事件中没有带tag的镜像", nil)
+}
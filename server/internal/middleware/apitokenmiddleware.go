@@ -0,0 +1,52 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/hongyuxuan/lizardcd/common/errorx"
+	commontypes "github.com/hongyuxuan/lizardcd/common/types"
+	"github.com/zeromicro/go-zero/core/logx"
+	"github.com/zeromicro/go-zero/rest/httpx"
+)
+
+// scopedTokenRoutes are the only mutating routes allowed for api tokens scoped to applications
+var scopedTokenRoutes = map[string]bool{
+	"POST /lizardcd/task/run": true,
+}
+
+type ApiTokenMiddleware struct {
+	check func(ctx context.Context, tokenId string) (*commontypes.ApiToken, error)
+}
+
+func NewApiTokenMiddleware(check func(ctx context.Context, tokenId string) (*commontypes.ApiToken, error)) *ApiTokenMiddleware {
+	return &ApiTokenMiddleware{
+		check: check,
+	}
+}
+
+// Handle rejects revoked or expired api tokens, requests authenticated by users are not affected.
+// The api token is put into the context of request for checking its applications.
+func (m *ApiTokenMiddleware) Handle(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		tokenId, ok := r.Context().Value("token_id").(string)
+		if !ok {
+			next(w, r)
+			return
+		}
+		token, err := m.check(r.Context(), tokenId)
+		if err != nil {
+			logx.WithContext(r.Context()).Errorf("api token id=%s is denied: %v", tokenId, err)
+			httpx.Error(w, err)
+			return
+		}
+		if len(token.Applications) > 0 && r.Method != http.MethodGet && r.Method != http.MethodHead {
+			if route, _ := matchRoute(r.Method, r.URL.Path); !scopedTokenRoutes[r.Method+" "+route] {
+				logx.WithContext(r.Context()).Errorf("api token \"%s\" is not allowed for %s %s", token.Name, r.Method, r.URL.Path)
+				httpx.Error(w, errorx.NewError(http.StatusForbidden, "限定应用的API令牌只能运行任务", nil))
+				return
+			}
+		}
+		next(w, r.WithContext(context.WithValue(r.Context(), commontypes.ApiTokenKey{}, token)))
+	}
+}
@@ -0,0 +1,78 @@
+package middleware
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/hongyuxuan/lizardcd/common/constant"
+	commontypes "github.com/hongyuxuan/lizardcd/common/types"
+	"github.com/hongyuxuan/lizardcd/common/utils"
+	"github.com/zeromicro/go-zero/rest/httpx"
+	"github.com/zeromicro/go-zero/rest/pathvar"
+)
+
+type AuditMiddleware struct {
+	record func(auditLog *commontypes.AuditLog)
+}
+
+func NewAuditMiddleware(record func(auditLog *commontypes.AuditLog)) *AuditMiddleware {
+	return &AuditMiddleware{
+		record: record,
+	}
+}
+
+// Handle records every mutating api call, GET requests are not recorded
+func (m *AuditMiddleware) Handle(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodGet || r.Method == http.MethodHead || r.Method == http.MethodOptions {
+			next(w, r)
+			return
+		}
+		username, tenant := utils.GetPayloadUser(r.Context())
+		auditLog := &commontypes.AuditLog{
+			Source:   constant.AUDIT_SOURCE_API,
+			Username: username,
+			Tenant:   tenant,
+			Method:   r.Method,
+			Path:     r.URL.Path,
+			ClientIp: httpx.GetRemoteAddr(r),
+			CreateAt: time.Now(),
+		}
+		vars := pathvar.Vars(r)
+		auditLog.Route, auditLog.Resource = routeOf(r.Method, r.URL.Path)
+		auditLog.Cluster = vars["cluster"]
+		auditLog.Namespace = vars["namespace"]
+		// the body of auth apis contains passwords, whose digest should not be stored
+		if r.Body != nil && !strings.HasPrefix(r.URL.Path, "/lizardcd/auth/") {
+			body, _ := io.ReadAll(r.Body)
+			r.Body = io.NopCloser(bytes.NewReader(body))
+			digest := sha256.Sum256(body)
+			auditLog.BodyDigest = hex.EncodeToString(digest[:])
+		}
+		aw := &auditResponseWriter{ResponseWriter: w, code: http.StatusOK}
+		next(aw, r)
+		auditLog.Code = aw.code
+		m.record(auditLog)
+	}
+}
+
+type auditResponseWriter struct {
+	http.ResponseWriter
+	code int
+}
+
+func (w *auditResponseWriter) WriteHeader(code int) {
+	w.code = code
+	w.ResponseWriter.WriteHeader(code)
+}
+
+func (w *auditResponseWriter) Flush() {
+	if flusher, ok := w.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
@@ -0,0 +1,128 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"strings"
+
+	"github.com/hongyuxuan/lizardcd/common/errorx"
+	"github.com/zeromicro/go-zero/core/logx"
+	"github.com/zeromicro/go-zero/rest/httpx"
+	"github.com/zeromicro/go-zero/rest/pathvar"
+)
+
+const nsRoute = "/cluster/:cluster/namespace/:namespace"
+
+// permission is the resource type and verb of a route, resource ":resource_type" is replaced by the path param
+type permission struct {
+	resource string
+	verb     string
+}
+
+// permissions of the routes checked by rbac policies, keyed by method and route
+var permissions = map[string]permission{
+	// kubernetes
+	"GET /lizardcd/kubernetes" + nsRoute + "/deployments":                           {"deployments", "get"},
+	"GET /lizardcd/kubernetes" + nsRoute + "/statefulsets":                          {"statefulsets", "get"},
+	"GET /lizardcd/kubernetes" + nsRoute + "/deployments/:workload_name":            {"deployments", "get"},
+	"GET /lizardcd/kubernetes" + nsRoute + "/statefulsets/:workload_name":           {"statefulsets", "get"},
+	"GET /lizardcd/kubernetes" + nsRoute + "/deployments/:workload_name/pods":       {"deployments", "get"},
+	"GET /lizardcd/kubernetes" + nsRoute + "/statefulsets/:workload_name/pods":      {"statefulsets", "get"},
+	"GET /lizardcd/kubernetes" + nsRoute + "/deployments/:workload_name/status":     {"deployments", "get"},
+	"GET /lizardcd/kubernetes" + nsRoute + "/:resource_type/:resource_name/yaml":    {":resource_type", "get"},
+	"GET /lizardcd/kubernetes" + nsRoute + "/:resource_type/:resource_name/events":  {":resource_type", "get"},
+	"GET /lizardcd/kubernetes" + nsRoute + "/:resource_type/:resource_name/quota":   {":resource_type", "get"},
+	"PATCH /lizardcd/kubernetes" + nsRoute + "/deployments/:workload_name":          {"deployments", "update"},
+	"PATCH /lizardcd/kubernetes" + nsRoute + "/statefulsets/:workload_name":         {"statefulsets", "update"},
+	"PATCH /lizardcd/kubernetes" + nsRoute + "/deployments/:workload_name/rollout":  {"deployments", "rollout"},
+	"PATCH /lizardcd/kubernetes" + nsRoute + "/statefulsets/:workload_name/rollout": {"statefulsets", "rollout"},
+	"PATCH /lizardcd/kubernetes" + nsRoute + "/deployments/scale":                   {"deployments", "scale"},
+	"PATCH /lizardcd/kubernetes" + nsRoute + "/statefulsets/scale":                  {"statefulsets", "scale"},
+	"PATCH /lizardcd/kubernetes" + nsRoute + "/apply/yaml":                          {"yaml", "apply"},
+	"PATCH /lizardcd/kubernetes" + nsRoute + "/apply/variable":                      {"yaml", "apply"},
+	"DELETE /lizardcd/kubernetes" + nsRoute + "/:resource_type/:resource_name":      {":resource_type", "delete"},
+	// istio
+	"GET /lizardcd/istio" + nsRoute + "/destinationrules":                   {"destinationrules", "get"},
+	"GET /lizardcd/istio" + nsRoute + "/virtualservices":                    {"virtualservices", "get"},
+	"GET /lizardcd/istio" + nsRoute + "/:resource_type/:resource_name/yaml": {":resource_type", "get"},
+	// helm
+	"GET /lizardcd/helm/repos":                            {"helm", "get"},
+	"GET /lizardcd/helm/repo/:repo_name":                  {"helm", "get"},
+	"GET /lizardcd/helm/repo/:name/:chart_name":           {"helm", "get"},
+	"GET /lizardcd/helm/repo/charts/values":               {"helm", "get"},
+	"GET /lizardcd/helm/repo/charts/readme":               {"helm", "get"},
+	"GET /lizardcd/helm/repo/charts/download":             {"helm", "get"},
+	"POST /lizardcd/helm/repo":                            {"helm", "create"},
+	"DELETE /lizardcd/helm/repo/:repo_name":               {"helm", "delete"},
+	"POST /lizardcd/helm" + nsRoute + "/repo/update":      {"helm", "update"},
+	"GET /lizardcd/helm" + nsRoute + "/releases":          {"helm", "get"},
+	"GET /lizardcd/helm" + nsRoute + "/release/values":    {"helm", "get"},
+	"GET /lizardcd/helm" + nsRoute + "/release/history":   {"helm", "get"},
+	"POST /lizardcd/helm" + nsRoute + "/charts/install":   {"helm", "install"},
+	"POST /lizardcd/helm" + nsRoute + "/charts/upgrade":   {"helm", "upgrade"},
+	"POST /lizardcd/helm" + nsRoute + "/charts/uninstall": {"helm", "uninstall"},
+	"POST /lizardcd/helm" + nsRoute + "/release/rollback": {"helm", "rollback"},
+	// task, workloads of a run, cancelled or approved task are checked again by their cluster and namespace
+	"POST /lizardcd/task/run":            {"task", "run"},
+	"POST /lizardcd/task/cancel/:id":     {"task", "cancel"},
+	"POST /lizardcd/task/approve/:id":    {"task", "approve"},
+	"POST /lizardcd/task/reject/:id":     {"task", "approve"},
+	"GET /lizardcd/task/events/:id":      {"task", "get"},
+	"DELETE /lizardcd/task/history/:id":  {"task", "delete"},
+	"POST /lizardcd/task/schedule":       {"schedule", "create"},
+	"PUT /lizardcd/task/schedule/:id":    {"schedule", "update"},
+	"DELETE /lizardcd/task/schedule/:id": {"schedule", "delete"},
+	// vm and http
+	"POST /lizardcd/vm/deploy":        {"vm", "deploy"},
+	"POST /lizardcd/vm/healthcheck":   {"vm", "get"},
+	"POST /lizardcd/http/deploy":      {"http", "deploy"},
+	"POST /lizardcd/http/healthcheck": {"http", "get"},
+}
+
+type AuthorizeMiddleware struct {
+	checkNamespace func(ctx context.Context, cluster, namespace string) error
+	authorize      func(ctx context.Context, cluster, namespace, resource, verb string) error
+}
+
+func NewAuthorizeMiddleware(checkNamespace func(ctx context.Context, cluster, namespace string) error, authorize func(ctx context.Context, cluster, namespace, resource, verb string) error) *AuthorizeMiddleware {
+	return &AuthorizeMiddleware{
+		checkNamespace: checkNamespace,
+		authorize:      authorize,
+	}
+}
+
+// Handle checks that the cluster and namespace of the route belong to the tenant, then checks the rbac policies of the route.
+// Kubernetes routes not in permissions are denied, other routes not in permissions are only restricted by roles.
+func (m *AuthorizeMiddleware) Handle(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		vars := pathvar.Vars(r)
+		cluster, hasCluster := vars["cluster"]
+		namespace, hasNamespace := vars["namespace"]
+		if hasCluster && hasNamespace {
+			if err := m.checkNamespace(r.Context(), cluster, namespace); err != nil {
+				logx.WithContext(r.Context()).Errorf("%s %s is denied: %v", r.Method, r.URL.Path, err)
+				httpx.Error(w, err)
+				return
+			}
+		}
+		route, _ := matchRoute(r.Method, r.URL.Path)
+		perm, ok := permissions[r.Method+" "+route]
+		if !ok && strings.HasPrefix(r.URL.Path, "/lizardcd/kubernetes/") {
+			logx.WithContext(r.Context()).Errorf("%s %s is denied: no permission of the route", r.Method, r.URL.Path)
+			httpx.Error(w, errorx.NewError(http.StatusForbidden, "没有权限访问该接口", nil))
+			return
+		}
+		if ok {
+			resource := perm.resource
+			if strings.HasPrefix(resource, ":") {
+				resource = vars[resource[1:]]
+			}
+			if err := m.authorize(r.Context(), cluster, namespace, resource, perm.verb); err != nil {
+				logx.WithContext(r.Context()).Errorf("%s %s is denied: %v", r.Method, r.URL.Path, err)
+				httpx.Error(w, err)
+				return
+			}
+		}
+		next(w, r)
+	}
+}
@@ -0,0 +1,83 @@
+package middleware
+
+import (
+	"strings"
+
+	"github.com/zeromicro/go-zero/rest"
+)
+
+// routes registered to the server, keyed the same as permissions
+var routes []string
+
+// SetRoutes sets the routes registered to the server, it should be called before the server starts
+func SetRoutes(rs []rest.Route) {
+	routes = make([]string, 0, len(rs))
+	for _, r := range rs {
+		routes = append(routes, r.Method+" "+r.Path)
+	}
+}
+
+// matchRoute finds the route of a request by matching its path against the routes and the permissions segment by segment.
+// A literal segment is preferred to a path param at the same position, the same as the router of go-zero,
+// so the route never depends on the values of the path params.
+func matchRoute(method, path string) (route string, ok bool) {
+	segments := strings.Split(strings.Trim(path, "/"), "/")
+	var best []string
+	match := func(key string) {
+		m, template, found := strings.Cut(key, " ")
+		if !found || m != method {
+			return
+		}
+		parts := strings.Split(strings.Trim(template, "/"), "/")
+		if len(parts) != len(segments) {
+			return
+		}
+		for i, part := range parts {
+			if !strings.HasPrefix(part, ":") && part != segments[i] {
+				return
+			}
+		}
+		if best != nil && !moreSpecific(parts, best) {
+			return
+		}
+		best, route, ok = parts, key, true
+	}
+	for _, key := range routes {
+		match(key)
+	}
+	for key := range permissions {
+		match(key)
+	}
+	if ok {
+		route = strings.TrimPrefix(route, method+" ")
+	}
+	return
+}
+
+// moreSpecific returns whether the first differing segment of a is literal and that of b is a path param
+func moreSpecific(a, b []string) bool {
+	for i := range a {
+		pa, pb := strings.HasPrefix(a[i], ":"), strings.HasPrefix(b[i], ":")
+		if pa != pb {
+			return pb
+		}
+	}
+	return false
+}
+
+// routeOf returns the route of a request and joins the path params except cluster and namespace as the resource.
+// The path is returned as the route if no route matches.
+func routeOf(method, path string) (route, resource string) {
+	route, ok := matchRoute(method, path)
+	if !ok {
+		return path, ""
+	}
+	segments := strings.Split(strings.Trim(path, "/"), "/")
+	var resources []string
+	for i, part := range strings.Split(strings.Trim(route, "/"), "/") {
+		if strings.HasPrefix(part, ":") && part != ":cluster" && part != ":namespace" {
+			resources = append(resources, segments[i])
+		}
+	}
+	return route, strings.Join(resources, "/")
+}
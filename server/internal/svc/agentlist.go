@@ -0,0 +1,71 @@
+package svc
+
+import (
+	"sync"
+
+	"github.com/hongyuxuan/lizardcd/server/internal/types"
+)
+
+// AgentList keeps the lizardcd-agents of registries, tunnels and static agents by their service keys.
+// It is changed by the watchers and read by the handlers concurrently, so every access goes through its lock.
+type AgentList struct {
+	lock   sync.RWMutex
+	agents map[string]*types.RpcAgent
+}
+
+func NewAgentList() *AgentList {
+	return &AgentList{
+		agents: make(map[string]*types.RpcAgent),
+	}
+}
+
+// Get returns the agent of key
+func (a *AgentList) Get(key string) (agent *types.RpcAgent, ok bool) {
+	a.lock.RLock()
+	defer a.lock.RUnlock()
+	agent, ok = a.agents[key]
+	return
+}
+
+// Range calls fn for each agent until fn returns false, fn must not change the list
+func (a *AgentList) Range(fn func(key string, agent *types.RpcAgent) bool) {
+	a.lock.RLock()
+	defer a.lock.RUnlock()
+	for key, agent := range a.agents {
+		if !fn(key, agent) {
+			return
+		}
+	}
+}
+
+// Add adds the agent of key if key is not in the list, and reports whether it is added
+func (a *AgentList) Add(key string, agent *types.RpcAgent) bool {
+	a.lock.Lock()
+	defer a.lock.Unlock()
+	if _, ok := a.agents[key]; ok {
+		return false
+	}
+	a.agents[key] = agent
+	return true
+}
+
+// Set adds or replaces the agent of key
+func (a *AgentList) Set(key string, agent *types.RpcAgent) {
+	a.lock.Lock()
+	defer a.lock.Unlock()
+	a.agents[key] = agent
+}
+
+// Delete removes the agent of key
+func (a *AgentList) Delete(key string) {
+	a.lock.Lock()
+	defer a.lock.Unlock()
+	delete(a.agents, key)
+}
+
+// Update calls fn with the lock held, for the changes which read and write the list at once
+func (a *AgentList) Update(fn func(agents map[string]*types.RpcAgent)) {
+	a.lock.Lock()
+	defer a.lock.Unlock()
+	fn(a.agents)
+}
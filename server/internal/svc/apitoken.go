@@ -0,0 +1,93 @@
+package svc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/hongyuxuan/lizardcd/common/errorx"
+	commontypes "github.com/hongyuxuan/lizardcd/common/types"
+	"github.com/samber/lo"
+	"github.com/zeromicro/go-zero/core/logx"
+	"gorm.io/gorm"
+)
+
+// lastUsedInterval is the minimum interval of recording the last used time of a token, so requests do not always write the db
+const lastUsedInterval = time.Minute
+
+type ApiTokens struct {
+	db     *gorm.DB
+	secret string
+}
+
+func NewApiTokens(db *gorm.DB, secret string) *ApiTokens {
+	return &ApiTokens{
+		db:     db,
+		secret: secret,
+	}
+}
+
+// Sign returns the jwt token of t, which is accepted by the jwt middleware like the token of a user.
+// namespaces are the namespaces of the tenant joined by ",".
+func (a *ApiTokens) Sign(t commontypes.ApiToken, namespaces string) (string, error) {
+	claims := jwt.MapClaims{
+		"iat":      t.CreateAt.Unix(),
+		"token_id": t.Id,
+		"payloads": map[string]interface{}{
+			"username":  t.Username(),
+			"role":      t.Role,
+			"tenant":    t.Tenant,
+			"namespace": namespaces,
+		},
+	}
+	if t.ExpireAt.Valid {
+		claims["exp"] = t.ExpireAt.Time.Unix()
+	}
+	return jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte(a.secret))
+}
+
+// Check returns the token of tokenId if it is neither revoked nor expired, and records its last used time
+func (a *ApiTokens) Check(ctx context.Context, tokenId string) (*commontypes.ApiToken, error) {
+	var token commontypes.ApiToken
+	if err := a.db.WithContext(context.WithValue(ctx, commontypes.TraceIDKey{}, "sqlite.GetApiToken")).
+		Where("id = ?", tokenId).First(&token).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errorx.NewError(http.StatusUnauthorized, "API令牌已被吊销", nil)
+		}
+		return nil, err
+	}
+	now := time.Now()
+	if token.ExpireAt.Valid && now.After(token.ExpireAt.Time) {
+		return nil, errorx.NewError(http.StatusUnauthorized, "API令牌已过期", nil)
+	}
+	if !token.LastUsedAt.Valid || now.Sub(token.LastUsedAt.Time) > lastUsedInterval {
+		if err := a.db.WithContext(context.WithValue(ctx, commontypes.TraceIDKey{}, "sqlite.UpdateApiToken")).
+			Model(&token).UpdateColumn("last_used_at", now).Error; err != nil {
+			logx.WithContext(ctx).Errorf("Failed to update last used time of api token \"%s\": %v", token.Name, err)
+		}
+	}
+	return &token, nil
+}
+
+// CheckApplication returns 403 if the request is authenticated by an api token which is not allowed to run tasks of the application,
+// or to deploy workloads not belonging to the application
+func CheckApplication(ctx context.Context, application commontypes.Application, workloads []commontypes.WorkLoad) error {
+	token, ok := ctx.Value(commontypes.ApiTokenKey{}).(*commontypes.ApiToken)
+	if !ok || len(token.Applications) == 0 {
+		return nil
+	}
+	if !lo.Contains(token.Applications, application.AppName) {
+		return errorx.NewError(http.StatusForbidden, fmt.Sprintf("API令牌\"%s\"没有应用\"%s\"的权限", token.Name, application.AppName), nil)
+	}
+	for _, w := range workloads {
+		if !lo.ContainsBy(application.Workload, func(item commontypes.WorkLoad) bool {
+			return item.Cluster == w.Cluster && item.Namespace == w.Namespace && item.WorkloadName == w.WorkloadName
+		}) {
+			return errorx.NewError(http.StatusForbidden, fmt.Sprintf("工作负载\"%s\"不属于应用\"%s\"", w.WorkloadName, application.AppName), nil)
+		}
+	}
+	return nil
+}
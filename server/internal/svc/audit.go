@@ -0,0 +1,107 @@
+package svc
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+	"time"
+
+	"github.com/hongyuxuan/lizardcd/common/constant"
+	commontypes "github.com/hongyuxuan/lizardcd/common/types"
+	"github.com/hongyuxuan/lizardcd/common/utils"
+	"github.com/zeromicro/go-zero/core/logx"
+	"github.com/zeromicro/go-zero/zrpc"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+	"gorm.io/gorm"
+)
+
+// mutating rpcs of lizardcd-agent, which are audited
+var auditedRpcPrefixes = []string{
+	"Patch", "Delete", "Apply", "Rollout", "Scale", "Create",
+	"HelmUpdateRepo", "HelmInstall", "HelmUninstall", "HelmUpgrade", "HelmRollback",
+	"VmDeploy",
+}
+
+type Auditor struct {
+	db *gorm.DB
+}
+
+func NewAuditor(db *gorm.DB) *Auditor {
+	return &Auditor{
+		db: db,
+	}
+}
+
+// Record saves an audit log, failures are only logged to not break the audited request
+func (a *Auditor) Record(auditLog *commontypes.AuditLog) {
+	if err := a.db.WithContext(context.WithValue(context.Background(), commontypes.TraceIDKey{}, "sqlite.CreateAuditLog")).
+		Create(auditLog).Error; err != nil {
+		logx.Errorf("Failed to save audit log of %s %s: %v", auditLog.Method, auditLog.Path, err)
+	}
+}
+
+// AgentClientOption returns the zrpc client option which audits the mutating rpcs to the agent of service
+func (a *Auditor) AgentClientOption(service string) zrpc.ClientOption {
+	return zrpc.WithUnaryClientInterceptor(func(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		err := invoker(ctx, method, req, reply, cc, opts...)
+		if !isAuditedRpc(method) {
+			return err
+		}
+		username, tenant := utils.GetPayloadUser(ctx)
+		auditLog := &commontypes.AuditLog{
+			Source:   constant.AUDIT_SOURCE_RPC,
+			Username: username,
+			Tenant:   tenant,
+			Method:   "RPC",
+			Route:    method,
+			Path:     service,
+			Code:     int(status.Code(err)),
+			CreateAt: time.Now(),
+		}
+		if r, ok := req.(interface{ GetNamespace() string }); ok {
+			auditLog.Namespace = r.GetNamespace()
+			// service name of an agent is <prefix>lizardcd-agent.<namespace>.<cluster>
+			if _, after, found := strings.Cut(service, "lizardcd-agent."+auditLog.Namespace+"."); found {
+				auditLog.Cluster = after
+			}
+		}
+		auditLog.Resource = rpcResource(req)
+		if m, ok := req.(proto.Message); ok {
+			if body, e := proto.Marshal(m); e == nil {
+				digest := sha256.Sum256(body)
+				auditLog.BodyDigest = hex.EncodeToString(digest[:])
+			}
+		}
+		a.Record(auditLog)
+		return err
+	})
+}
+
+func isAuditedRpc(method string) bool {
+	name := method[strings.LastIndex(method, "/")+1:]
+	for _, prefix := range auditedRpcPrefixes {
+		if strings.HasPrefix(name, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+func rpcResource(req any) string {
+	switch r := req.(type) {
+	case interface{ GetWorkloadName() string }:
+		return r.GetWorkloadName()
+	case interface{ GetReleaseName() string }:
+		return r.GetReleaseName()
+	case interface{ GetName() string }:
+		return r.GetName()
+	case interface{ GetKind() string }:
+		return r.GetKind()
+	case interface{ GetDeployPath() string }:
+		return r.GetDeployPath()
+	}
+	return ""
+}
@@ -0,0 +1,28 @@
+package svc
+
+import (
+	"github.com/hongyuxuan/lizardcd/server/internal/config"
+	"github.com/samber/lo"
+)
+
+// UserIdentity is a user authenticated by an external identity provider
+type UserIdentity struct {
+	Username string
+	Groups   []string
+	Source   string // ldap or oidc
+	Subject  string // unique id of the user in the identity provider, users are matched by it instead of username
+}
+
+// mapGroups returns the role and tenant of the first group mapping matched by groups, or the default role and tenant if none is matched
+func mapGroups(mappings []config.GroupMapping, defaultRole, defaultTenant string, groups []string) (role, tenant string, matched bool) {
+	for _, m := range mappings {
+		if lo.Contains(groups, m.Group) {
+			tenant = m.Tenant
+			if tenant == "" {
+				tenant = defaultTenant
+			}
+			return m.Role, tenant, true
+		}
+	}
+	return defaultRole, defaultTenant, false
+}
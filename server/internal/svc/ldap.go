@@ -0,0 +1,166 @@
+package svc
+
+import (
+	"crypto/tls"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/go-ldap/ldap/v3"
+	"github.com/hongyuxuan/lizardcd/common/constant"
+	"github.com/hongyuxuan/lizardcd/common/errorx"
+	"github.com/hongyuxuan/lizardcd/server/internal/config"
+)
+
+const ldapTimeout = 10 * time.Second
+
+// LdapService authenticates users by binding to LDAP or Active Directory
+type LdapService struct {
+	conf config.LdapConf
+}
+
+func NewLdapService(conf config.LdapConf) *LdapService {
+	return &LdapService{
+		conf: conf,
+	}
+}
+
+func (l *LdapService) Enabled() bool {
+	return l.conf.Url != ""
+}
+
+// IsLdapUnavailable reports whether err is returned by Authenticate because the ldap server is unreachable,
+// rather than it rejects the user
+func IsLdapUnavailable(err error) bool {
+	e, ok := err.(*errorx.LizardcdError)
+	return ok && e.Code == http.StatusServiceUnavailable
+}
+
+// ldapError returns 503 for the network errors of ldap, which are checked by IsLdapUnavailable
+func ldapError(err error, format string, a ...any) error {
+	if ldap.IsErrorWithCode(err, ldap.ErrorNetwork) {
+		return errorx.NewError(http.StatusServiceUnavailable, fmt.Sprintf(format, a...), nil)
+	}
+	return errorx.NewDefaultError(format, a...)
+}
+
+// Authenticate searches the user by BindDn and binds as the user with its password.
+// The groups of the user are the cn of its groups.
+func (l *LdapService) Authenticate(username, password string) (identity *UserIdentity, err error) {
+	// binding with an empty password is an unauthenticated bind which always succeeds
+	if username == "" || password == "" {
+		return nil, errorx.NewError(http.StatusUnauthorized, "wrong username or password", nil)
+	}
+	conn, err := l.dial()
+	if err != nil {
+		return
+	}
+	defer conn.Close()
+
+	if err = l.bind(conn); err != nil {
+		return
+	}
+	res, err := conn.Search(ldap.NewSearchRequest(
+		l.conf.BaseDn, ldap.ScopeWholeSubtree, ldap.NeverDerefAliases, 2, int(ldapTimeout.Seconds()), false,
+		fmt.Sprintf(l.conf.UserFilter, ldap.EscapeFilter(username)),
+		[]string{"dn", l.conf.GroupAttribute},
+		nil,
+	))
+	if err != nil {
+		return nil, ldapError(err, "Failed to search ldap user \"%s\": %v", username, err)
+	}
+	if len(res.Entries) != 1 {
+		return nil, errorx.NewError(http.StatusUnauthorized, fmt.Sprintf("Found %d ldap users of \"%s\"", len(res.Entries), username), nil)
+	}
+	entry := res.Entries[0]
+	if err = conn.Bind(entry.DN, password); err != nil {
+		if ldap.IsErrorWithCode(err, ldap.ErrorNetwork) {
+			return nil, ldapError(err, "Failed to bind ldap as \"%s\": %v", entry.DN, err)
+		}
+		return nil, errorx.NewError(http.StatusUnauthorized, "wrong username or password", err)
+	}
+
+	identity = &UserIdentity{
+		Username: username,
+		Source:   constant.USER_SOURCE_LDAP,
+		Subject:  entry.DN,
+	}
+	groupDns := entry.GetAttributeValues(l.conf.GroupAttribute)
+	if l.conf.GroupBaseDn != "" {
+		if groupDns, err = l.searchGroups(conn, entry.DN); err != nil {
+			return
+		}
+	}
+	for _, dn := range groupDns {
+		identity.Groups = append(identity.Groups, groupName(dn))
+	}
+	return
+}
+
+// MapUser returns the role and tenant mapped by the groups of a user
+func (l *LdapService) MapUser(groups []string) (role, tenant string, matched bool) {
+	return mapGroups(l.conf.GroupMappings, l.conf.DefaultRole, l.conf.DefaultTenant, groups)
+}
+
+func (l *LdapService) dial() (conn *ldap.Conn, err error) {
+	tlsConfig := &tls.Config{InsecureSkipVerify: l.conf.InsecureSkipVerify}
+	// the server is unavailable if it cannot be connected
+	if conn, err = ldap.DialURL(l.conf.Url, ldap.DialWithTLSConfig(tlsConfig), ldap.DialWithDialer(&net.Dialer{Timeout: ldapTimeout})); err != nil {
+		return nil, errorx.NewError(http.StatusServiceUnavailable, fmt.Sprintf("Failed to connect to ldap %s: %v", l.conf.Url, err), nil)
+	}
+	conn.SetTimeout(ldapTimeout)
+	if l.conf.StartTLS {
+		if err = conn.StartTLS(tlsConfig); err != nil {
+			conn.Close()
+			return nil, ldapError(err, "Failed to start tls of ldap %s: %v", l.conf.Url, err)
+		}
+	}
+	return
+}
+
+// bind binds as BindDn, or stays anonymous if BindDn is empty
+func (l *LdapService) bind(conn *ldap.Conn) error {
+	if l.conf.BindDn == "" {
+		return nil
+	}
+	if err := conn.Bind(l.conf.BindDn, l.conf.BindPassword); err != nil {
+		return ldapError(err, "Failed to bind ldap as \"%s\": %v", l.conf.BindDn, err)
+	}
+	return nil
+}
+
+// searchGroups returns the dn of groups having the user as a member, searched as BindDn
+func (l *LdapService) searchGroups(conn *ldap.Conn, userDn string) (dns []string, err error) {
+	if err = l.bind(conn); err != nil {
+		return
+	}
+	res, err := conn.Search(ldap.NewSearchRequest(
+		l.conf.GroupBaseDn, ldap.ScopeWholeSubtree, ldap.NeverDerefAliases, 0, int(ldapTimeout.Seconds()), false,
+		fmt.Sprintf(l.conf.GroupFilter, ldap.EscapeFilter(userDn)),
+		[]string{"dn"},
+		nil,
+	))
+	if err != nil {
+		return nil, ldapError(err, "Failed to search ldap groups of \"%s\": %v", userDn, err)
+	}
+	for _, entry := range res.Entries {
+		dns = append(dns, entry.DN)
+	}
+	return
+}
+
+// groupName returns the cn of a group dn, or the dn itself if it has no cn
+func groupName(dn string) string {
+	parsed, err := ldap.ParseDN(dn)
+	if err != nil || len(parsed.RDNs) == 0 {
+		return dn
+	}
+	for _, attr := range parsed.RDNs[0].Attributes {
+		if strings.EqualFold(attr.Type, "cn") {
+			return attr.Value
+		}
+	}
+	return dn
+}
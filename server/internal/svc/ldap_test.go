@@ -0,0 +1,28 @@
+package svc
+
+import (
+	"net"
+	"net/http"
+	"testing"
+
+	"github.com/hongyuxuan/lizardcd/common/errorx"
+	"github.com/hongyuxuan/lizardcd/server/internal/config"
+)
+
+func TestIsLdapUnavailable(t *testing.T) {
+	// a port without listener, so the ldap server is unreachable
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := l.Addr().String()
+	l.Close()
+
+	_, err = NewLdapService(config.LdapConf{Url: "ldap://" + addr, BaseDn: "dc=example,dc=com"}).Authenticate("alice", "password")
+	if !IsLdapUnavailable(err) {
+		t.Errorf("Authenticate() of unreachable ldap error = %v, want unavailable", err)
+	}
+	if IsLdapUnavailable(errorx.NewError(http.StatusUnauthorized, "wrong username or password", nil)) {
+		t.Error("rejected credentials are reported unavailable")
+	}
+}
@@ -0,0 +1,353 @@
+package svc
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/smtp"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"text/template"
+	"time"
+
+	"github.com/hongyuxuan/lizardcd/common/constant"
+	commontypes "github.com/hongyuxuan/lizardcd/common/types"
+	"github.com/hongyuxuan/lizardcd/common/utils"
+	"github.com/samber/lo"
+	"github.com/zeromicro/go-zero/core/logx"
+	"go.opentelemetry.io/otel"
+	"gorm.io/gorm"
+)
+
+var notifyEventNames = map[string]string{
+	constant.NOTIFY_EVENT_START:   "开始",
+	constant.NOTIFY_EVENT_SUCCESS: "成功",
+	constant.NOTIFY_EVENT_FAILURE: "失败",
+	constant.NOTIFY_EVENT_TIMEOUT: "超时",
+	constant.NOTIFY_EVENT_TEST:    "测试",
+}
+
+// NotifyMessage is the data of notification templates
+type NotifyMessage struct {
+	Event       string           `json:"event"` // start, success, failure, timeout or test
+	TaskId      string           `json:"task_id"`
+	AppName     string           `json:"app_name"`
+	TaskType    string           `json:"task_type"`
+	TriggerType string           `json:"trigger_type"`
+	Status      string           `json:"status"`
+	Success     bool             `json:"success"`
+	ErrMessage  string           `json:"err_message"`
+	Expire      string           `json:"expire"`
+	Tenant      string           `json:"tenant"`
+	Workloads   []NotifyWorkload `json:"workloads"`
+	Time        string           `json:"time"`
+}
+
+type NotifyWorkload struct {
+	Cluster      string `json:"cluster"`
+	Namespace    string `json:"namespace"`
+	WorkloadName string `json:"workload_name"`
+	ArtifactUrl  string `json:"artifact_url"`
+	Success      bool   `json:"success"`
+	ErrMessage   string `json:"err_message"`
+}
+
+// Notifier sends the task events to the notify channels of the task's tenant
+type Notifier struct {
+	db   *gorm.DB
+	mu   sync.Mutex
+	sent map[string]string // the last event sent by task id, so each event is sent only once
+}
+
+func NewNotifier(db *gorm.DB) *Notifier {
+	return &Notifier{
+		db:   db,
+		sent: make(map[string]string),
+	}
+}
+
+// OnTask sends the event of a task state transition in background. Stage tasks are notified by their pipeline task.
+func (n *Notifier) OnTask(task commontypes.TaskHistory) {
+	if task.ParentId != "" {
+		return
+	}
+	event := taskNotifyEvent(task)
+	if event == "" {
+		return
+	}
+	n.mu.Lock()
+	last := n.sent[task.Id]
+	if last == event || (last != "" && last != constant.NOTIFY_EVENT_START) {
+		n.mu.Unlock()
+		return
+	}
+	n.sent[task.Id] = event
+	n.mu.Unlock()
+	if event != constant.NOTIFY_EVENT_START {
+		// keep the finished task for a while, so the updates after it finished are not notified again
+		time.AfterFunc(10*time.Minute, func() {
+			n.mu.Lock()
+			delete(n.sent, task.Id)
+			n.mu.Unlock()
+		})
+	}
+	go n.Notify(event, task)
+}
+
+// taskNotifyEvent returns the event of the task status, or empty if there is nothing to notify
+func taskNotifyEvent(task commontypes.TaskHistory) string {
+	switch {
+	case task.Status == constant.TASK_STATUS_RUNNING:
+		return constant.NOTIFY_EVENT_START
+	case !task.Success.Valid || lo.Contains([]string{constant.TASK_STATUS_INITIALIZE, constant.TASK_STATUS_RECOVERED, constant.TASK_STATUS_WAITING_APPROVAL}, task.Status):
+		return ""
+	case task.Success.Bool:
+		return constant.NOTIFY_EVENT_SUCCESS
+	case task.Reason == constant.TASK_REASON_TIMEOUT:
+		return constant.NOTIFY_EVENT_TIMEOUT
+	}
+	return constant.NOTIFY_EVENT_FAILURE
+}
+
+// Notify sends an event of a task to the enabled channels subscribing the event
+func (n *Notifier) Notify(event string, task commontypes.TaskHistory) {
+	var channels []commontypes.NotifyChannel
+	if err := n.db.Where("tenant = ? AND enabled = ?", task.Tenant, true).Find(&channels).Error; err != nil {
+		logx.Error(err)
+		return
+	}
+	channels = lo.Filter(channels, func(c commontypes.NotifyChannel, _ int) bool {
+		return len(c.Events) == 0 || lo.Contains(c.Events, event)
+	})
+	if len(channels) == 0 {
+		return
+	}
+	message := n.newMessage(event, task)
+	for _, channel := range channels {
+		if err := n.Send(channel, message); err != nil {
+			logx.Errorf("Failed to send %s notification of task id=%s to channel \"%s\": %v", event, task.Id, channel.Name, err)
+		} else {
+			logx.Infof("Sent %s notification of task id=%s to channel \"%s\"", event, task.Id, channel.Name)
+		}
+	}
+}
+
+func (n *Notifier) newMessage(event string, task commontypes.TaskHistory) NotifyMessage {
+	message := NotifyMessage{
+		Event:       event,
+		TaskId:      task.Id,
+		AppName:     task.AppName,
+		TaskType:    task.TaskType,
+		TriggerType: task.TriggerType,
+		Status:      task.Status,
+		Success:     task.Success.Bool,
+		ErrMessage:  task.ErrMessage,
+		Expire:      task.Expire,
+		Tenant:      task.Tenant,
+		Workloads:   []NotifyWorkload{},
+		Time:        time.Now().Format("2006-01-02 15:04:05"),
+	}
+	// the workloads of a pipeline task are in its stage tasks
+	var workloads []commontypes.TaskHistoryWorkload
+	if err := n.db.Where("task_history_id = ? OR task_history_id IN (?)", task.Id,
+		n.db.Model(&commontypes.TaskHistory{}).Select("id").Where("parent_id = ?", task.Id)).Find(&workloads).Error; err != nil {
+		logx.Error(err)
+	}
+	for _, w := range workloads {
+		message.Workloads = append(message.Workloads, NotifyWorkload{
+			Cluster:      w.Workload.Cluster,
+			Namespace:    w.Workload.Namespace,
+			WorkloadName: w.Workload.WorkloadName,
+			ArtifactUrl:  w.Workload.ArtifactUrl,
+			Success:      w.Success.Bool,
+			ErrMessage:   w.ErrMessage,
+		})
+	}
+	return message
+}
+
+// NewTestMessage returns a message for testing a channel
+func NewTestMessage(tenant string) NotifyMessage {
+	return NotifyMessage{
+		Event:       constant.NOTIFY_EVENT_TEST,
+		TaskId:      "test",
+		AppName:     "lizardcd-test",
+		TaskType:    constant.K8S_TASK_TYPE_DEPLOY,
+		TriggerType: "手动触发",
+		Status:      constant.TASK_STATUS_FINISHED,
+		Success:     true,
+		Tenant:      tenant,
+		Workloads: []NotifyWorkload{{
+			Cluster:      "test",
+			Namespace:    "default",
+			WorkloadName: "lizardcd-test",
+			ArtifactUrl:  "nginx:latest",
+			Success:      true,
+		}},
+		Time: time.Now().Format("2006-01-02 15:04:05"),
+	}
+}
+
+// Send sends a message to a channel
+func (n *Notifier) Send(channel commontypes.NotifyChannel, message NotifyMessage) (err error) {
+	if channel.Secret, err = utils.DecryptSecret(channel.Secret); err != nil {
+		return
+	}
+	if channel.SmtpPassword, err = utils.DecryptSecret(channel.SmtpPassword); err != nil {
+		return
+	}
+	if channel.ChannelType == constant.NOTIFY_CHANNEL_WEBHOOK {
+		return sendWebhook(channel, message)
+	}
+	text, err := notifyText(channel, message)
+	if err != nil {
+		return err
+	}
+	switch channel.ChannelType {
+	case constant.NOTIFY_CHANNEL_DINGTALK:
+		return sendDingTalk(channel, text)
+	case constant.NOTIFY_CHANNEL_WECOM:
+		return sendRobot(channel.Url, "http.SendWeCom", map[string]interface{}{
+			"msgtype": "text",
+			"text":    map[string]string{"content": text},
+		})
+	case constant.NOTIFY_CHANNEL_SLACK:
+		return postJson(channel.Url, "http.SendSlack", map[string]string{"text": text}, nil)
+	case constant.NOTIFY_CHANNEL_EMAIL:
+		return sendEmail(channel, notifyTitle(message), text)
+	}
+	return fmt.Errorf("unsupported channel type \"%s\"", channel.ChannelType)
+}
+
+func notifyTitle(message NotifyMessage) string {
+	return fmt.Sprintf("[lizardcd] 应用 %s %s任务%s", message.AppName, message.TaskType, notifyEventNames[message.Event])
+}
+
+// notifyText renders the template of channel, or the default text
+func notifyText(channel commontypes.NotifyChannel, message NotifyMessage) (string, error) {
+	if channel.Template != "" {
+		return renderTemplate(channel.Template, message)
+	}
+	var b strings.Builder
+	b.WriteString(notifyTitle(message) + "\n")
+	b.WriteString(fmt.Sprintf("任务ID: %s\n触发方式: %s\n状态: %s\n", message.TaskId, message.TriggerType, message.Status))
+	if message.Expire != "" {
+		b.WriteString(fmt.Sprintf("耗时: %s\n", message.Expire))
+	}
+	if len(message.Workloads) > 0 {
+		b.WriteString("工作负载:\n")
+		for _, w := range message.Workloads {
+			b.WriteString(fmt.Sprintf("- %s/%s/%s %s", w.Cluster, w.Namespace, w.WorkloadName, w.ArtifactUrl))
+			if w.ErrMessage != "" {
+				b.WriteString(" " + w.ErrMessage)
+			}
+			b.WriteString("\n")
+		}
+	}
+	if message.ErrMessage != "" {
+		b.WriteString(fmt.Sprintf("错误信息: %s\n", message.ErrMessage))
+	}
+	b.WriteString(fmt.Sprintf("时间: %s", message.Time))
+	return b.String(), nil
+}
+
+// renderTemplate renders a go template with the message, e.g. {"text": "{{.AppName}} {{.Event}}"}, "json" marshals a value
+func renderTemplate(text string, message NotifyMessage) (string, error) {
+	tpl, err := template.New("notify").Funcs(template.FuncMap{
+		"json": func(v interface{}) (string, error) {
+			b, err := json.Marshal(v)
+			return string(b), err
+		},
+	}).Parse(text)
+	if err != nil {
+		return "", fmt.Errorf("invalid template: %v", err)
+	}
+	var buf bytes.Buffer
+	if err = tpl.Execute(&buf, message); err != nil {
+		return "", fmt.Errorf("failed to render template: %v", err)
+	}
+	return buf.String(), nil
+}
+
+// sendWebhook posts the rendered template, or the message as json, signed by header X-Lizardcd-Signature if secret is set
+func sendWebhook(channel commontypes.NotifyChannel, message NotifyMessage) (err error) {
+	var body []byte
+	if channel.Template != "" {
+		var text string
+		if text, err = renderTemplate(channel.Template, message); err != nil {
+			return
+		}
+		body = []byte(text)
+	} else if body, err = json.Marshal(message); err != nil {
+		return
+	}
+	headers := map[string]string{"Content-Type": "application/json"}
+	if channel.Secret != "" {
+		mac := hmac.New(sha256.New, []byte(channel.Secret))
+		mac.Write(body)
+		headers["X-Lizardcd-Signature"] = "sha256=" + hex.EncodeToString(mac.Sum(nil))
+	}
+	return postJson(channel.Url, "http.SendWebhook", body, headers)
+}
+
+// sendDingTalk sends text to a DingTalk robot, which is signed if the robot enables signature
+func sendDingTalk(channel commontypes.NotifyChannel, text string) error {
+	robotUrl := channel.Url
+	if channel.Secret != "" {
+		timestamp := strconv.FormatInt(time.Now().UnixMilli(), 10)
+		mac := hmac.New(sha256.New, []byte(channel.Secret))
+		mac.Write([]byte(timestamp + "\n" + channel.Secret))
+		sign := url.QueryEscape(base64.StdEncoding.EncodeToString(mac.Sum(nil)))
+		robotUrl += fmt.Sprintf("&timestamp=%s&sign=%s", timestamp, sign)
+	}
+	return sendRobot(robotUrl, "http.SendDingTalk", map[string]interface{}{
+		"msgtype": "text",
+		"text":    map[string]string{"content": text},
+	})
+}
+
+// sendRobot sends to a DingTalk or WeCom robot, which responses errcode 0 if succeeded
+func sendRobot(robotUrl, traceId string, body interface{}) error {
+	var res struct {
+		Errcode int    `json:"errcode"`
+		Errmsg  string `json:"errmsg"`
+	}
+	if err := postJson(robotUrl, traceId, body, nil, &res); err != nil {
+		return err
+	}
+	if res.Errcode != 0 {
+		return fmt.Errorf("errcode=%d errmsg=%s", res.Errcode, res.Errmsg)
+	}
+	return nil
+}
+
+func postJson(url, traceId string, body interface{}, headers map[string]string, result ...interface{}) error {
+	client := utils.NewHttpClient(otel.Tracer("imroc/req"))
+	req := client.Post(url).SetHeader("Content-Type", "application/json").SetHeaders(headers).SetBody(body)
+	if len(result) > 0 {
+		req.SetResult(result[0])
+	}
+	return req.Do(context.WithValue(context.Background(), commontypes.TraceIDKey{}, traceId)).Err
+}
+
+// sendEmail sends a plain text email by SMTP, using STARTTLS if the server supports it
+func sendEmail(channel commontypes.NotifyChannel, subject, text string) error {
+	if len(channel.MailTo) == 0 {
+		return fmt.Errorf("mail_to is empty")
+	}
+	var auth smtp.Auth
+	if channel.SmtpUsername != "" {
+		host := strings.Split(channel.SmtpAddr, ":")[0]
+		auth = smtp.PlainAuth("", channel.SmtpUsername, channel.SmtpPassword, host)
+	}
+	msg := fmt.Sprintf("From: %s\r\nTo: %s\r\nSubject: =?UTF-8?B?%s?=\r\nMIME-Version: 1.0\r\nContent-Type: text/plain; charset=UTF-8\r\n\r\n%s\r\n",
+		channel.MailFrom, strings.Join(channel.MailTo, ","), base64.StdEncoding.EncodeToString([]byte(subject)), text)
+	return smtp.SendMail(channel.SmtpAddr, auth, channel.MailFrom, channel.MailTo, []byte(msg))
+}
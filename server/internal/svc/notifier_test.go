@@ -0,0 +1,167 @@
+package svc
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/hongyuxuan/lizardcd/common/constant"
+	commontypes "github.com/hongyuxuan/lizardcd/common/types"
+)
+
+// notifyStub records the requests of a channel and responds body
+type notifyStub struct {
+	*httptest.Server
+	requests []*http.Request
+	bodies   [][]byte
+}
+
+func newNotifyStub(t *testing.T, body string) *notifyStub {
+	stub := &notifyStub{}
+	stub.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, _ := io.ReadAll(r.Body)
+		stub.requests = append(stub.requests, r)
+		stub.bodies = append(stub.bodies, b)
+		w.Header().Set("Content-Type", "application/json")
+		io.WriteString(w, body)
+	}))
+	t.Cleanup(stub.Close)
+	return stub
+}
+
+func TestTaskNotifyEvent(t *testing.T) {
+	tests := []struct {
+		name string
+		task commontypes.TaskHistory
+		want string
+	}{
+		{"running", commontypes.TaskHistory{Status: constant.TASK_STATUS_RUNNING}, constant.NOTIFY_EVENT_START},
+		{"waiting approval", commontypes.TaskHistory{Status: constant.TASK_STATUS_WAITING_APPROVAL, Success: sql.NullBool{Valid: true}}, ""},
+		{"success", commontypes.TaskHistory{Status: constant.TASK_STATUS_FINISHED, Success: sql.NullBool{Bool: true, Valid: true}}, constant.NOTIFY_EVENT_SUCCESS},
+		{"timeout", commontypes.TaskHistory{Status: constant.TASK_STATUS_FINISHED, Reason: constant.TASK_REASON_TIMEOUT, Success: sql.NullBool{Valid: true}}, constant.NOTIFY_EVENT_TIMEOUT},
+		{"failure mentioning timeout", commontypes.TaskHistory{Status: constant.TASK_STATUS_FINISHED, ErrMessage: "readiness probe TIMEOUT", Success: sql.NullBool{Valid: true}}, constant.NOTIFY_EVENT_FAILURE},
+		{"cancelled", commontypes.TaskHistory{Status: constant.TASK_STATUS_CANCELLED, Reason: constant.TASK_REASON_CANCELLED, Success: sql.NullBool{Valid: true}}, constant.NOTIFY_EVENT_FAILURE},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := taskNotifyEvent(tt.task); got != tt.want {
+				t.Errorf("taskNotifyEvent() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSendWebhook(t *testing.T) {
+	stub := newNotifyStub(t, `{}`)
+	channel := commontypes.NotifyChannel{
+		ChannelType: constant.NOTIFY_CHANNEL_WEBHOOK,
+		Url:         stub.URL,
+		Secret:      "s3cret",
+	}
+	message := NewTestMessage("default")
+	if err := NewNotifier(nil).Send(channel, message); err != nil {
+		t.Fatal(err)
+	}
+	if len(stub.requests) != 1 {
+		t.Fatalf("got %d requests, want 1", len(stub.requests))
+	}
+	var got NotifyMessage
+	if err := json.Unmarshal(stub.bodies[0], &got); err != nil {
+		t.Fatal(err)
+	}
+	if got.Event != constant.NOTIFY_EVENT_TEST || got.AppName != message.AppName {
+		t.Errorf("got message %+v", got)
+	}
+	mac := hmac.New(sha256.New, []byte(channel.Secret))
+	mac.Write(stub.bodies[0])
+	if want := "sha256=" + hex.EncodeToString(mac.Sum(nil)); stub.requests[0].Header.Get("X-Lizardcd-Signature") != want {
+		t.Errorf("signature = %q, want %q", stub.requests[0].Header.Get("X-Lizardcd-Signature"), want)
+	}
+}
+
+func TestSendWebhookTemplate(t *testing.T) {
+	stub := newNotifyStub(t, `{}`)
+	channel := commontypes.NotifyChannel{
+		ChannelType: constant.NOTIFY_CHANNEL_WEBHOOK,
+		Url:         stub.URL,
+		Template:    `{"app": "{{.AppName}}", "workloads": {{json .Workloads}}}`,
+	}
+	if err := NewNotifier(nil).Send(channel, NewTestMessage("default")); err != nil {
+		t.Fatal(err)
+	}
+	var got struct {
+		App       string           `json:"app"`
+		Workloads []NotifyWorkload `json:"workloads"`
+	}
+	if err := json.Unmarshal(stub.bodies[0], &got); err != nil {
+		t.Fatalf("invalid body %s: %v", stub.bodies[0], err)
+	}
+	if got.App != "lizardcd-test" || len(got.Workloads) != 1 {
+		t.Errorf("got %+v", got)
+	}
+	if stub.requests[0].Header.Get("X-Lizardcd-Signature") != "" {
+		t.Error("unsigned webhook has signature")
+	}
+}
+
+func TestSendDingTalk(t *testing.T) {
+	stub := newNotifyStub(t, `{"errcode": 0, "errmsg": "ok"}`)
+	channel := commontypes.NotifyChannel{
+		ChannelType: constant.NOTIFY_CHANNEL_DINGTALK,
+		Url:         stub.URL + "/robot/send?access_token=token",
+		Secret:      "SECxxx",
+	}
+	if err := NewNotifier(nil).Send(channel, NewTestMessage("default")); err != nil {
+		t.Fatal(err)
+	}
+	query := stub.requests[0].URL.Query()
+	if query.Get("access_token") != "token" || query.Get("timestamp") == "" || query.Get("sign") == "" {
+		t.Errorf("query = %v", query)
+	}
+	var got struct {
+		Msgtype string `json:"msgtype"`
+		Text    struct {
+			Content string `json:"content"`
+		} `json:"text"`
+	}
+	json.Unmarshal(stub.bodies[0], &got)
+	if got.Msgtype != "text" || !strings.Contains(got.Text.Content, "lizardcd-test") {
+		t.Errorf("got %+v", got)
+	}
+}
+
+func TestSendRobotError(t *testing.T) {
+	stub := newNotifyStub(t, `{"errcode": 93000, "errmsg": "invalid webhook url"}`)
+	channel := commontypes.NotifyChannel{
+		ChannelType: constant.NOTIFY_CHANNEL_WECOM,
+		Url:         stub.URL,
+	}
+	err := NewNotifier(nil).Send(channel, NewTestMessage("default"))
+	if err == nil || !strings.Contains(err.Error(), "errcode=93000") {
+		t.Errorf("err = %v, want errcode=93000", err)
+	}
+}
+
+func TestSendSlack(t *testing.T) {
+	stub := newNotifyStub(t, `ok`)
+	channel := commontypes.NotifyChannel{
+		ChannelType: constant.NOTIFY_CHANNEL_SLACK,
+		Url:         stub.URL,
+		Template:    "{{.AppName}} {{.Event}}",
+	}
+	if err := NewNotifier(nil).Send(channel, NewTestMessage("default")); err != nil {
+		t.Fatal(err)
+	}
+	var got map[string]string
+	json.Unmarshal(stub.bodies[0], &got)
+	if got["text"] != "lizardcd-test test" {
+		t.Errorf("text = %q", got["text"])
+	}
+}
@@ -0,0 +1,272 @@
+package svc
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/hongyuxuan/lizardcd/common/constant"
+	"github.com/hongyuxuan/lizardcd/common/errorx"
+	"github.com/hongyuxuan/lizardcd/server/internal/config"
+	"golang.org/x/oauth2"
+)
+
+const oidcStateExpire = 10 * time.Minute
+
+// OidcStateCookie is the cookie binding the state of a login to the browser starting it
+const OidcStateCookie = "lizardcd_oidc_state"
+
+// OidcService is the authorization code flow of OIDC single sign-on
+type OidcService struct {
+	conf     config.OidcConf
+	key      []byte // signing the state cookie
+	mu       sync.Mutex
+	provider *oidcProvider
+}
+
+type oidcProvider struct {
+	Issuer                string `json:"issuer"`
+	AuthorizationEndpoint string `json:"authorization_endpoint"`
+	TokenEndpoint         string `json:"token_endpoint"`
+	UserinfoEndpoint      string `json:"userinfo_endpoint"`
+}
+
+// oidcState is saved in the signed state cookie, so the callback can be handled by any lizardcd-server
+type oidcState struct {
+	State    string `json:"state"`
+	Nonce    string `json:"nonce"`
+	Verifier string `json:"verifier"`
+	jwt.RegisteredClaims
+}
+
+// NewOidcService signs the state cookie by a key derived from secret, so the cookie is never accepted as a jwt token of users
+func NewOidcService(conf config.OidcConf, secret string) *OidcService {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte("lizardcd oidc state"))
+	return &OidcService{
+		conf: conf,
+		key:  mac.Sum(nil),
+	}
+}
+
+func (o *OidcService) Enabled() bool {
+	return o.conf.Issuer != ""
+}
+
+// AuthCodeURL returns the url of the provider to login with a new state, nonce and PKCE challenge,
+// and the state cookie which must be set to the browser, so the callback is only accepted from the same browser
+func (o *OidcService) AuthCodeURL(ctx context.Context) (string, *http.Cookie, error) {
+	cfg, _, err := o.oauth2Config(ctx)
+	if err != nil {
+		return "", nil, err
+	}
+	s := oidcState{
+		State:            oauth2.GenerateVerifier(),
+		Nonce:            oauth2.GenerateVerifier(),
+		Verifier:         oauth2.GenerateVerifier(),
+		RegisteredClaims: jwt.RegisteredClaims{ExpiresAt: jwt.NewNumericDate(time.Now().Add(oidcStateExpire))},
+	}
+	value, err := jwt.NewWithClaims(jwt.SigningMethodHS256, s).SignedString(o.key)
+	if err != nil {
+		return "", nil, err
+	}
+	cookie := o.stateCookie(value, int(oidcStateExpire.Seconds()))
+	return cfg.AuthCodeURL(s.State, oauth2.SetAuthURLParam("nonce", s.Nonce), oauth2.S256ChallengeOption(s.Verifier)), cookie, nil
+}
+
+// ClearStateCookie returns the cookie deleting the state cookie, which is used only once
+func (o *OidcService) ClearStateCookie() *http.Cookie {
+	return o.stateCookie("", -1)
+}
+
+// stateCookie is only sent to the callback of lizardcd-server
+func (o *OidcService) stateCookie(value string, maxAge int) *http.Cookie {
+	path := "/"
+	if u, err := url.Parse(o.conf.RedirectUrl); err == nil && u.Path != "" {
+		path = u.Path
+	}
+	return &http.Cookie{
+		Name:     OidcStateCookie,
+		Value:    value,
+		Path:     path,
+		MaxAge:   maxAge,
+		HttpOnly: true,
+		Secure:   strings.HasPrefix(o.conf.RedirectUrl, "https://"),
+		SameSite: http.SameSiteLaxMode, // sent on the top-level redirect from the provider
+	}
+}
+
+// parseState returns the state in the state cookie if it is signed by lizardcd-server, not expired and matches state
+func (o *OidcService) parseState(cookie, state string) (*oidcState, error) {
+	var s oidcState
+	if _, err := jwt.ParseWithClaims(cookie, &s, func(t *jwt.Token) (interface{}, error) {
+		if _, ok := t.Method.(*jwt.SigningMethodHMAC); !ok {
+			return nil, fmt.Errorf("unexpected signing method %v", t.Header["alg"])
+		}
+		return o.key, nil
+	}); err != nil || state == "" || subtle.ConstantTimeCompare([]byte(s.State), []byte(state)) != 1 {
+		return nil, errorx.NewError(http.StatusBadRequest, "登录状态无效或已过期，请重新登录", nil)
+	}
+	return &s, nil
+}
+
+// Exchange exchanges the code for the id token and returns its user. stateCookie is the value of the state cookie of the browser.
+// The id token is received from the token endpoint directly, so it is validated by TLS instead of its signature, see OpenID Connect Core 3.1.3.7.
+func (o *OidcService) Exchange(ctx context.Context, code, state, stateCookie string) (identity *UserIdentity, err error) {
+	s, err := o.parseState(stateCookie, state)
+	if err != nil {
+		return
+	}
+	cfg, provider, err := o.oauth2Config(ctx)
+	if err != nil {
+		return
+	}
+	token, err := cfg.Exchange(ctx, code, oauth2.VerifierOption(s.Verifier))
+	if err != nil {
+		return nil, errorx.NewError(http.StatusUnauthorized, fmt.Sprintf("获取token失败: %v", err), nil)
+	}
+	rawIdToken, _ := token.Extra("id_token").(string)
+	if rawIdToken == "" {
+		return nil, errorx.NewError(http.StatusUnauthorized, "token响应中没有id_token", nil)
+	}
+	claims := jwt.MapClaims{}
+	if _, _, err = jwt.NewParser().ParseUnverified(rawIdToken, claims); err != nil {
+		return nil, errorx.NewError(http.StatusUnauthorized, fmt.Sprintf("解析id_token失败: %v", err), nil)
+	}
+	if !claims.VerifyIssuer(provider.Issuer, true) || !claims.VerifyAudience(o.conf.ClientId, true) || !claims.VerifyExpiresAt(time.Now().Unix(), true) {
+		return nil, errorx.NewError(http.StatusUnauthorized, "id_token的iss、aud或exp无效", nil)
+	}
+	if nonce, _ := claims["nonce"].(string); nonce != s.Nonce {
+		return nil, errorx.NewError(http.StatusUnauthorized, "id_token的nonce无效", nil)
+	}
+	// the username or groups may be only returned by the userinfo endpoint
+	if (claims[o.conf.UsernameClaim] == nil || claims[o.conf.GroupsClaim] == nil) && provider.UserinfoEndpoint != "" {
+		if err = o.userinfo(ctx, cfg, token, provider.UserinfoEndpoint, claims); err != nil {
+			return
+		}
+	}
+	sub, _ := claims["sub"].(string)
+	if sub == "" {
+		return nil, errorx.NewError(http.StatusUnauthorized, "id_token中没有sub", nil)
+	}
+	identity = &UserIdentity{
+		Groups:  claimStrings(claims[o.conf.GroupsClaim]),
+		Source:  constant.USER_SOURCE_OIDC,
+		Subject: provider.Issuer + "|" + sub, // sub is only unique within its issuer
+	}
+	if identity.Username, _ = claims[o.conf.UsernameClaim].(string); identity.Username == "" {
+		return nil, errorx.NewError(http.StatusUnauthorized, fmt.Sprintf("id_token中没有用户名%s", o.conf.UsernameClaim), nil)
+	}
+	return
+}
+
+// MapUser returns the role and tenant mapped by the groups of a user
+func (o *OidcService) MapUser(groups []string) (role, tenant string, matched bool) {
+	return mapGroups(o.conf.GroupMappings, o.conf.DefaultRole, o.conf.DefaultTenant, groups)
+}
+
+// AutoCreateUser reports whether users not existed are created at their first login
+func (o *OidcService) AutoCreateUser() bool {
+	return o.conf.AutoCreateUser
+}
+
+// UiRedirectUrl returns the url of ui which the callback redirects to
+func (o *OidcService) UiRedirectUrl() string {
+	return o.conf.UiRedirectUrl
+}
+
+// userinfo adds the claims of the userinfo endpoint which are not in claims
+func (o *OidcService) userinfo(ctx context.Context, cfg *oauth2.Config, token *oauth2.Token, endpoint string, claims jwt.MapClaims) error {
+	res, err := cfg.Client(ctx, token).Get(endpoint)
+	if err != nil {
+		return errorx.NewError(http.StatusUnauthorized, fmt.Sprintf("获取userinfo失败: %v", err), nil)
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		return errorx.NewError(http.StatusUnauthorized, fmt.Sprintf("获取userinfo失败: %s", res.Status), nil)
+	}
+	info := map[string]interface{}{}
+	if err = json.NewDecoder(res.Body).Decode(&info); err != nil {
+		return errorx.NewError(http.StatusUnauthorized, fmt.Sprintf("解析userinfo失败: %v", err), nil)
+	}
+	for k, v := range info {
+		if _, ok := claims[k]; !ok {
+			claims[k] = v
+		}
+	}
+	return nil
+}
+
+func (o *OidcService) oauth2Config(ctx context.Context) (*oauth2.Config, *oidcProvider, error) {
+	provider, err := o.discover(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+	return &oauth2.Config{
+		ClientID:     o.conf.ClientId,
+		ClientSecret: o.conf.ClientSecret,
+		RedirectURL:  o.conf.RedirectUrl,
+		Scopes:       o.conf.Scopes,
+		Endpoint: oauth2.Endpoint{
+			AuthURL:  provider.AuthorizationEndpoint,
+			TokenURL: provider.TokenEndpoint,
+		},
+	}, provider, nil
+}
+
+// discover gets the endpoints of the provider once from its openid configuration
+func (o *OidcService) discover(ctx context.Context) (*oidcProvider, error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	if o.provider != nil {
+		return o.provider, nil
+	}
+	url := strings.TrimSuffix(o.conf.Issuer, "/") + "/.well-known/openid-configuration"
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	res, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, errorx.NewDefaultError("Failed to get openid configuration of %s: %v", o.conf.Issuer, err)
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		return nil, errorx.NewDefaultError("Failed to get openid configuration of %s: %s", o.conf.Issuer, res.Status)
+	}
+	var provider oidcProvider
+	if err = json.NewDecoder(res.Body).Decode(&provider); err != nil {
+		return nil, errorx.NewDefaultError("Failed to parse openid configuration of %s: %v", o.conf.Issuer, err)
+	}
+	if provider.Issuer != strings.TrimSuffix(o.conf.Issuer, "/") && provider.Issuer != o.conf.Issuer {
+		return nil, errorx.NewDefaultError("Issuer \"%s\" of openid configuration does not match \"%s\"", provider.Issuer, o.conf.Issuer)
+	}
+	o.provider = &provider
+	return o.provider, nil
+}
+
+// claimStrings returns a claim of a string or an array of strings as strings
+func claimStrings(claim interface{}) (res []string) {
+	switch v := claim.(type) {
+	case string:
+		if v != "" {
+			res = append(res, v)
+		}
+	case []interface{}:
+		for _, item := range v {
+			if s, ok := item.(string); ok {
+				res = append(res, s)
+			}
+		}
+	}
+	return
+}
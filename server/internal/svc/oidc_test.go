@@ -0,0 +1,203 @@
+package svc
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/hongyuxuan/lizardcd/server/internal/config"
+)
+
+// mockOidcProvider is a local OIDC provider serving discovery, token and userinfo endpoints.
+// Codes are issued by authorize with the PKCE challenge and nonce of an auth code url.
+type mockOidcProvider struct {
+	*httptest.Server
+	mu    sync.Mutex
+	codes map[string]mockOidcCode
+}
+
+type mockOidcCode struct {
+	challenge string
+	claims    jwt.MapClaims
+}
+
+func newMockOidcProvider(t *testing.T) *mockOidcProvider {
+	p := &mockOidcProvider{codes: make(map[string]mockOidcCode)}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]string{
+			"issuer":                 p.URL,
+			"authorization_endpoint": p.URL + "/authorize",
+			"token_endpoint":         p.URL + "/token",
+			"userinfo_endpoint":      p.URL + "/userinfo",
+		})
+	})
+	mux.HandleFunc("/token", func(w http.ResponseWriter, r *http.Request) {
+		r.ParseForm()
+		p.mu.Lock()
+		code, ok := p.codes[r.PostForm.Get("code")]
+		delete(p.codes, r.PostForm.Get("code"))
+		p.mu.Unlock()
+		sum := sha256.Sum256([]byte(r.PostForm.Get("code_verifier")))
+		if !ok || base64.RawURLEncoding.EncodeToString(sum[:]) != code.challenge {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(map[string]string{"error": "invalid_grant"})
+			return
+		}
+		idToken, _ := jwt.NewWithClaims(jwt.SigningMethodHS256, code.claims).SignedString([]byte("provider"))
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"access_token": "access-token",
+			"token_type":   "Bearer",
+			"expires_in":   3600,
+			"id_token":     idToken,
+		})
+	})
+	mux.HandleFunc("/userinfo", func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer access-token" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{"sub": "u-1", "groups": []string{"devops"}})
+	})
+	p.Server = httptest.NewServer(mux)
+	t.Cleanup(p.Close)
+	return p
+}
+
+// authorize issues a code for the auth code url, as if the user logged in to the provider
+func (p *mockOidcProvider) authorize(t *testing.T, authCodeURL string, claims jwt.MapClaims) string {
+	u, err := url.Parse(authCodeURL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	q := u.Query()
+	if q.Get("code_challenge_method") != "S256" {
+		t.Fatalf("code_challenge_method = %s, want S256", q.Get("code_challenge_method"))
+	}
+	if claims["nonce"] == nil {
+		claims["nonce"] = q.Get("nonce")
+	}
+	code := "code-" + q.Get("state")
+	p.mu.Lock()
+	p.codes[code] = mockOidcCode{challenge: q.Get("code_challenge"), claims: claims}
+	p.mu.Unlock()
+	return code
+}
+
+func newTestOidcService(issuer, secret string) *OidcService {
+	return NewOidcService(config.OidcConf{
+		Issuer:        issuer,
+		ClientId:      "lizardcd",
+		ClientSecret:  "secret",
+		RedirectUrl:   "https://lizardcd.example.com/lizardcd/auth/oidc/callback",
+		Scopes:        []string{"openid", "profile"},
+		UsernameClaim: "preferred_username",
+		GroupsClaim:   "groups",
+	}, secret)
+}
+
+// login starts a login and returns the state and the state cookie of the browser
+func login(t *testing.T, o *OidcService) (authCodeURL, state, cookie string) {
+	authCodeURL, c, err := o.AuthCodeURL(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !c.HttpOnly || !c.Secure || c.Path != "/lizardcd/auth/oidc/callback" || c.SameSite != http.SameSiteLaxMode {
+		t.Fatalf("unexpected state cookie %+v", c)
+	}
+	u, _ := url.Parse(authCodeURL)
+	return authCodeURL, u.Query().Get("state"), c.Value
+}
+
+func TestOidcExchange(t *testing.T) {
+	provider := newMockOidcProvider(t)
+	claims := func(modify func(jwt.MapClaims)) jwt.MapClaims {
+		c := jwt.MapClaims{
+			"iss":                provider.URL,
+			"aud":                "lizardcd",
+			"sub":                "u-1",
+			"exp":                time.Now().Add(time.Hour).Unix(),
+			"preferred_username": "alice",
+		}
+		if modify != nil {
+			modify(c)
+		}
+		return c
+	}
+
+	tests := []struct {
+		name string
+		// exchange returns the code, state and state cookie sent to the callback
+		exchange func(o *OidcService) (code, state, cookie string)
+		wantErr  bool
+	}{
+		{"success", func(o *OidcService) (string, string, string) {
+			u, state, cookie := login(t, o)
+			return provider.authorize(t, u, claims(nil)), state, cookie
+		}, false},
+		{"wrong issuer", func(o *OidcService) (string, string, string) {
+			u, state, cookie := login(t, o)
+			return provider.authorize(t, u, claims(func(c jwt.MapClaims) { c["iss"] = "https://evil.example.com" })), state, cookie
+		}, true},
+		{"wrong audience", func(o *OidcService) (string, string, string) {
+			u, state, cookie := login(t, o)
+			return provider.authorize(t, u, claims(func(c jwt.MapClaims) { c["aud"] = "other" })), state, cookie
+		}, true},
+		{"expired id token", func(o *OidcService) (string, string, string) {
+			u, state, cookie := login(t, o)
+			return provider.authorize(t, u, claims(func(c jwt.MapClaims) { c["exp"] = time.Now().Add(-time.Minute).Unix() })), state, cookie
+		}, true},
+		{"wrong nonce", func(o *OidcService) (string, string, string) {
+			u, state, cookie := login(t, o)
+			return provider.authorize(t, u, claims(func(c jwt.MapClaims) { c["nonce"] = "replayed" })), state, cookie
+		}, true},
+		{"code issued for the pkce challenge of another login", func(o *OidcService) (string, string, string) {
+			other, _, _ := login(t, o)
+			_, state, cookie := login(t, o)
+			return provider.authorize(t, other, claims(nil)), state, cookie
+		}, true},
+		{"state cookie of another browser", func(o *OidcService) (string, string, string) {
+			u, state, _ := login(t, o)
+			_, _, cookie := login(t, o)
+			return provider.authorize(t, u, claims(nil)), state, cookie
+		}, true},
+		{"no state cookie", func(o *OidcService) (string, string, string) {
+			u, state, _ := login(t, o)
+			return provider.authorize(t, u, claims(nil)), state, ""
+		}, true},
+		{"state cookie signed by another secret", func(o *OidcService) (string, string, string) {
+			u, state, cookie := login(t, newTestOidcService(provider.URL, "other"))
+			return provider.authorize(t, u, claims(nil)), state, cookie
+		}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			o := newTestOidcService(provider.URL, "access-secret")
+			code, state, cookie := tt.exchange(o)
+			identity, err := o.Exchange(context.Background(), code, state, cookie)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Exchange() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			if identity.Username != "alice" || identity.Subject != provider.URL+"|u-1" {
+				t.Errorf("Exchange() identity = %+v", identity)
+			}
+			// groups are not in the id token and only returned by the userinfo endpoint
+			if len(identity.Groups) != 1 || identity.Groups[0] != "devops" {
+				t.Errorf("Exchange() groups = %v, want [devops]", identity.Groups)
+			}
+		})
+	}
+}
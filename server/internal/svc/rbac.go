@@ -0,0 +1,133 @@
+package svc
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"path"
+
+	"github.com/hongyuxuan/lizardcd/common/constant"
+	"github.com/hongyuxuan/lizardcd/common/errorx"
+	commontypes "github.com/hongyuxuan/lizardcd/common/types"
+	"github.com/hongyuxuan/lizardcd/common/utils"
+	"github.com/zeromicro/go-zero/core/logx"
+	"gorm.io/gorm"
+)
+
+type Rbac struct {
+	db *gorm.DB
+}
+
+func NewRbac(db *gorm.DB) *Rbac {
+	return &Rbac{
+		db: db,
+	}
+}
+
+// RequireAdmin returns 403 with message if the user in ctx is not admin
+func RequireAdmin(ctx context.Context, message string) error {
+	if _, role, _, _ := utils.GetPayload(ctx); role != constant.ROLE_ADMIN {
+		return errorx.NewError(http.StatusForbidden, message, nil)
+	}
+	return nil
+}
+
+// Authorize checks the rbac policies of the user in ctx, or of the creator of the api token in ctx. Admin and users not bound by any policy are only restricted by their roles.
+// Empty cluster or namespace means the operation is not namespaced, which is matched by any pattern.
+func (r *Rbac) Authorize(ctx context.Context, cluster, namespace, resource, verb string) error {
+	username, role, _, _ := utils.GetPayload(ctx)
+	if role == constant.ROLE_ADMIN {
+		return nil
+	}
+	// an api token is bound by the policies of its creator, so it never exceeds the creator
+	if token, ok := ctx.Value(commontypes.ApiTokenKey{}).(*commontypes.ApiToken); ok {
+		username = token.CreateBy
+	}
+	policies, err := r.ListPolicies(ctx, username)
+	if err != nil {
+		return err
+	}
+	if len(policies) == 0 {
+		return nil
+	}
+	allowed := false
+	for _, policy := range policies {
+		if !policy.Match(cluster, namespace, resource, verb) {
+			continue
+		}
+		if policy.Effect == constant.RBAC_EFFECT_DENY {
+			allowed = false
+			break
+		}
+		allowed = true
+	}
+	if !allowed {
+		return errorx.NewError(http.StatusForbidden, fmt.Sprintf("没有此权限: %s %s, cluster=%s namespace=%s", verb, resource, cluster, namespace), nil)
+	}
+	return nil
+}
+
+// CheckNamespace checks that the cluster and namespace are one of the (cluster, namespace) pairs of the tenant of the user in ctx.
+// Admin can access all namespaces.
+func (r *Rbac) CheckNamespace(ctx context.Context, cluster, namespace string) error {
+	_, role, tenant, _ := utils.GetPayload(ctx)
+	if role == constant.ROLE_ADMIN {
+		return nil
+	}
+	return r.CheckTenantNamespace(ctx, tenant, cluster, namespace)
+}
+
+// CheckTenantNamespace checks that the cluster and namespace are one of the (cluster, namespace) pairs of tenant
+func (r *Rbac) CheckTenantNamespace(ctx context.Context, tenant, cluster, namespace string) error {
+	var t commontypes.Tenant
+	if err := r.db.WithContext(context.WithValue(ctx, commontypes.TraceIDKey{}, "sqlite.GetTenant")).
+		Where("tenant_name = ?", tenant).Limit(1).Find(&t).Error; err != nil {
+		return err
+	}
+	var nsclusters []commontypes.NsCluster
+	if t.Namespaces != "" {
+		if err := json.Unmarshal([]byte(t.Namespaces), &nsclusters); err != nil {
+			logx.WithContext(ctx).Errorf("Failed to parse namespaces of tenant \"%s\": %v", tenant, err)
+		}
+	}
+	for _, ns := range nsclusters {
+		if ns.Cluster == cluster && ns.Namespace == namespace {
+			return nil
+		}
+	}
+	return errorx.NewError(http.StatusForbidden, fmt.Sprintf("租户\"%s\"没有集群\"%s\"命名空间\"%s\"的权限", tenant, cluster, namespace), nil)
+}
+
+// ListPolicies returns the policies bound to the user or its groups
+func (r *Rbac) ListPolicies(ctx context.Context, username string) (policies []commontypes.RbacPolicy, err error) {
+	var user commontypes.User
+	if err = r.db.WithContext(context.WithValue(ctx, commontypes.TraceIDKey{}, "sqlite.GetUser")).
+		Select("username", "groups").Where("username = ?", username).Limit(1).Find(&user).Error; err != nil {
+		return
+	}
+	err = r.db.WithContext(context.WithValue(ctx, commontypes.TraceIDKey{}, "sqlite.ListRbacPolicy")).
+		Where("subject_type = ? AND subject = ?", constant.RBAC_SUBJECT_USER, username).
+		Or("subject_type = ? AND subject IN ?", constant.RBAC_SUBJECT_GROUP, []string(user.Groups)).
+		Find(&policies).Error
+	return
+}
+
+// ValidatePolicy checks the subject, effect and patterns of a policy
+func ValidatePolicy(policy commontypes.RbacPolicy) error {
+	if policy.SubjectType != constant.RBAC_SUBJECT_USER && policy.SubjectType != constant.RBAC_SUBJECT_GROUP {
+		return errorx.NewError(http.StatusBadRequest, fmt.Sprintf("subject_type只能是%s或%s", constant.RBAC_SUBJECT_USER, constant.RBAC_SUBJECT_GROUP), nil)
+	}
+	if policy.Subject == "" || policy.Resource == "" || len(policy.Verbs) == 0 {
+		return errorx.NewError(http.StatusBadRequest, "subject、resource和verbs不能为空", nil)
+	}
+	if policy.Effect != constant.RBAC_EFFECT_ALLOW && policy.Effect != constant.RBAC_EFFECT_DENY {
+		return errorx.NewError(http.StatusBadRequest, fmt.Sprintf("effect只能是%s或%s", constant.RBAC_EFFECT_ALLOW, constant.RBAC_EFFECT_DENY), nil)
+	}
+	for _, pattern := range []string{policy.Cluster, policy.Namespace, policy.Resource} {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return errorx.NewError(http.StatusBadRequest, fmt.Sprintf("无效的匹配模式\"%s\": %v", pattern, err), nil)
+		}
+	}
+	return nil
+}
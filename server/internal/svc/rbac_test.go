@@ -0,0 +1,64 @@
+package svc
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/hongyuxuan/lizardcd/common/constant"
+	commontypes "github.com/hongyuxuan/lizardcd/common/types"
+	"github.com/hongyuxuan/lizardcd/common/utils"
+	"gorm.io/gorm"
+)
+
+// newTestDB returns a sqlite database in a temporary directory with the tables of models
+func newTestDB(t *testing.T, models ...interface{}) *gorm.DB {
+	db := utils.NewSQLite(filepath.Join(t.TempDir(), "lizardcd.db"), "error")
+	if err := db.AutoMigrate(models...); err != nil {
+		t.Fatal(err)
+	}
+	return db
+}
+
+func withPayloads(username, role, tenant string) context.Context {
+	return context.WithValue(context.Background(), "payloads", map[string]interface{}{
+		"username":  username,
+		"role":      role,
+		"tenant":    tenant,
+		"namespace": "",
+	})
+}
+
+func TestAuthorizeApiToken(t *testing.T) {
+	db := newTestDB(t, &commontypes.User{}, &commontypes.RbacPolicy{})
+	db.Create(&commontypes.User{Username: "alice", Role: constant.ROLE_READWRITE, Tenant: "dev", Groups: commontypes.StringList{}})
+	db.Create(&[]commontypes.RbacPolicy{
+		{SubjectType: constant.RBAC_SUBJECT_USER, Subject: "alice", Cluster: "*", Namespace: "*", Resource: "*", Verbs: commontypes.StringList{"*"}, Effect: constant.RBAC_EFFECT_ALLOW},
+		{SubjectType: constant.RBAC_SUBJECT_USER, Subject: "alice", Cluster: "prod", Namespace: "*", Resource: "task", Verbs: commontypes.StringList{"run"}, Effect: constant.RBAC_EFFECT_DENY},
+	})
+	rbac := NewRbac(db)
+	token := &commontypes.ApiToken{Name: "ci", Role: constant.ROLE_READWRITE, Tenant: "dev", CreateBy: "alice", CreateAt: time.Now()}
+	ctx := context.WithValue(withPayloads(token.Username(), token.Role, token.Tenant), commontypes.ApiTokenKey{}, token)
+
+	tests := []struct {
+		name    string
+		ctx     context.Context
+		cluster string
+		wantErr bool
+	}{
+		{"user allowed", withPayloads("alice", constant.ROLE_READWRITE, "dev"), "test", false},
+		{"user denied", withPayloads("alice", constant.ROLE_READWRITE, "dev"), "prod", true},
+		{"token allowed", ctx, "test", false},
+		{"token denied by policies of creator", ctx, "prod", true},
+		{"admin", withPayloads("admin", constant.ROLE_ADMIN, "admin"), "prod", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := rbac.Authorize(tt.ctx, tt.cluster, "default", "task", "run")
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Authorize() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
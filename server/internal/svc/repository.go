@@ -6,8 +6,10 @@ import (
 	"net/url"
 	"os"
 	"regexp"
+	"strings"
 	"time"
 
+	"github.com/hongyuxuan/lizardcd/common/constant"
 	"github.com/hongyuxuan/lizardcd/common/errorx"
 	commontypes "github.com/hongyuxuan/lizardcd/common/types"
 	"github.com/hongyuxuan/lizardcd/common/utils"
@@ -120,3 +122,113 @@ func (r *RepoService) GetS3ArtifactList(repo commontypes.ImageRepository, repoNa
 	}
 	return
 }
+
+// Repository returns repo as stored in image_repository with the decrypted password, the repo of applications does not contain the password
+func (r *RepoService) Repository(repo commontypes.ImageRepository) (res commontypes.ImageRepository, err error) {
+	if repo.Id == 0 {
+		return repo, nil
+	}
+	if err = r.svcCtx.Sqlite.WithContext(context.WithValue(r.ctx, commontypes.TraceIDKey{}, "sqlite.GetImageRepository")).
+		Where("id = ?", repo.Id).First(&res).Error; err != nil {
+		return res, errorx.NewDefaultError("Failed to get image repository \"%s\": %v", repo.RepoUrl, err)
+	}
+	res.RepoPassword, err = utils.DecryptSecret(res.RepoPassword)
+	return
+}
+
+// ListArtifacts lists the artifacts of the image of application, tag is a filter of DockerHub only
+func (r *RepoService) ListArtifacts(application commontypes.Application, tag string) (artifacts []commontypes.ArtifactListRes, err error) {
+	if application.Repo, err = r.Repository(application.Repo); err != nil {
+		return
+	}
+	if application.Repo.RepoType == constant.REPO_TYPE_ARTIFACTORY {
+		var fileList []commontypes.JfrogFileItem
+		if fileList, err = r.GetJrogArtifactList(application.Repo, application.RepoName, application.ImageName); err != nil {
+			r.Logger.Errorf("Jforg failed to list %s, %v", application.RepoName, err)
+			return
+		}
+		matches := regexp.MustCompile(`http[s]{0,1}://(.+)`).FindStringSubmatch(application.Repo.RepoUrl)
+		if len(matches) < 2 {
+			return nil, errorx.NewDefaultError("repo \"%s\" is not valid", application.Repo.RepoUrl)
+		}
+		for _, item := range fileList {
+			if strings.Contains(item.Uri, "sha256") {
+				continue
+			}
+			artifact_url := fmt.Sprintf("%s/%s/%s:%s", matches[1], application.RepoName, application.ImageName, item.Uri[1:])
+			if application.DeployType != "容器" {
+				artifact_url = fmt.Sprintf("%s/artifactory/%s/%s%s", application.Repo.RepoUrl, application.RepoName, application.ImageName, item.Uri)
+			}
+			artifacts = append(artifacts, commontypes.ArtifactListRes{
+				ArtifactUrl:  artifact_url,
+				LastModified: item.LastModified,
+				Tag:          item.Uri[1:],
+			})
+		}
+	}
+	if application.Repo.RepoType == constant.REPO_TYPE_HARBOR {
+		var fileList []commontypes.HarborFileItem
+		if fileList, err = r.GetHarborArtifactList(application.Repo, application.RepoName, application.ImageName); err != nil {
+			r.Logger.Errorf("Harbor failed to list %s, %v", application.RepoName, err)
+			return
+		}
+		matches := regexp.MustCompile(`http[s]{0,1}://(.+)`).FindStringSubmatch(application.Repo.RepoUrl)
+		if len(matches) < 2 {
+			return nil, errorx.NewDefaultError("repo \"%s\" is not valid", application.Repo.RepoUrl)
+		}
+		for _, item := range fileList {
+			if len(item.Tags) == 0 { // untagged artifacts
+				continue
+			}
+			artifacts = append(artifacts, commontypes.ArtifactListRes{
+				ArtifactUrl:  fmt.Sprintf("%s/%s/%s:%s", matches[1], application.RepoName, application.ImageName, item.Tags[0].Name),
+				LastModified: item.Tags[0].PushTime,
+				Tag:          item.Tags[0].Name,
+			})
+		}
+	}
+	if application.Repo.RepoType == constant.REPO_TYPE_DOCKERHUB {
+		var fileList []commontypes.DockerHubImageItem
+		if fileList, err = r.GetDockerHubImages(application.Repo, application.RepoName, application.ImageName, tag); err != nil {
+			r.Logger.Errorf("DockerHub failed to fetch %s/%s, %v", application.RepoName, application.ImageName, err)
+			return
+		}
+		for _, item := range fileList {
+			artifactUrl := fmt.Sprintf("%s/%s:%s", application.RepoName, application.ImageName, item.Name)
+			if application.RepoName == "library" {
+				artifactUrl = fmt.Sprintf("%s:%s", application.ImageName, item.Name)
+			}
+			artifacts = append(artifacts, commontypes.ArtifactListRes{
+				ArtifactUrl:  artifactUrl,
+				LastModified: item.LastUpdated,
+				Tag:          item.Name,
+			})
+		}
+	}
+	if application.Repo.RepoType == constant.REPO_TYPE_S3 {
+		return r.GetS3ArtifactList(application.Repo, application.RepoName, application.ImageName)
+	}
+	return
+}
+
+// GetArtifactUrl returns the artifact url of a tag of the application, in the same format as listing image tags
+func (r *RepoService) GetArtifactUrl(application commontypes.Application, tag string) string {
+	host := application.Repo.RepoUrl
+	if matches := regexp.MustCompile(`http[s]{0,1}://(.+)`).FindStringSubmatch(application.Repo.RepoUrl); len(matches) > 1 {
+		host = matches[1]
+	}
+	switch application.Repo.RepoType {
+	case constant.REPO_TYPE_ARTIFACTORY:
+		if application.DeployType != constant.DEPLOY_TYPE_CONTAINER {
+			return fmt.Sprintf("%s/artifactory/%s/%s/%s", application.Repo.RepoUrl, application.RepoName, application.ImageName, tag)
+		}
+	case constant.REPO_TYPE_DOCKERHUB:
+		if application.RepoName == "library" {
+			return fmt.Sprintf("%s:%s", application.ImageName, tag)
+		}
+		return fmt.Sprintf("%s/%s:%s", application.RepoName, application.ImageName, tag)
+	case constant.REPO_TYPE_S3:
+		return fmt.Sprintf("%s/%s/%s/%s", application.Repo.RepoUrl, application.RepoName, application.ImageName, tag)
+	}
+	return fmt.Sprintf("%s/%s/%s:%s", host, application.RepoName, application.ImageName, tag)
+}
@@ -0,0 +1,154 @@
+package svc
+
+import (
+	"encoding/json"
+
+	commontypes "github.com/hongyuxuan/lizardcd/common/types"
+	"github.com/hongyuxuan/lizardcd/common/utils"
+)
+
+// secretColumns are the columns of credentials, which are encrypted in sqlite and masked in responses
+var secretColumns = map[string][]string{
+	"image_repository":  {"repo_password"},
+	"helm_repositories": {"password"},
+	"notify_channel":    {"secret", "smtp_password"},
+}
+
+// HasSecrets reports whether tablename stores credentials
+func HasSecrets(tablename string) bool {
+	return tablename == "application" || len(secretColumns[tablename]) > 0
+}
+
+// EncryptSecrets encrypts the credentials in body written to tablename by the generic db apis.
+// old is the row being updated, whose credentials are kept if masked in body, or nil when creating.
+func EncryptSecrets(tablename string, body, old map[string]interface{}) (err error) {
+	for _, column := range secretColumns[tablename] {
+		v, ok := body[column].(string)
+		if !ok {
+			continue
+		}
+		if v == utils.SecretMask {
+			if old != nil {
+				delete(body, column)
+			} else {
+				body[column] = ""
+			}
+			continue
+		}
+		if body[column], err = utils.EncryptSecret(v); err != nil {
+			return
+		}
+	}
+	if tablename != "application" {
+		return
+	}
+	var oldApp commontypes.Application
+	if old != nil {
+		oldApp.Webhook.Scan(old["webhook"])
+		oldApp.ExtraVars = columnString(old["extra_vars"])
+	}
+	// the password of repo is read from image_repository, see RepoService.Repository
+	if err = convertJsonColumn(body, "repo", func(v map[string]interface{}) error {
+		delete(v, "repo_password")
+		return nil
+	}); err != nil {
+		return
+	}
+	if err = convertJsonColumn(body, "webhook", func(v map[string]interface{}) (err error) {
+		secret, _ := v["secret"].(string)
+		if secret == utils.SecretMask {
+			secret = oldApp.Webhook.Secret
+		}
+		if secret == "" {
+			delete(v, "secret")
+			return
+		}
+		v["secret"], err = utils.EncryptSecret(secret)
+		return
+	}); err != nil {
+		return
+	}
+	if extraVars, ok := body["extra_vars"].(string); ok {
+		oldHeaders := extraVarsHeaders(oldApp.ExtraVars)
+		body["extra_vars"], err = utils.ConvertHttpHeaders(extraVars, func(key, value string) (string, error) {
+			if value == utils.SecretMask {
+				value = oldHeaders[key]
+			}
+			return utils.EncryptSecret(value)
+		})
+	}
+	return
+}
+
+// MaskSecrets masks the credentials in rows of tablename read by the generic db apis
+func MaskSecrets(tablename string, rows []map[string]interface{}) {
+	for _, row := range rows {
+		for _, column := range secretColumns[tablename] {
+			if v, ok := row[column].(string); ok {
+				row[column] = utils.MaskSecret(v)
+			}
+		}
+	}
+}
+
+// MaskApplication masks the credentials of the webhook and http deploy headers of application
+func MaskApplication(application *commontypes.Application) {
+	application.Repo.RepoPassword = utils.MaskSecret(application.Repo.RepoPassword)
+	application.Webhook.Secret = utils.MaskSecret(application.Webhook.Secret)
+	application.ExtraVars, _ = utils.ConvertHttpHeaders(application.ExtraVars, func(_, value string) (string, error) {
+		return utils.MaskSecret(value), nil
+	})
+}
+
+// MaskTaskHistory masks the http headers saved in the health check of http workloads
+func MaskTaskHistory(task *commontypes.TaskHistory) {
+	for i := range task.TaskHistoryWorkloads {
+		w := &task.TaskHistoryWorkloads[i]
+		w.HealthCheck, _ = utils.ConvertHttpHeaders(w.HealthCheck, func(_, value string) (string, error) {
+			return utils.MaskSecret(value), nil
+		})
+	}
+}
+
+// convertJsonColumn converts the json object of column in body, which is either a json string or an object
+func convertJsonColumn(body map[string]interface{}, column string, convert func(map[string]interface{}) error) error {
+	switch v := body[column].(type) {
+	case map[string]interface{}:
+		return convert(v)
+	case string:
+		obj := map[string]interface{}{}
+		if err := json.Unmarshal([]byte(v), &obj); err != nil {
+			return nil // not a json object, nothing to convert
+		}
+		if err := convert(obj); err != nil {
+			return err
+		}
+		b, err := json.Marshal(obj)
+		if err != nil {
+			return err
+		}
+		body[column] = string(b)
+	}
+	return nil
+}
+
+// columnString returns the value of a text column read into a map
+func columnString(v interface{}) string {
+	switch s := v.(type) {
+	case string:
+		return s
+	case []byte:
+		return string(s)
+	}
+	return ""
+}
+
+// extraVarsHeaders returns the http deploy headers of extra_vars as they are stored
+func extraVarsHeaders(extraVars string) map[string]string {
+	headers := map[string]string{}
+	utils.ConvertHttpHeaders(extraVars, func(key, value string) (string, error) {
+		headers[key] = value
+		return value, nil
+	})
+	return headers
+}
@@ -1,11 +1,15 @@
 package svc
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"net"
 	"os"
 	"regexp"
 	"strconv"
 	"strings"
+	"sync/atomic"
 	"time"
 
 	capi "github.com/hashicorp/consul/api"
@@ -22,28 +26,71 @@ import (
 	"github.com/nacos-group/nacos-sdk-go/vo"
 	"github.com/zeromicro/go-zero/core/logx"
 	"github.com/zeromicro/go-zero/rest"
+	"github.com/zeromicro/go-zero/zrpc"
 	clientv3 "go.etcd.io/etcd/client/v3"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/keepalive"
 	"gorm.io/gorm"
 )
 
 type ServiceContext struct {
 	Config       config.Config
-	AgentList    map[string]*types.RpcAgent
+	AgentList    *AgentList
 	EtcdClient   *clientv3.Client
 	ConsulClient *capi.Client
 	NacosClient  naming_client.INamingClient
 	Sqlite       *gorm.DB
 	Version      string
 	Validateuser rest.Middleware
+	Authorize    rest.Middleware
+	Audit        rest.Middleware
+	ApiToken     rest.Middleware
+	ApiTokens    *ApiTokens
+	Auditor      *Auditor
+	Rbac         *Rbac
+	Oidc         *OidcService
+	Ldap         *LdapService
+	TaskEvents   *TaskEventHub
+	Notifier     *Notifier
+	StaticAgents *StaticAgents
+	agentCreds   credentials.TransportCredentials // tls to agents, nil if insecure
 }
 
 func NewServiceContext(c config.Config) *ServiceContext {
+	utils.SetMasterKey(c.MasterKey)
+	if c.MasterKey == "" {
+		logx.Errorf("MasterKey is not set, credentials of repositories and notify channels are stored in plaintext")
+	}
 	svcCtx := &ServiceContext{
 		Config:       c,
-		AgentList:    make(map[string]*types.RpcAgent),
+		AgentList:    NewAgentList(),
 		Sqlite:       utils.NewSQLite(c.Sqlite, c.Log.Level),
 		Validateuser: middleware.NewValidateuserMiddleware().Handle,
 	}
+	svcCtx.Rbac = NewRbac(svcCtx.Sqlite)
+	svcCtx.Authorize = middleware.NewAuthorizeMiddleware(svcCtx.Rbac.CheckNamespace, svcCtx.Rbac.Authorize).Handle
+	svcCtx.Oidc = NewOidcService(c.Oidc, c.Auth.AccessSecret)
+	svcCtx.Ldap = NewLdapService(c.Ldap)
+	svcCtx.ApiTokens = NewApiTokens(svcCtx.Sqlite, c.Auth.AccessSecret)
+	svcCtx.ApiToken = middleware.NewApiTokenMiddleware(svcCtx.ApiTokens.Check).Handle
+	svcCtx.Auditor = NewAuditor(svcCtx.Sqlite)
+	svcCtx.Audit = middleware.NewAuditMiddleware(svcCtx.Auditor.Record).Handle
+	svcCtx.TaskEvents = NewTaskEventHub(svcCtx.Sqlite)
+	svcCtx.Notifier = NewNotifier(svcCtx.Sqlite)
+	svcCtx.TaskEvents.OnTask(svcCtx.Notifier.OnTask)
+	svcCtx.StaticAgents = NewStaticAgents(svcCtx)
+	if c.Rpc.Tls.Enabled() {
+		tlsConfig, err := c.Rpc.Tls.ClientConfig()
+		if err != nil {
+			logx.Errorf("Failed to load tls config of rpc: %v", err)
+			os.Exit(0)
+		}
+		svcCtx.agentCreds = credentials.NewTLS(tlsConfig)
+	}
+	if !c.Rpc.Tls.Enabled() && c.Rpc.Secret == "" {
+		logx.Errorf("Neither Rpc.Tls nor Rpc.Secret is set, the rpcs to lizardcd-agent are not authenticated")
+	}
 	if c.Etcd.Address != "" {
 		etcdHosts := strings.Split(c.Etcd.Address, ",")
 		client, err := clientv3.New(clientv3.Config{
@@ -91,22 +138,72 @@ func NewServiceContext(c config.Config) *ServiceContext {
 	return svcCtx
 }
 
+// AgentClientOptions returns the zrpc client options of the agent of service, including keepalive, authentication and audit
+func (s *ServiceContext) AgentClientOptions(service string) []zrpc.ClientOption {
+	opts := s.agentClientOptions(service, s.agentCreds != nil)
+	if s.agentCreds != nil {
+		opts = append(opts, zrpc.WithTransportCredentials(s.agentCreds))
+	}
+	return opts
+}
+
+// TunnelClientOptions returns the zrpc client options of the agent of service connected by tunnel conn,
+// which is protected by the tls of the tunnel instead of Rpc.Tls
+func (s *ServiceContext) TunnelClientOptions(service string, conn net.Conn) []zrpc.ClientOption {
+	var dialed int32
+	return append(s.agentClientOptions(service, false),
+		zrpc.WithDialOption(grpc.WithContextDialer(func(context.Context, string) (net.Conn, error) {
+			// the agent dials a new tunnel instead of being reconnected
+			if atomic.CompareAndSwapInt32(&dialed, 0, 1) {
+				return conn, nil
+			}
+			return nil, errors.New("tunnel is closed")
+		})),
+		zrpc.WithDialOption(grpc.WithIdleTimeout(0)),
+	)
+}
+
+func (s *ServiceContext) agentClientOptions(service string, secure bool) []zrpc.ClientOption {
+	opts := []zrpc.ClientOption{
+		zrpc.WithDialOption(grpc.WithKeepaliveParams(keepalive.ClientParameters{
+			Time:                time.Duration(s.Config.Rpc.KeepaliveTime) * time.Second,
+			Timeout:             time.Second,
+			PermitWithoutStream: true,
+		})),
+		s.Auditor.AgentClientOption(service),
+	}
+	if s.Config.Rpc.Secret != "" {
+		opts = append(opts, zrpc.WithDialOption(grpc.WithPerRPCCredentials(utils.NewRpcCredentials(s.Config.Rpc.Secret, secure))))
+	}
+	return opts
+}
+
 func (s *ServiceContext) GetAgent(cluster, namespace string) (agent lizardagent.LizardAgent, err error) {
-	for k, v := range s.AgentList {
+	s.AgentList.Range(func(k string, v *types.RpcAgent) bool {
 		re, _ := regexp.Compile(k)
 		if re.MatchString(fmt.Sprintf("%slizardcd-agent.%s.%s", s.Config.ServicePrefix, namespace, cluster)) {
-			return v.Client, nil
+			agent = v.Client
+			return false
 		}
+		return true
+	})
+	if agent != nil {
+		return
 	}
 	return nil, errorx.NewDefaultError("Cannot find lizardcd-agent of cluster=%s namespace=%s, maybe the server cannot communicated with the agent", cluster, namespace)
 }
 
 func (s *ServiceContext) GetTargetAgent(ip string) (agent lizardagent.LizardAgent, err error) {
-	for k, v := range s.AgentList {
-		re, _ := regexp.Compile(fmt.Sprintf("%slizardcd-agent_vm\\.(.+?)\\.%s", s.Config.ServicePrefix, ip))
+	re, _ := regexp.Compile(fmt.Sprintf("%slizardcd-agent_vm\\.(.+?)\\.%s", s.Config.ServicePrefix, ip))
+	s.AgentList.Range(func(k string, v *types.RpcAgent) bool {
 		if re.MatchString(k) {
-			return v.Client, nil
+			agent = v.Client
+			return false
 		}
+		return true
+	})
+	if agent != nil {
+		return
 	}
 	return nil, errorx.NewDefaultError("Cannot find lizardcd-agent of ip=%s, maybe the server cannot communicated with the agent", ip)
 }
@@ -134,3 +231,29 @@ func (s *ServiceContext) GetHelmSettings(tenant string) (wait bool, timeout int6
 	}
 	return
 }
+
+// GetTaskSettings returns the task timeout and intervals of a tenant in seconds, 0 means not set
+func (s *ServiceContext) GetTaskSettings(tenant string) (timeout, initialDelay, pollInterval int64, err error) {
+	var settings []commontypes.Settings
+	if err = s.Sqlite.Where("tenant = ?", tenant).Find(&settings).Error; err != nil {
+		return
+	}
+	for _, s := range settings {
+		switch s.SettingKey {
+		case "task_timeout":
+			timeout, _ = strconv.ParseInt(s.SettingValue, 10, 64)
+		case "task_initial_delay":
+			initialDelay, _ = strconv.ParseInt(s.SettingValue, 10, 64)
+		case "task_poll_interval":
+			pollInterval, _ = strconv.ParseInt(s.SettingValue, 10, 64)
+		}
+	}
+	return
+}
+
+// GetDeployWindows returns the allowed deploy windows of a tenant, empty means no limit
+func (s *ServiceContext) GetDeployWindows(tenant string) (windows string, err error) {
+	var setting commontypes.Settings
+	err = s.Sqlite.Where("tenant = ? AND setting_key = ?", tenant, "deploy_windows").Limit(1).Find(&setting).Error
+	return setting.SettingValue, err
+}
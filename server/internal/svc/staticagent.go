@@ -0,0 +1,172 @@
+package svc
+
+import (
+	"context"
+	"net"
+	"regexp"
+	"strings"
+	"sync"
+
+	"github.com/hongyuxuan/lizardcd/agent/lizardagent"
+	"github.com/hongyuxuan/lizardcd/common/errorx"
+	commontypes "github.com/hongyuxuan/lizardcd/common/types"
+	"github.com/hongyuxuan/lizardcd/common/utils"
+	"github.com/hongyuxuan/lizardcd/server/internal/config"
+	"github.com/hongyuxuan/lizardcd/server/internal/types"
+	"github.com/zeromicro/go-zero/core/logx"
+	"github.com/zeromicro/go-zero/zrpc"
+)
+
+// StaticAgents keeps the agents of Agents in config file and table static_agent in AgentList, as the watchers of registries do
+type StaticAgents struct {
+	svcCtx       *ServiceContext
+	lock         sync.Mutex
+	configAgents []config.StaticAgentConf
+}
+
+// StaticAgentStatus is a static agent and its connectivity
+type StaticAgentStatus struct {
+	commontypes.StaticAgent
+	Source string `json:"source"` // config or sqlite, agents of config can only be changed by the config file
+	State  string `json:"state"`  // state of the grpc connection, empty if the key is taken by an agent of registry or tunnel
+}
+
+func NewStaticAgents(svcCtx *ServiceContext) *StaticAgents {
+	return &StaticAgents{
+		svcCtx:       svcCtx,
+		configAgents: svcCtx.Config.Agents,
+	}
+}
+
+// SetConfigAgents replaces the agents of config file, e.g. the file is reloaded
+func (s *StaticAgents) SetConfigAgents(agents []config.StaticAgentConf) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.configAgents = agents
+}
+
+// InConfig reports whether key is an agent of config file
+func (s *StaticAgents) InConfig(key string) bool {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	for _, agent := range s.configAgents {
+		if agent.Key == key {
+			return true
+		}
+	}
+	return false
+}
+
+// List returns the agents of config file and table static_agent
+func (s *StaticAgents) List(ctx context.Context) (agents []StaticAgentStatus, err error) {
+	var rows []commontypes.StaticAgent
+	if err = s.svcCtx.Sqlite.WithContext(context.WithValue(ctx, commontypes.TraceIDKey{}, "sqlite.ListStaticAgent")).Order("id").Find(&rows).Error; err != nil {
+		return
+	}
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	agents = []StaticAgentStatus{}
+	for _, agent := range s.configAgents {
+		agents = append(agents, s.status(commontypes.StaticAgent{ServiceKey: agent.Key, Address: agent.Address}, "config"))
+	}
+	for _, agent := range rows {
+		agents = append(agents, s.status(agent, "sqlite"))
+	}
+	return
+}
+
+func (s *StaticAgents) status(agent commontypes.StaticAgent, source string) StaticAgentStatus {
+	st := StaticAgentStatus{
+		StaticAgent: agent,
+		Source:      source,
+	}
+	if v, ok := s.svcCtx.AgentList.Get(s.svcCtx.Config.ServicePrefix + agent.ServiceKey); ok && v.ServiceSource == "static" && v.Address == agent.Address {
+		st.State = v.Cli.Conn().GetState().String()
+	}
+	return st
+}
+
+// Sync adds, updates and removes the static agents in AgentList, agents of config file take precedence over table static_agent.
+// Agents of config file are still synced if table static_agent cannot be read, e.g. not migrated.
+func (s *StaticAgents) Sync(ctx context.Context) (err error) {
+	var rows []commontypes.StaticAgent
+	if err = s.svcCtx.Sqlite.WithContext(context.WithValue(ctx, commontypes.TraceIDKey{}, "sqlite.ListStaticAgent")).Find(&rows).Error; err != nil {
+		rows = nil
+	}
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	// full key with ServicePrefix => address
+	agents := make(map[string]string)
+	for _, agent := range rows {
+		agents[s.svcCtx.Config.ServicePrefix+agent.ServiceKey] = agent.Address
+	}
+	for _, agent := range s.configAgents {
+		if err := ValidateStaticAgent(agent.Key, agent.Address); err != nil {
+			logx.Errorf("Invalid static agent of config: %v", err)
+			continue
+		}
+		agents[s.svcCtx.Config.ServicePrefix+agent.Key] = agent.Address
+	}
+
+	var removed []*types.RpcAgent
+	s.svcCtx.AgentList.Update(func(list map[string]*types.RpcAgent) {
+		for key, agent := range list {
+			if agent.ServiceSource != "static" {
+				continue
+			}
+			if address, ok := agents[key]; !ok || address != agent.Address {
+				delete(list, key)
+				removed = append(removed, agent)
+				logx.Infof("Lizardcd-agent: %s removed from static agents", key)
+			}
+		}
+	})
+	for _, agent := range removed {
+		agent.Cli.Conn().Close()
+	}
+	for key, address := range agents {
+		if _, ok := s.svcCtx.AgentList.Get(key); ok {
+			continue // unchanged, or registered by registry or tunnel
+		}
+		// not blocking, the agent may be started later
+		cli, err := zrpc.NewClient(zrpc.RpcClientConf{
+			Timeout:   s.svcCtx.Config.Rpc.Timeout,
+			Endpoints: []string{address},
+			NonBlock:  true,
+		}, s.svcCtx.AgentClientOptions(key)...)
+		if err != nil {
+			logx.Errorf("Failed to connect to lizardcd-agent: %s at %s: %v", key, address, err)
+			continue
+		}
+		if !s.svcCtx.AgentList.Add(key, &types.RpcAgent{
+			Client:        lizardagent.NewLizardAgent(cli),
+			ServiceSource: "static",
+			Cli:           cli,
+			Count:         1,
+			Address:       address,
+		}) {
+			cli.Conn().Close() // registered by registry or tunnel meanwhile
+			continue
+		}
+		logx.Infof("A new lizardcd-agent: %s added to static agents at %s", key, address)
+	}
+	return
+}
+
+// ValidateStaticAgent checks key is lizardcd-agent.<namespace>.<cluster> and address is host:port
+func ValidateStaticAgent(key, address string) error {
+	if !strings.HasPrefix(key, "lizardcd-agent") {
+		return errorx.NewDefaultError("Key \"%s\" must be lizardcd-agent.<namespace>.<cluster> without ServicePrefix", key)
+	}
+	if _, err := utils.GetServiceMata("", key); err != nil {
+		return err
+	}
+	if _, err := regexp.Compile(key); err != nil { // keys are matched as regexps, see GetAgent
+		return errorx.NewDefaultError("Invalid key \"%s\": %v", key, err)
+	}
+	if _, _, err := net.SplitHostPort(address); err != nil {
+		return errorx.NewDefaultError("Invalid address \"%s\" of %s: %v", address, key, err)
+	}
+	return nil
+}
@@ -0,0 +1,109 @@
+package svc
+
+import (
+	"sync"
+
+	commontypes "github.com/hongyuxuan/lizardcd/common/types"
+	"github.com/zeromicro/go-zero/core/logx"
+	"gorm.io/gorm"
+)
+
+const TASK_EVENT_TASK = "task"
+const TASK_EVENT_WORKLOAD = "workload"
+
+type TaskEvent struct {
+	Event string      `json:"event"`
+	Data  interface{} `json:"data"`
+}
+
+// TaskEventHub sends state transitions of tasks to the subscribers, e.g. streaming task progress to clients
+type TaskEventHub struct {
+	db          *gorm.DB
+	mu          sync.Mutex
+	subscribers map[string]map[chan TaskEvent]bool
+	listeners   []func(task commontypes.TaskHistory) // called with every task update, e.g. sending notifications
+}
+
+func NewTaskEventHub(db *gorm.DB) *TaskEventHub {
+	return &TaskEventHub{
+		db:          db,
+		subscribers: make(map[string]map[chan TaskEvent]bool),
+	}
+}
+
+// OnTask adds a listener of all tasks, which must not block
+func (h *TaskEventHub) OnTask(listener func(task commontypes.TaskHistory)) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.listeners = append(h.listeners, listener)
+}
+
+func (h *TaskEventHub) Subscribe(taskId string) chan TaskEvent {
+	ch := make(chan TaskEvent, 100)
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.subscribers[taskId] == nil {
+		h.subscribers[taskId] = make(map[chan TaskEvent]bool)
+	}
+	h.subscribers[taskId][ch] = true
+	return ch
+}
+
+func (h *TaskEventHub) Unsubscribe(taskId string, ch chan TaskEvent) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	delete(h.subscribers[taskId], ch)
+	if len(h.subscribers[taskId]) == 0 {
+		delete(h.subscribers, taskId)
+	}
+}
+
+func (h *TaskEventHub) hasSubscribers(taskId string) bool {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return len(h.subscribers[taskId]) > 0
+}
+
+func (h *TaskEventHub) publish(taskId string, event TaskEvent) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for ch := range h.subscribers[taskId] {
+		select {
+		case ch <- event:
+		default: // never block the task runner by a slow subscriber
+			logx.Errorf("Task event channel is full, drop event=%s of task id=%s", event.Event, taskId)
+		}
+	}
+}
+
+// PublishTask sends the latest task_history (without workloads) to the listeners and subscribers
+func (h *TaskEventHub) PublishTask(taskId string) {
+	h.mu.Lock()
+	listeners := h.listeners
+	h.mu.Unlock()
+	if len(listeners) == 0 && !h.hasSubscribers(taskId) {
+		return
+	}
+	var task commontypes.TaskHistory
+	if err := h.db.Where("id = ?", taskId).First(&task).Error; err != nil {
+		logx.Error(err)
+		return
+	}
+	for _, listener := range listeners {
+		listener(task)
+	}
+	h.publish(taskId, TaskEvent{Event: TASK_EVENT_TASK, Data: task})
+}
+
+// PublishWorkload sends the latest task_history_workload to the subscribers
+func (h *TaskEventHub) PublishWorkload(taskId string, id int) {
+	if !h.hasSubscribers(taskId) {
+		return
+	}
+	var taskWorkload commontypes.TaskHistoryWorkload
+	if err := h.db.Where("id = ?", id).First(&taskWorkload).Error; err != nil {
+		logx.Error(err)
+		return
+	}
+	h.publish(taskId, TaskEvent{Event: TASK_EVENT_WORKLOAD, Data: taskWorkload})
+}
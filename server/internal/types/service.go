@@ -10,6 +10,7 @@ type RpcAgent struct {
 	ServiceSource string
 	Cli           zrpc.Client
 	Count         int
+	Address       string // address of the static agent, or the remote address of the agent connected by tunnel
 }
 
 type HttpcheckResponse struct {
@@ -20,6 +20,13 @@ type LoginReq struct {
 	Password string `json:"password"`
 }
 
+type OidcCallbackReq struct {
+	Code             string `form:"code,optional"`
+	State            string `form:"state,optional"`
+	Error            string `form:"error,optional"`
+	ErrorDescription string `form:"error_description,optional"`
+}
+
 type ChpasswdReq struct {
 	Username    string `json:"username"`
 	OldPassword string `json:"oldPassword"`
@@ -27,9 +34,10 @@ type ChpasswdReq struct {
 }
 
 type AddUserReq struct {
-	Username string `json:"username"`
-	Role     string `json:"role"`
-	Tenant   string `json:"tenant"`
+	Username string   `json:"username"`
+	Role     string   `json:"role"`
+	Tenant   string   `json:"tenant"`
+	Groups   []string `json:"groups,optional"`
 }
 
 type GetDataReq struct {
@@ -182,6 +190,9 @@ type RunTaskReq struct {
 	Labels      []string       `json:"labels,optional"`
 	Workloads   []TaskWorkload `json:"workloads,optional"`
 	ArtifactUrl string         `json:"artifact_url,optional"` // HTTP部署用
+	Wait        bool           `json:"wait,optional"`         // 同步等待任务结束
+	Timeout     int            `json:"timeout,optional"`      // 同步等待超时时间(秒)，不超过服务端Task.WaitTimeout
+	Strategy    string         `json:"strategy,optional"`     // 发布策略: rolling, canary, bluegreen，为空则使用应用的发布策略
 }
 
 type TaskWorkload struct {
@@ -197,6 +208,30 @@ type TaskIdReq struct {
 	Id string `path:"id"`
 }
 
+type CancelTaskReq struct {
+	Id       string `path:"id"`
+	Rollback bool   `json:"rollback,optional"` // 回滚已更新的工作负载
+}
+
+type ApproveTaskReq struct {
+	Id     string `path:"id"`              // 流水线任务或阶段任务ID
+	Reason string `json:"reason,optional"` // 拒绝原因
+}
+
+type ScheduleTaskReq struct {
+	Id          string         `path:"id,optional"`
+	Name        string         `json:"name"`
+	AppName     string         `json:"app_name"`
+	TaskType    string         `json:"task_type"`
+	Cron        string         `json:"cron,optional"`   // cron表达式，例如 "0 2 * * *"，与run_at二选一
+	RunAt       string         `json:"run_at,optional"` // 一次性执行时间，格式 2006-01-02 15:04:05
+	Enabled     bool           `json:"enabled,default=true"`
+	Labels      []string       `json:"labels,optional"`
+	Workloads   []TaskWorkload `json:"workloads,optional"`
+	ArtifactUrl string         `json:"artifact_url,optional"` // HTTP部署用
+	Strategy    string         `json:"strategy,optional"`
+}
+
 type VmDeployReq struct {
 	ArtifactUrl    string            `json:"artifact_url"`
 	ArtifactHeader map[string]string `json:"artifact_header"`
@@ -250,3 +285,103 @@ type HttpCheckReq struct {
 	HttpHeader map[string]string `json:"http_header"`
 	HttpCheck
 }
+
+type HarborWebhookReq struct {
+	AppName       string          `path:"app_name"`
+	Secret        string          `form:"secret,optional"`
+	Authorization string          `header:"Authorization,optional"` // Harbor webhook的Auth Header
+	Type          string          `json:"type"`                     // 仅处理 PUSH_ARTIFACT 事件
+	Operator      string          `json:"operator,optional"`
+	EventData     HarborEventData `json:"event_data"`
+}
+
+type HarborEventData struct {
+	Resources  []HarborResource `json:"resources,optional"`
+	Repository HarborRepository `json:"repository"`
+}
+
+type HarborResource struct {
+	Digest      string `json:"digest,optional"`
+	Tag         string `json:"tag,optional"`
+	ResourceUrl string `json:"resource_url,optional"`
+}
+
+type HarborRepository struct {
+	Name         string `json:"name,optional"`
+	Namespace    string `json:"namespace,optional"`
+	RepoFullName string `json:"repo_full_name,optional"`
+}
+
+type DockerHubWebhookReq struct {
+	AppName    string              `path:"app_name"`
+	Secret     string              `form:"secret,optional"` // Docker Hub webhook不支持自定义header，只能通过query传递
+	PushData   DockerHubPushData   `json:"push_data"`
+	Repository DockerHubRepository `json:"repository"`
+}
+
+type DockerHubPushData struct {
+	Tag    string `json:"tag"`
+	Pusher string `json:"pusher,optional"`
+}
+
+type DockerHubRepository struct {
+	RepoName  string `json:"repo_name,optional"`
+	Name      string `json:"name,optional"`
+	Namespace string `json:"namespace,optional"`
+}
+
+type GenericWebhookReq struct {
+	AppName       string   `path:"app_name"`
+	Secret        string   `form:"secret,optional"`
+	Authorization string   `header:"Authorization,optional"`
+	Tag           string   `json:"tag"`
+	ArtifactUrl   string   `json:"artifact_url,optional"` // 根据应用的镜像仓库和tag生成，不为空时必须与生成的地址一致
+	Labels        []string `json:"labels,optional"`
+}
+
+type ListRbacPolicyReq struct {
+	SubjectType string `form:"subject_type,optional"`
+	Subject     string `form:"subject,optional"`
+}
+
+type RbacPolicyReq struct {
+	Id          int      `path:"id,optional"`
+	Name        string   `json:"name,optional"`
+	SubjectType string   `json:"subject_type"`       // user, group
+	Subject     string   `json:"subject"`            // 用户名或用户组
+	Cluster     string   `json:"cluster,optional"`   // 集群匹配模式，例如 prod-*，为空匹配全部
+	Namespace   string   `json:"namespace,optional"` // 命名空间匹配模式，为空匹配全部
+	Resource    string   `json:"resource"`           // 资源类型，例如 deployments, helm, task, * 匹配全部
+	Verbs       []string `json:"verbs"`              // 操作，例如 get, scale, delete, * 匹配全部
+	Effect      string   `json:"effect,default=allow,options=allow|deny"`
+}
+
+type RbacPolicyIdReq struct {
+	Id int `path:"id"`
+}
+
+type ListApiTokenReq struct {
+	Tenant string `form:"tenant,optional"`
+}
+
+type ApiTokenReq struct {
+	Name         string   `json:"name"`
+	Role         string   `json:"role,options=admin|readwrite|readonly"`
+	Tenant       string   `json:"tenant,optional"`       // 为空则为当前用户的租户
+	Applications []string `json:"applications,optional"` // 只允许运行这些应用的任务，为空则不限制
+	ExpireAt     string   `json:"expire_at,optional"`    // 过期时间，格式 2006-01-02 15:04:05，为空则永不过期
+}
+
+type ApiTokenIdReq struct {
+	Id string `path:"id"`
+}
+
+type StaticAgentReq struct {
+	Id         int    `path:"id,optional"`
+	ServiceKey string `json:"service_key"` // lizardcd-agent.<namespace>.<cluster>，不包含ServicePrefix
+	Address    string `json:"address"`     // agent的grpc地址，例如 10.50.89.20:5017
+}
+
+type StaticAgentIdReq struct {
+	Id int `path:"id"`
+}
@@ -7,6 +7,7 @@ import (
 	"github.com/hongyuxuan/lizardcd/common/errorx"
 	"github.com/hongyuxuan/lizardcd/server/internal/config"
 	"github.com/hongyuxuan/lizardcd/server/internal/handler"
+	"github.com/hongyuxuan/lizardcd/server/internal/middleware"
 	"github.com/hongyuxuan/lizardcd/server/internal/svc"
 	"gopkg.in/alecthomas/kingpin.v2"
 
@@ -34,6 +35,14 @@ var (
 	dbfile           = kingpin.Flag("db", "SQLite database file.").Default("").String()
 	accessSecret     = kingpin.Flag("access.secret", "Jwt token accessSecret.").Default("").String()
 	accessExpire     = kingpin.Flag("access.expire", "Jwt token expire time.").Int64()
+	rpcTlsCert       = kingpin.Flag("rpc.tls-cert", "Client certificate of mutual tls to lizardcd-agent.").Default("").String()
+	rpcTlsKey        = kingpin.Flag("rpc.tls-key", "Client key of mutual tls to lizardcd-agent.").Default("").String()
+	rpcTlsCa         = kingpin.Flag("rpc.tls-ca", "CA verifying the certificates of lizardcd-agent.").Default("").String()
+	rpcSecret        = kingpin.Flag("rpc.secret", "Shared secret signing the rpc tokens to lizardcd-agent.").Envar("LIZARDCD_RPC_SECRET").Default("").String()
+	tunnelListenOn   = kingpin.Flag("tunnel.addr", "Tunnel listen address, which lizardcd-agent dials out to in tunnel mode.").Default("").String()
+	tunnelTlsCert    = kingpin.Flag("tunnel.tls-cert", "Server certificate of tunnel.").Default("").String()
+	tunnelTlsKey     = kingpin.Flag("tunnel.tls-key", "Server key of tunnel.").Default("").String()
+	tunnelTlsCa      = kingpin.Flag("tunnel.tls-ca", "CA verifying the client certificates of lizardcd-agent on tunnel, whose common name or dns name must be the service key of the agent.").Default("").String()
 
 	/* print app version */
 	AppVersion = "unknown"
@@ -65,7 +74,15 @@ func main() {
 		metricsListenOn,
 		dbfile,
 		accessSecret,
-		accessExpire)
+		accessExpire,
+		rpcTlsCert,
+		rpcTlsKey,
+		rpcTlsCa,
+		rpcSecret,
+		tunnelListenOn,
+		tunnelTlsCert,
+		tunnelTlsKey,
+		tunnelTlsCa)
 
 	logx.DisableStat()
 	logx.MustSetup(c.Log)
@@ -77,7 +94,10 @@ func main() {
 
 	ctx := svc.NewServiceContext(c)
 	ctx.SetVersion(AppVersion)
+	server.Use(ctx.Audit)
+	server.Use(ctx.ApiToken)
 	handler.RegisterHandlers(server, ctx)
+	middleware.SetRoutes(server.Routes())
 
 	httpx.SetErrorHandler(func(err error) (int, interface{}) {
 		switch e := err.(type) {
@@ -100,6 +120,13 @@ func main() {
 	if c.Nacos.Address != "" {
 		go handler.StartNacosWatch(ctx)
 	}
+	if c.Tunnel.ListenOn != "" {
+		go handler.StartTunnel(ctx)
+	}
+	go handler.StartStaticAgentWatch(ctx, *configFile)
+	go handler.StartTaskRecover(ctx)
+	go handler.StartScheduler(ctx)
+	go handler.StartImageUpdater(ctx)
 
 	logx.Infof("Starting server at %s:%d...", c.Host, c.Port)
 	server.Start()